@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/bundle"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/handlers"
+)
+
+const bundleUsage = `Usage:
+  %[1]s bundle export [-format yaml|json] [-o FILE]
+  %[1]s bundle plan   -f FILE [-detailed-exitcode]
+  %[1]s bundle apply  -f FILE [-author NAME]
+
+plan prints the changes as JSON. With -detailed-exitcode it exits 2 when
+there are changes, 0 when the database already matches, 1 on errors.
+`
+
+// runBundle implements the "bundle" subcommand and returns the exit code.
+func runBundle(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, bundleUsage, os.Args[0])
+		return 1
+	}
+
+	fs := flag.NewFlagSet("bundle "+args[0], flag.ContinueOnError)
+	format := fs.String("format", "yaml", "export format: yaml or json")
+	output := fs.String("o", "", "export to FILE instead of stdout")
+	file := fs.String("f", "", "bundle FILE to plan or apply")
+	detailed := fs.Bool("detailed-exitcode", false, "plan: exit 2 when there are changes")
+	author := fs.String("author", defaultBundleAuthor(), "apply: author recorded in rule revisions")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "export":
+		b, err := handlers.ExportRuleBundle(ctx)
+		if err != nil {
+			return fail(err)
+		}
+		out, err := bundle.Encode(b, *format)
+		if err != nil {
+			return fail(err)
+		}
+		if *output == "" {
+			os.Stdout.Write(out)
+			return 0
+		}
+		if err := os.WriteFile(*output, out, 0o644); err != nil {
+			return fail(err)
+		}
+		return 0
+
+	case "plan", "apply":
+		if *file == "" {
+			return fail(fmt.Errorf("-f is required"))
+		}
+		raw, err := os.ReadFile(*file)
+		if err != nil {
+			return fail(err)
+		}
+		b, err := bundle.Decode(raw)
+		if err != nil {
+			return fail(err)
+		}
+
+		var plan *bundle.Plan
+		if args[0] == "plan" {
+			plan, err = handlers.PlanRuleBundle(ctx, b)
+		} else {
+			plan, err = handlers.ApplyRuleBundle(ctx, b, *author)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(plan)
+		if args[0] == "plan" && *detailed && !plan.Empty() {
+			return 2
+		}
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, bundleUsage, os.Args[0])
+	return 1
+}
+
+func defaultBundleAuthor() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "cli"
+}
+
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, "bundle:", err)
+	return 1
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"log"
 	"os"
 	"time"
 
@@ -8,6 +10,11 @@ import (
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/handlers"
     "github.com/ruby4mag/alertmanager-go-backend-ui/internal/ai"
     "github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+    "github.com/ruby4mag/alertmanager-go-backend-ui/internal/grpcingest"
+    "github.com/ruby4mag/alertmanager-go-backend-ui/internal/rulestats"
+    "github.com/ruby4mag/alertmanager-go-backend-ui/internal/alias"
+    "github.com/ruby4mag/alertmanager-go-backend-ui/internal/snmptrap"
+    "github.com/ruby4mag/alertmanager-go-backend-ui/internal/syslog"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -17,6 +24,11 @@ import (
 
 
 func main() {
+	// "bundle" runs the rule import/export CLI instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "bundle" {
+		os.Exit(runBundle(os.Args[2:]))
+	}
+
     // Init AI Clients
     // TODO: move to env vars
     ai.InitAI("http://localhost:6333", "http://localhost:11434", "nomic-embed-text")
@@ -41,6 +53,64 @@ func main() {
     }
     db.InitNeo4j(neo4jURI, neo4jUser, neo4jPassword)
 
+	// One open alert per fingerprint, even under concurrent ingestion
+	indexCtx, cancelIndex := context.WithTimeout(context.Background(), time.Minute)
+	if err := handlers.EnsureAlertIndexes(indexCtx); err != nil {
+		log.Printf("Creating alert fingerprint index failed: %v", err)
+	}
+	cancelIndex()
+
+	// Syslog listener (disabled unless SYSLOG_*_ADDR is set)
+	if err := syslog.Start(syslog.ConfigFromEnv(), handlers.IngestAlert); err != nil {
+		log.Fatalf("Syslog listener failed to start: %v", err)
+	}
+
+	// SNMP trap receiver (disabled unless SNMP_TRAP_ADDR is set)
+	trapPipeline := snmptrap.Pipeline{
+		Ingest:  handlers.IngestAlert,
+		Resolve: handlers.ResolveAlert,
+		Load:    handlers.LoadSNMPTrapConfig,
+	}
+	if err := snmptrap.Start(snmptrap.ConfigFromEnv(), trapPipeline); err != nil {
+		log.Fatalf("SNMP trap receiver failed to start: %v", err)
+	}
+
+	// gRPC ingestion (disabled unless GRPC_ADDR is set)
+	grpcPipeline := grpcingest.Pipeline{
+		Ingest:      handlers.IngestAlert,
+		Fingerprint: handlers.FingerprintAlert,
+	}
+	if err := grpcingest.Start(grpcingest.ConfigFromEnv(), grpcPipeline); err != nil {
+		log.Fatalf("gRPC server failed to start: %v", err)
+	}
+
+	// Heal rules saved in alertrules by earlier versions move to healrules,
+	// and alert rules saved with a single setfield/setvalue get an actions list
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), time.Minute)
+	if n, err := handlers.MigrateHealRules(migrateCtx); err != nil {
+		log.Printf("Migrating heal rules failed: %v", err)
+	} else if n > 0 {
+		log.Printf("Migrated %d heal rules to healrules", n)
+	}
+	if n, err := handlers.MigrateAlertRules(migrateCtx); err != nil {
+		log.Printf("Migrating alert rules failed: %v", err)
+	} else if n > 0 {
+		log.Printf("Migrated %d alert rules to actions", n)
+	}
+	cancelMigrate()
+
+	// Flush rule hit counters from Redis to Mongo
+	rulestats.Start()
+
+	// Entity aliases: periodic reload and Neo4j sync
+	alias.Start()
+
+	// Near-duplicate indexes of open alerts for similarity correlation
+	handlers.StartSimilarityIndexes()
+
+	// Embeddings of stored alerts for EMBEDDING correlation rules
+	handlers.StartEmbeddingWorker()
+
 	noderedEndpoint := os.Getenv("NODERED_ENDPOINT")
 	if noderedEndpoint == "" {
 		noderedEndpoint = "http://localhost:1880/notifications"
@@ -62,6 +132,9 @@ func main() {
 	r.POST("/login", handlers.Login)
 	r.POST("/refresh", handlers.RefreshToken)
 	r.POST("/alerts/callback", handlers.AlertCallback)
+	r.POST("/alerts/alertmanager", handlers.AlertmanagerWebhook)
+	r.POST("/ingest/:source", handlers.IngestFromSource)
+	r.POST("/v1/logs", handlers.OTLPLogs)
 	r.GET("/entity/:name", handlers.HandleEntityGraph)
 
 	protected := r.Group("/api")
@@ -78,6 +151,7 @@ func main() {
 		protected.POST("/healrules", handlers.NewHeal)
 		protected.GET("/healrules/:id", handlers.EditHeal)
 		protected.PUT("/healrules/:id", handlers.UpdateHeal)
+		protected.POST("/healrules/:id/hits", handlers.RecordHealHit)
 
 		protected.GET("/notifyrules", handlers.IndexNotify)
 		protected.POST("/notifyrules", handlers.NewNotify)
@@ -94,6 +168,55 @@ func main() {
 		protected.GET("/correlationrules/:id", handlers.EditCorrelation)
 		protected.PUT("/correlationrules/:id", handlers.UpdateCorrelation)
 
+		// Lifecycle and revision history shared by every rule collection
+		for _, name := range handlers.RuleCollections {
+			protected.DELETE("/"+name+"/:id", handlers.DeleteRule(name))
+			protected.PUT("/"+name+"/:id/enabled", handlers.SetRuleEnabled(name))
+			protected.PUT("/"+name+"/order", handlers.ReorderRules(name))
+			protected.GET("/"+name+"/:id/revisions", handlers.ListRevisions(name))
+			protected.GET("/"+name+"/:id/revisions/:version", handlers.GetRevision(name))
+			protected.GET("/"+name+"/:id/diff", handlers.DiffRevisions(name))
+			protected.POST("/"+name+"/:id/rollback/:version", handlers.RollbackRevision(name))
+		}
+
+		protected.POST("/rules/simulate", handlers.SimulateRules)
+		protected.GET("/rules/stale", handlers.StaleRules)
+		protected.GET("/rules/analysis", handlers.AnalyzeRules)
+
+		protected.GET("/bundle", handlers.ExportBundle)
+		protected.POST("/bundle/plan", handlers.PlanBundle)
+		protected.POST("/bundle/apply", handlers.ApplyBundle)
+
+		protected.GET("/ingestsources", handlers.IndexIngestSource)
+		protected.POST("/ingestsources", handlers.NewIngestSource)
+		protected.GET("/ingestsources/:id", handlers.EditIngestSource)
+		protected.PUT("/ingestsources/:id", handlers.UpdateIngestSource)
+		protected.DELETE("/ingestsources/:id", handlers.DeleteIngestSource)
+
+		protected.GET("/lookuptables", handlers.IndexLookupTable)
+		protected.POST("/lookuptables", handlers.NewLookupTable)
+		protected.GET("/lookuptables/:id", handlers.EditLookupTable)
+		protected.PUT("/lookuptables/:id", handlers.UpdateLookupTable)
+		protected.DELETE("/lookuptables/:id", handlers.DeleteLookupTable)
+		protected.POST("/lookuptables/:id/import", handlers.ImportLookupTable)
+
+		protected.GET("/calendars", handlers.IndexCalendar)
+		protected.POST("/calendars", handlers.NewCalendar)
+		protected.GET("/calendars/:id", handlers.EditCalendar)
+		protected.PUT("/calendars/:id", handlers.UpdateCalendar)
+		protected.DELETE("/calendars/:id", handlers.DeleteCalendar)
+		protected.GET("/calendars/:id/status", handlers.CalendarStatus)
+
+		protected.GET("/aliases", handlers.IndexEntityAlias)
+		protected.POST("/aliases", handlers.NewEntityAlias)
+		protected.GET("/aliases/resolve", handlers.ResolveEntityAlias)
+		protected.POST("/aliases/sync", handlers.SyncEntityAliases)
+		protected.PUT("/aliases/:id", handlers.UpdateEntityAlias)
+		protected.DELETE("/aliases/:id", handlers.DeleteEntityAlias)
+
+		protected.GET("/snmptrap/config", handlers.GetSNMPTrapConfig)
+		protected.PUT("/snmptrap/config", handlers.UpdateSNMPTrapConfig)
+
 		// PagerDuty endpoints
 		protected.GET("/pagerduty/services", handlers.GetPagerDutyServices)
 		protected.GET("/pagerduty/escalation-policies", handlers.GetPagerDutyEscalationPolicies)
@@ -112,6 +235,10 @@ func main() {
         protected.GET("/v1/changes/risk", handlers.ListChangesWithRisk)
         protected.GET("/v1/changes/:change_id", handlers.GetChangeDetail)
 
+        // Alert ingestion
+        protected.POST("/v1/alerts", handlers.IngestAlertHandler)
+        protected.POST("/v1/alerts/batch", handlers.IngestAlertBatchHandler)
+
         protected.GET("/v1/alerts/:alert_id/related-changes", handlers.GetRelatedChanges)
         protected.POST("/v1/alerts/:id/rca/trigger", handlers.TriggerAIRCA)
         
@@ -2,6 +2,7 @@ package ai
 
 import (
     "bytes"
+    "context"
     "encoding/json"
     "fmt"
     "io"
@@ -43,6 +44,12 @@ type EmbeddingResponse struct {
 }
 
 func GetEmbedding(text string) ([]float64, error) {
+    return GetEmbeddingContext(context.Background(), text)
+}
+
+// GetEmbeddingContext is GetEmbedding with a context, for callers that must
+// not wait on Ollama longer than the context allows.
+func GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
     url := fmt.Sprintf("%s/api/embeddings", OllamaStub.URL)
     payload := EmbeddingRequest{
         Model:  OllamaStub.Model,
@@ -54,7 +61,12 @@ func GetEmbedding(text string) ([]float64, error) {
         return nil, err
     }
 
-    resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := http.DefaultClient.Do(req)
     if err != nil {
         return nil, err
     }
@@ -0,0 +1,219 @@
+// Package alias resolves the names alerts and changes use for an entity
+// (short hostname, FQDN, IP address, cloud instance ID) to the canonical
+// entity, the name of its Neo4j node. Aliases are stored in the
+// "entityaliases" collection, filled from Neo4j node properties and the
+// manual API, and served from an in-memory snapshot.
+package alias
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionName = "entityaliases"
+
+var instanceIDPattern = regexp.MustCompile(`^i-[0-9a-f]{8,17}$`)
+
+// snapshot is the alias table held in memory.
+type snapshot struct {
+	canonical map[string]string   // lower-case alias -> entity
+	names     map[string][]string // lower-case entity -> its aliases
+}
+
+var (
+	mu      sync.RWMutex
+	current *snapshot
+
+	indexOnce sync.Once
+)
+
+// Collection returns the alias collection, creating its unique index once.
+func Collection(ctx context.Context) *mongo.Collection {
+	col := db.GetCollection(collectionName)
+	indexOnce.Do(func() {
+		_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "alias", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			log.Printf("Creating entity alias index failed: %v", err)
+		}
+	})
+	return col
+}
+
+// Normalize is the form aliases are stored and looked up in.
+func Normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(name), ".")))
+}
+
+// Kind guesses the kind of an alias from its form.
+func Kind(name string) string {
+	switch {
+	case net.ParseIP(name) != nil:
+		return models.AliasKindIP
+	case instanceIDPattern.MatchString(name):
+		return models.AliasKindInstanceID
+	case strings.Contains(name, "."):
+		return models.AliasKindFQDN
+	}
+	return models.AliasKindHostname
+}
+
+// Reload replaces the in-memory snapshot with the stored aliases.
+func Reload(ctx context.Context) error {
+	cursor, err := Collection(ctx).Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var records []models.DbEntityAlias
+	if err := cursor.All(ctx, &records); err != nil {
+		return err
+	}
+	s := &snapshot{canonical: make(map[string]string, len(records)), names: map[string][]string{}}
+	for _, r := range records {
+		s.canonical[r.Alias] = r.Entity
+		key := strings.ToLower(r.Entity)
+		s.names[key] = append(s.names[key], r.Alias)
+	}
+	mu.Lock()
+	current = s
+	mu.Unlock()
+	return nil
+}
+
+// load returns the snapshot, reading it on first use.
+func load(ctx context.Context) *snapshot {
+	mu.RLock()
+	s := current
+	mu.RUnlock()
+	if s != nil {
+		return s
+	}
+	if err := Reload(ctx); err != nil {
+		log.Printf("Loading entity aliases failed: %v", err)
+		// Do not retry on every alert; the refresh loop tries again
+		mu.Lock()
+		if current == nil {
+			current = &snapshot{canonical: map[string]string{}, names: map[string][]string{}}
+		}
+		mu.Unlock()
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Canonical returns the entity the first resolvable candidate refers to.
+// Every candidate is tried as given before any FQDN is tried by its short
+// hostname.
+func Canonical(ctx context.Context, candidates ...string) (string, bool) {
+	s := load(ctx)
+	for _, c := range candidates {
+		if entity, ok := s.canonical[Normalize(c)]; ok {
+			return entity, true
+		}
+	}
+	for _, c := range candidates {
+		n := Normalize(c)
+		if net.ParseIP(n) != nil {
+			continue
+		}
+		if short, _, ok := strings.Cut(n, "."); ok && short != "" {
+			if entity, ok := s.canonical[short]; ok {
+				return entity, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Resolve returns the canonical entity for name, or name itself when it has
+// no alias.
+func Resolve(ctx context.Context, name string) string {
+	if entity, ok := Canonical(ctx, name); ok {
+		return entity
+	}
+	return name
+}
+
+// Collation compares strings case-insensitively. Queries matching Names
+// against stored records must use it, since aliases are lower-cased and
+// records keep the case they were written in.
+var Collation = &options.Collation{Locale: "en", Strength: 2}
+
+// Names returns entity and every alias of it, for matching records that
+// were stored under any of them. Match them with Collation.
+func Names(ctx context.Context, entity string) []string {
+	s := load(ctx)
+	out := []string{entity}
+	seen := map[string]bool{entity: true}
+	for _, a := range s.names[strings.ToLower(entity)] {
+		if !seen[a] {
+			seen[a] = true
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Start refreshes the snapshot every ALIAS_REFRESH_INTERVAL (default 1m) and
+// syncs the aliases from Neo4j at startup and every ALIAS_SYNC_INTERVAL
+// (default 15m). An interval of 0 turns the loop off.
+func Start() {
+	refresh := durationEnv("ALIAS_REFRESH_INTERVAL", time.Minute)
+	syncEvery := durationEnv("ALIAS_SYNC_INTERVAL", 15*time.Minute)
+
+	if refresh > 0 {
+		go func() {
+			for range time.Tick(refresh) {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				if err := Reload(ctx); err != nil {
+					log.Printf("Refreshing entity aliases failed: %v", err)
+				}
+				cancel()
+			}
+		}()
+	}
+
+	if syncEvery <= 0 || db.Neo4jDriver == nil {
+		return
+	}
+	go func() {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			if result, err := SyncNeo4j(ctx); err != nil {
+				log.Printf("Syncing entity aliases from Neo4j failed: %v", err)
+			} else {
+				log.Printf("Synced entity aliases from Neo4j: %+v", result)
+			}
+			cancel()
+			time.Sleep(syncEvery)
+		}
+	}()
+}
+
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	s := os.Getenv(name)
+	if s == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		log.Printf("Ignoring %s %q", name, s)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,178 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultProperties are the node properties read as aliases unless
+// ALIAS_NEO4J_PROPERTIES lists others.
+const defaultProperties = "id,hostname,fqdn,ip,ip_address,ips,instance_id"
+
+// SyncResult counts what a Neo4j sync changed.
+type SyncResult struct {
+	Nodes     int `json:"nodes"`
+	Upserted  int `json:"upserted"`
+	Deleted   int `json:"deleted"`
+	Conflicts int `json:"conflicts"` // aliases claimed by several nodes; the first by name wins
+}
+
+func properties() []string {
+	raw := os.Getenv("ALIAS_NEO4J_PROPERTIES")
+	if raw == "" {
+		raw = defaultProperties
+	}
+	var out []string
+	for _, p := range strings.Split(raw, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// SyncNeo4j makes the Neo4j-sourced aliases match the graph: every node is
+// an alias of itself by name (so lookups ignore case) and by each alias
+// property, list properties contributing every element. Manual aliases are
+// never overwritten. The snapshot is reloaded afterwards.
+func SyncNeo4j(ctx context.Context) (SyncResult, error) {
+	var result SyncResult
+	desired, err := graphAliases(ctx, &result)
+	if err != nil {
+		return result, err
+	}
+
+	col := Collection(ctx)
+	cursor, err := col.Find(ctx, bson.M{})
+	if err != nil {
+		return result, err
+	}
+	var stored []models.DbEntityAlias
+	if err := cursor.All(ctx, &stored); err != nil {
+		return result, err
+	}
+
+	now := time.Now().UTC()
+	var writes []mongo.WriteModel
+	existing := make(map[string]models.DbEntityAlias, len(stored))
+	for _, a := range stored {
+		existing[a.Alias] = a
+		if a.Source == models.AliasSourceNeo4j {
+			if _, ok := desired[a.Alias]; !ok {
+				writes = append(writes, mongo.NewDeleteOneModel().SetFilter(bson.M{"_id": a.ID}))
+				result.Deleted++
+			}
+		}
+	}
+	for name, want := range desired {
+		have, ok := existing[name]
+		if ok && (have.Source == models.AliasSourceManual || (have.Entity == want.Entity && have.Kind == want.Kind)) {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"alias": name, "source": bson.M{"$ne": models.AliasSourceManual}}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"entity":     want.Entity,
+				"kind":       want.Kind,
+				"source":     models.AliasSourceNeo4j,
+				"updated_at": now,
+			}}).
+			SetUpsert(true))
+		result.Upserted++
+	}
+
+	if len(writes) > 0 {
+		if _, err := col.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			// A manual alias created during the sync makes its upsert a
+			// duplicate; the rest of the batch is still written
+			if !mongo.IsDuplicateKeyError(err) {
+				return result, err
+			}
+			log.Printf("Entity alias sync skipped aliases created meanwhile: %v", err)
+		}
+	}
+	return result, Reload(ctx)
+}
+
+// graphAliases reads the alias properties of every named node.
+func graphAliases(ctx context.Context, result *SyncResult) (map[string]models.DbEntityAlias, error) {
+	session := db.GetNeo4jDriver().NewSession(ctx, neo4j.SessionConfig{DatabaseName: "neo4j", AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	cypher := `
+	MATCH (n)
+	WHERE n.name IS NOT NULL
+	RETURN n.name AS name, [p IN $props WHERE n[p] IS NOT NULL | n[p]] AS values
+	ORDER BY name
+	`
+	res, err := session.Run(ctx, cypher, map[string]interface{}{"props": properties()})
+	if err != nil {
+		return nil, err
+	}
+
+	desired := map[string]models.DbEntityAlias{}
+	conflicts := map[string]bool{}
+	add := func(value, entity, kind string) {
+		key := Normalize(value)
+		if key == "" {
+			return
+		}
+		if have, ok := desired[key]; ok {
+			if have.Entity != entity {
+				conflicts[key] = true
+			}
+			return
+		}
+		desired[key] = models.DbEntityAlias{Alias: key, Entity: entity, Kind: kind}
+	}
+
+	var names []string
+	values := map[string][]interface{}{}
+	for res.Next(ctx) {
+		rec := res.Record()
+		name, ok := rec.Values[0].(string)
+		if !ok || name == "" {
+			continue
+		}
+		result.Nodes++
+		names = append(names, name)
+		if vs, ok := rec.Values[1].([]interface{}); ok {
+			values[name] = append(values[name], vs...)
+		}
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+
+	// Names first, so that a property never takes over another node's name
+	for _, name := range names {
+		add(name, name, models.AliasKindName)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range values[name] {
+			items, isList := v.([]interface{})
+			if !isList {
+				items = []interface{}{v}
+			}
+			for _, item := range items {
+				s := fmt.Sprint(item)
+				add(s, name, Kind(s))
+			}
+		}
+	}
+	result.Conflicts = len(conflicts)
+	return desired, nil
+}
@@ -0,0 +1,203 @@
+// Package bundle serializes the rule collections into a single YAML or JSON
+// document that can live in git, and computes the changes needed to make the
+// database match such a document.
+//
+// A bundle looks like:
+//
+//	version: 1
+//	alertrules:
+//	  - rulename: prod-severity
+//	    ruleobject: '{"combinator":"and","rules":[...]}'
+//	    setfield: severity
+//	    setvalue: CRITICAL
+//	pagerduty_services:
+//	  - service_id: PABC123
+//	    service_name: Payments
+//
+// Entries use the same field names as the REST API, without database IDs.
+// Each entry is identified by its key field (see Sections), so a bundle can be
+// applied to any environment.
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the bundle format version written by Encode.
+const Version = 1
+
+// Section describes one collection in a bundle.
+type Section struct {
+	Name string // collection name, also the bundle key
+	Key  string // field identifying an entry
+}
+
+// Sections lists the collections a bundle can hold, in output order.
+var Sections = []Section{
+	{Name: "alertrules", Key: "rulename"},
+	{Name: "tagrules", Key: "rulename"},
+	{Name: "healrules", Key: "rulename"},
+	{Name: "notifyrules", Key: "rulename"},
+	{Name: "correlationrules", Key: "groupname"},
+	{Name: "lookuptables", Key: "name"},
+	{Name: "calendars", Key: "name"},
+	{Name: "pagerduty_services", Key: "service_id"},
+	{Name: "pagerduty_escalation_policies", Key: "ep_id"},
+}
+
+// Entry is one document in the JSON form used by the REST API.
+type Entry = map[string]interface{}
+
+// Bundle holds the entries of each section. A section missing from a decoded
+// bundle is nil and left alone by Plan; an empty section deletes everything.
+type Bundle struct {
+	Sections map[string][]Entry
+}
+
+// New returns an empty bundle.
+func New() *Bundle {
+	return &Bundle{Sections: map[string][]Entry{}}
+}
+
+// SectionByName returns the section called name.
+func SectionByName(name string) (Section, bool) {
+	for _, s := range Sections {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Section{}, false
+}
+
+// Add appends a document to a section, converting it to its JSON form and
+// dropping its database ID.
+func (b *Bundle) Add(section string, doc interface{}) error {
+	entry, err := Normalize(doc)
+	if err != nil {
+		return err
+	}
+	b.Sections[section] = append(b.Sections[section], entry)
+	return nil
+}
+
+// Normalize converts a document to the JSON form of an entry: field names
+// from the json tags, numbers as float64, no ID.
+func Normalize(doc interface{}) (Entry, error) {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, err
+	}
+	for _, k := range []string{"ID", "id", "_id"} {
+		delete(entry, k)
+	}
+	return entry, nil
+}
+
+// KeyOf returns the key of an entry in section s.
+func (s Section) KeyOf(e Entry) string {
+	if v, ok := e[s.Key].(string); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+// Encode writes the bundle as "yaml" or "json". Sections and entries are
+// sorted so that exporting an unchanged database yields identical output.
+func Encode(b *Bundle, format string) ([]byte, error) {
+	doc := map[string]interface{}{"version": Version}
+	for _, s := range Sections {
+		entries, ok := b.Sections[s.Name]
+		if !ok {
+			continue
+		}
+		sorted := append([]Entry{}, entries...)
+		sort.SliceStable(sorted, func(i, j int) bool { return s.KeyOf(sorted[i]) < s.KeyOf(sorted[j]) })
+		doc[s.Name] = sorted
+	}
+
+	switch format {
+	case "json":
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "yaml", "":
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("bundle: unknown format %q", format)
+}
+
+// Decode reads a YAML or JSON bundle.
+func Decode(data []byte) (*Bundle, error) {
+	// JSON is valid YAML, so one parser handles both
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("bundle: %v", err)
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("bundle: empty document")
+	}
+
+	if v, ok := doc["version"]; ok {
+		if n, isInt := v.(int); !isInt || n != Version {
+			return nil, fmt.Errorf("bundle: unsupported version %v", v)
+		}
+		delete(doc, "version")
+	}
+
+	b := New()
+	for name, value := range doc {
+		s, ok := SectionByName(name)
+		if !ok {
+			return nil, fmt.Errorf("bundle: unknown section %q", name)
+		}
+		var items []interface{}
+		if value != nil {
+			if items, ok = value.([]interface{}); !ok {
+				return nil, fmt.Errorf("bundle: %s must be a list", name)
+			}
+		}
+		entries := make([]Entry, 0, len(items))
+		seen := map[string]bool{}
+		for i, item := range items {
+			entry, err := Normalize(item)
+			if err != nil {
+				return nil, fmt.Errorf("bundle: %s[%d]: %v", name, i, err)
+			}
+			key := s.KeyOf(entry)
+			if key == "" {
+				return nil, fmt.Errorf("bundle: %s[%d]: %s is required", name, i, s.Key)
+			}
+			if seen[key] {
+				return nil, fmt.Errorf("bundle: %s: duplicate %s %q", name, s.Key, key)
+			}
+			seen[key] = true
+			entries = append(entries, entry)
+		}
+		b.Sections[name] = entries
+	}
+	return b, nil
+}
@@ -0,0 +1,94 @@
+package bundle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	doc := struct {
+		ID       string            `json:"id"`
+		RuleName string            `json:"rulename"`
+		Priority int               `json:"priority"`
+		Labels   map[string]string `json:"labels,omitempty"`
+	}{ID: "64b7f0c2a1b2c3d4e5f60718", RuleName: "r", Priority: 3}
+	got, err := Normalize(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Entry{"rulename": "r", "priority": 3.0}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize = %#v, want %#v", got, want)
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	b := New()
+	for _, name := range []string{"zeta", "alpha"} {
+		if err := b.Add("alertrules", map[string]interface{}{"_id": "x", "rulename": name, "priority": 1, "tags": []string{"a"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.Sections["calendars"] = []Entry{}
+
+	for _, format := range []string{"yaml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			data, err := Encode(b, format)
+			if err != nil {
+				t.Fatalf("Encode error: %v", err)
+			}
+			got, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode error: %v\n%s", err, data)
+			}
+			want := map[string][]Entry{
+				"alertrules": {
+					{"rulename": "alpha", "priority": 1.0, "tags": []interface{}{"a"}},
+					{"rulename": "zeta", "priority": 1.0, "tags": []interface{}{"a"}},
+				},
+				"calendars": {},
+			}
+			if !reflect.DeepEqual(got.Sections, want) {
+				t.Errorf("sections =\n%#v\nwant\n%#v", got.Sections, want)
+			}
+		})
+	}
+	if _, err := Encode(b, "toml"); err == nil {
+		t.Error("Encode accepted an unknown format")
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"not yaml", "alertrules: [\n"},
+		{"unsupported version", "version: 2\n"},
+		{"unknown section", "users: []\n"},
+		{"section not a list", "alertrules: {rulename: a}\n"},
+		{"missing key", "alertrules:\n  - setfield: severity\n"},
+		{"blank key", "calendars:\n  - name: '  '\n"},
+		{"duplicate key", "alertrules:\n  - rulename: a\n  - rulename: a\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if b, err := Decode([]byte(tt.data)); err == nil {
+				t.Errorf("Decode = %+v, want an error", b.Sections)
+			}
+		})
+	}
+}
+
+func TestDecodeMissingSectionIsNil(t *testing.T) {
+	b, err := Decode([]byte("version: 1\nalertrules:\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, ok := b.Sections["alertrules"]; !ok || entries == nil || len(entries) != 0 {
+		t.Errorf("alertrules = %#v, want an empty list", entries)
+	}
+	if _, ok := b.Sections["tagrules"]; ok {
+		t.Error("absent section decoded as present")
+	}
+}
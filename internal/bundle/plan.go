@@ -0,0 +1,119 @@
+package bundle
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Plan actions
+const (
+	Create = "create"
+	Update = "update"
+	Delete = "delete"
+)
+
+// FieldChange is one field that differs between the database and the bundle.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Change is one entry to create, update or delete.
+type Change struct {
+	Section string        `json:"section"`
+	Key     string        `json:"key"`
+	Action  string        `json:"action"`
+	Fields  []FieldChange `json:"fields,omitempty"`
+	// Entry is the desired document, unset for deletes
+	Entry Entry `json:"-"`
+}
+
+// Plan is the ordered list of changes turning current into desired.
+type Plan struct {
+	Changes []Change       `json:"changes"`
+	Summary map[string]int `json:"summary"`
+}
+
+// Empty reports whether the database already matches the bundle.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Diff compares the desired bundle with the current contents of the
+// database. Only sections present in desired are compared. Entries with the
+// same key in current are ambiguous and reported as an error.
+func Diff(desired, current *Bundle) (*Plan, error) {
+	plan := &Plan{Changes: []Change{}, Summary: map[string]int{Create: 0, Update: 0, Delete: 0}}
+	for _, s := range Sections {
+		want, ok := desired.Sections[s.Name]
+		if !ok {
+			continue
+		}
+
+		have := map[string]Entry{}
+		for _, e := range current.Sections[s.Name] {
+			key := s.KeyOf(e)
+			if _, dup := have[key]; dup {
+				return nil, fmt.Errorf("%s: several documents have %s %q; rename them before importing", s.Name, s.Key, key)
+			}
+			have[key] = e
+		}
+
+		wanted := map[string]bool{}
+		for _, e := range want {
+			key := s.KeyOf(e)
+			wanted[key] = true
+			old, exists := have[key]
+			if !exists {
+				plan.add(Change{Section: s.Name, Key: key, Action: Create, Fields: diffEntries(nil, e), Entry: e})
+				continue
+			}
+			if fields := diffEntries(old, e); len(fields) > 0 {
+				plan.add(Change{Section: s.Name, Key: key, Action: Update, Fields: fields, Entry: e})
+			}
+		}
+
+		var removed []string
+		for key := range have {
+			if !wanted[key] {
+				removed = append(removed, key)
+			}
+		}
+		sort.Strings(removed)
+		for _, key := range removed {
+			plan.add(Change{Section: s.Name, Key: key, Action: Delete})
+		}
+	}
+	return plan, nil
+}
+
+func (p *Plan) add(c Change) {
+	p.Changes = append(p.Changes, c)
+	p.Summary[c.Action]++
+}
+
+// diffEntries lists the top-level fields that differ, sorted by name.
+func diffEntries(from, to Entry) []FieldChange {
+	names := map[string]bool{}
+	for k := range from {
+		names[k] = true
+	}
+	for k := range to {
+		names[k] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for k := range names {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []FieldChange
+	for _, k := range sorted {
+		if !reflect.DeepEqual(from[k], to[k]) {
+			changes = append(changes, FieldChange{Field: k, From: from[k], To: to[k]})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,82 @@
+package bundle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func rule(name, value string) Entry {
+	return Entry{"rulename": name, "setfield": "severity", "setvalue": value}
+}
+
+func TestDiff(t *testing.T) {
+	desired := New()
+	desired.Sections["alertrules"] = []Entry{rule("keep", "INFO"), rule("change", "CRITICAL"), rule("new", "WARN")}
+	desired.Sections["calendars"] = []Entry{}
+
+	current := New()
+	current.Sections["alertrules"] = []Entry{rule("gone-b", "INFO"), rule("keep", "INFO"), rule("change", "WARN"), rule("gone-a", "INFO")}
+	current.Sections["calendars"] = []Entry{{"name": "holidays"}}
+	// Not in the bundle, so left alone
+	current.Sections["tagrules"] = []Entry{{"rulename": "untouched"}}
+
+	plan, err := Diff(desired, current)
+	if err != nil {
+		t.Fatalf("Diff error: %v", err)
+	}
+	want := []Change{
+		{Section: "alertrules", Key: "change", Action: Update, Fields: []FieldChange{{Field: "setvalue", From: "WARN", To: "CRITICAL"}}, Entry: rule("change", "CRITICAL")},
+		{Section: "alertrules", Key: "new", Action: Create, Fields: []FieldChange{
+			{Field: "rulename", To: "new"},
+			{Field: "setfield", To: "severity"},
+			{Field: "setvalue", To: "WARN"},
+		}, Entry: rule("new", "WARN")},
+		{Section: "alertrules", Key: "gone-a", Action: Delete},
+		{Section: "alertrules", Key: "gone-b", Action: Delete},
+		{Section: "calendars", Key: "holidays", Action: Delete},
+	}
+	if !reflect.DeepEqual(plan.Changes, want) {
+		t.Errorf("changes =\n%+v\nwant\n%+v", plan.Changes, want)
+	}
+	if summary := map[string]int{Create: 1, Update: 1, Delete: 3}; !reflect.DeepEqual(plan.Summary, summary) {
+		t.Errorf("summary = %v, want %v", plan.Summary, summary)
+	}
+	if plan.Empty() {
+		t.Error("plan with changes reported empty")
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	b := New()
+	b.Sections["alertrules"] = []Entry{rule("a", "INFO"), rule("b", "WARN")}
+	plan, err := Diff(b, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() || plan.Summary[Create]+plan.Summary[Update]+plan.Summary[Delete] != 0 {
+		t.Errorf("plan = %+v, want no changes", plan)
+	}
+}
+
+func TestDiffFieldRemoved(t *testing.T) {
+	desired, current := New(), New()
+	desired.Sections["alertrules"] = []Entry{{"rulename": "r"}}
+	current.Sections["alertrules"] = []Entry{{"rulename": "r", "calendar": "business-hours"}}
+	plan, err := Diff(desired, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FieldChange{{Field: "calendar", From: "business-hours"}}
+	if len(plan.Changes) != 1 || plan.Changes[0].Action != Update || !reflect.DeepEqual(plan.Changes[0].Fields, want) {
+		t.Errorf("changes = %+v, want an update removing calendar", plan.Changes)
+	}
+}
+
+func TestDiffRejectsDuplicateKeys(t *testing.T) {
+	desired, current := New(), New()
+	desired.Sections["alertrules"] = []Entry{rule("a", "INFO")}
+	current.Sections["alertrules"] = []Entry{rule("a", "INFO"), rule(" a ", "WARN")}
+	if plan, err := Diff(desired, current); err == nil {
+		t.Errorf("Diff = %+v, want an error for duplicate keys", plan)
+	}
+}
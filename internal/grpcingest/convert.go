@@ -0,0 +1,105 @@
+package grpcingest
+
+import (
+	"fmt"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/grpcingest/ingestpb"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AlertFromProto converts a wire alert into a DbAlert. Unset fields stay at
+// their zero value so the ingestion pipeline fills in its defaults.
+func AlertFromProto(in *ingestpb.Alert) (models.DbAlert, error) {
+	if in == nil {
+		return models.DbAlert{}, fmt.Errorf("alert is required")
+	}
+
+	out := models.DbAlert{
+		Entity:                    in.GetEntity(),
+		AlertFirstTime:            customTime(in.GetAlertFirstTime()),
+		AlertLastTime:             customTime(in.GetAlertLastTime()),
+		AlertClearTime:            customTime(in.GetAlertClearTime()),
+		AlertSource:               in.GetAlertSource(),
+		ServiceName:               in.GetServiceName(),
+		AlertSummary:              in.GetAlertSummary(),
+		AlertStatus:               in.GetAlertStatus(),
+		AlertNotes:                in.GetAlertNotes(),
+		AlertAcked:                in.GetAlertAcked(),
+		Severity:                  in.GetSeverity(),
+		AlertId:                   in.GetAlertId(),
+		AlertPriority:             in.GetAlertPriority(),
+		IpAddress:                 in.GetIpAddress(),
+		AlertType:                 in.GetAlertType(),
+		AlertCount:                int(in.GetAlertCount()),
+		AlertDropped:              in.GetAlertDropped(),
+		Fingerprint:               in.GetFingerprint(),
+		GroupIdentifier:           in.GetGroupIdentifier(),
+		Grouped:                   in.GetGrouped(),
+		GroupIncidentId:           in.GetGroupIncidentId(),
+		Parent:                    in.GetParent(),
+		AlertDestination:          in.GetAlertDestination(),
+		PagerDutyIncidentNumber:   int(in.GetPagerdutyIncidentNumber()),
+		PagerDutyIncidentId:       in.GetPagerdutyIncidentId(),
+		PagerDutyPriority:         in.GetPagerdutyPriority(),
+		PagerDutyUrgency:          in.GetPagerdutyUrgency(),
+		PagerDutyHtmlUrl:          in.GetPagerdutyHtmlUrl(),
+		PagerDutyService:          in.GetPagerdutyService(),
+		PagerDutyEscalationPolicy: in.GetPagerdutyEscalationPolicy(),
+		Major_incident_number:     int(in.GetMajorIncidentNumber()),
+		Major_incident_id:         in.GetMajorIncidentId(),
+		Major_incident_url:        in.GetMajorIncidentUrl(),
+		Major_incident_status:     in.GetMajorIncidentStatus(),
+	}
+
+	if in.GetId() != "" {
+		id, err := primitive.ObjectIDFromHex(in.GetId())
+		if err != nil {
+			return out, fmt.Errorf("invalid id %q", in.GetId())
+		}
+		out.ID = id
+	}
+	if in.GetAdditionalDetails() != nil {
+		out.AdditionalDetails = in.GetAdditionalDetails().AsMap()
+	}
+	for _, wl := range in.GetWorkLogs() {
+		entry := models.WorkLog{Author: wl.GetAuthor(), Comment: wl.GetComment()}
+		if wl.GetId() != "" {
+			id, err := primitive.ObjectIDFromHex(wl.GetId())
+			if err != nil {
+				return out, fmt.Errorf("invalid work log id %q", wl.GetId())
+			}
+			entry.ID = id
+		}
+		if wl.GetCreatedAt() != nil {
+			entry.CreatedAt = wl.GetCreatedAt().AsTime()
+		}
+		out.WorkLogs = append(out.WorkLogs, entry)
+	}
+	for _, hex := range in.GetGroupAlerts() {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return out, fmt.Errorf("invalid group alert id %q", hex)
+		}
+		out.GroupAlerts = append(out.GroupAlerts, id)
+	}
+	for _, child := range in.GetChildAlerts() {
+		c, err := AlertFromProto(child)
+		if err != nil {
+			return out, fmt.Errorf("child alert: %v", err)
+		}
+		out.ChildAlerts = append(out.ChildAlerts, c)
+	}
+	if gr := in.GetGroupingReason(); gr != nil {
+		out.GroupingReason = &models.GroupingReason{Type: gr.GetType(), Description: gr.GetDescription(), Reasons: gr.GetReasons()}
+	}
+	return out, nil
+}
+
+func customTime(ts *timestamppb.Timestamp) models.CustomTime {
+	if ts == nil {
+		return models.CustomTime{}
+	}
+	return models.CustomTime{Time: ts.AsTime()}
+}
@@ -0,0 +1,133 @@
+package grpcingest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/grpcingest/ingestpb"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const hexID = "64b7f0c2a1b2c3d4e5f60718"
+
+func TestAlertFromProto(t *testing.T) {
+	first := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	details, err := structpb.NewStruct(map[string]interface{}{"region": "eu", "cpu": 93.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := &ingestpb.Alert{
+		Id:                  hexID,
+		Entity:              "web-01",
+		AlertFirstTime:      timestamppb.New(first),
+		AlertSummary:        "CPU high",
+		Severity:            "CRITICAL",
+		AlertCount:          3,
+		AdditionalDetails:   details,
+		WorkLogs:            []*ingestpb.WorkLog{{Id: hexID, Author: "ops", Comment: "looking", CreatedAt: timestamppb.New(first)}, {Author: "bot"}},
+		GroupAlerts:         []string{hexID},
+		ChildAlerts:         []*ingestpb.Alert{{Entity: "db-01"}},
+		GroupingReason:      &ingestpb.GroupingReason{Type: "TAG", Reasons: []string{"same site"}},
+		MajorIncidentNumber: 12,
+	}
+
+	got, err := AlertFromProto(in)
+	if err != nil {
+		t.Fatalf("AlertFromProto error: %v", err)
+	}
+	id, _ := primitive.ObjectIDFromHex(hexID)
+	want := models.DbAlert{
+		ID:                    id,
+		Entity:                "web-01",
+		AlertFirstTime:        models.CustomTime{Time: first},
+		AlertSummary:          "CPU high",
+		Severity:              "CRITICAL",
+		AlertCount:            3,
+		AdditionalDetails:     map[string]interface{}{"region": "eu", "cpu": 93.5},
+		WorkLogs:              []models.WorkLog{{ID: id, Author: "ops", Comment: "looking", CreatedAt: first}, {Author: "bot"}},
+		GroupAlerts:           []primitive.ObjectID{id},
+		ChildAlerts:           []models.DbAlert{{Entity: "db-01"}},
+		GroupingReason:        &models.GroupingReason{Type: "TAG", Reasons: []string{"same site"}},
+		Major_incident_number: 12,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AlertFromProto =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestAlertFromProtoUnsetFields(t *testing.T) {
+	got, err := AlertFromProto(&ingestpb.Alert{Entity: "web-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, models.DbAlert{Entity: "web-01"}) {
+		t.Errorf("AlertFromProto = %+v, want only the entity set", got)
+	}
+}
+
+func TestAlertFromProtoRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *ingestpb.Alert
+	}{
+		{"nil alert", nil},
+		{"invalid id", &ingestpb.Alert{Id: "not-hex"}},
+		{"invalid work log id", &ingestpb.Alert{WorkLogs: []*ingestpb.WorkLog{{Id: "123"}}}},
+		{"invalid group alert id", &ingestpb.Alert{GroupAlerts: []string{hexID, "zz"}}},
+		{"invalid child alert", &ingestpb.Alert{ChildAlerts: []*ingestpb.Alert{{Id: "bad"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := AlertFromProto(tt.in); err == nil {
+				t.Errorf("AlertFromProto = %+v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestIngestOne(t *testing.T) {
+	var seen models.DbAlert
+	var dedup []string
+	s := &server{pipeline: Pipeline{
+		Ingest: func(ctx context.Context, alert models.DbAlert) (models.DbAlert, bool, error) {
+			seen = alert
+			if alert.Entity == "fail" {
+				return alert, false, errors.New("store down")
+			}
+			alert.ID, _ = primitive.ObjectIDFromHex(hexID)
+			alert.AlertId = "ALR-1"
+			alert.AlertCount = 2
+			return alert, false, nil
+		},
+		Fingerprint: func(alert models.DbAlert, fields []string) string {
+			dedup = fields
+			return "fp-" + alert.Entity
+		},
+	}}
+
+	ack := s.ingestOne(context.Background(), &ingestpb.IngestRequest{Sequence: 7, Alert: &ingestpb.Alert{Entity: "web-01"}, DedupFields: []string{"entity"}})
+	if !ack.Ok || ack.Created || ack.Sequence != 7 || ack.Id != hexID || ack.AlertId != "ALR-1" || ack.Fingerprint != "fp-web-01" || ack.AlertCount != 2 {
+		t.Errorf("ack = %+v", ack)
+	}
+	if seen.AlertSource != "grpc" || !reflect.DeepEqual(dedup, []string{"entity"}) {
+		t.Errorf("pipeline saw source %q and dedup fields %v", seen.AlertSource, dedup)
+	}
+
+	// A fingerprint and source sent by the producer are kept
+	s.ingestOne(context.Background(), &ingestpb.IngestRequest{Alert: &ingestpb.Alert{Entity: "web-01", Fingerprint: "mine", AlertSource: "probe"}})
+	if seen.Fingerprint != "mine" || seen.AlertSource != "probe" {
+		t.Errorf("pipeline saw fingerprint %q source %q, want mine, probe", seen.Fingerprint, seen.AlertSource)
+	}
+
+	for _, req := range []*ingestpb.IngestRequest{{Sequence: 8}, {Sequence: 9, Alert: &ingestpb.Alert{Entity: "fail"}}} {
+		if ack := s.ingestOne(context.Background(), req); ack.Ok || ack.Error == "" || ack.Sequence != req.Sequence {
+			t.Errorf("ack for sequence %d = %+v, want a failure", req.Sequence, ack)
+		}
+	}
+}
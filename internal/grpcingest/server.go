@@ -0,0 +1,179 @@
+// Package grpcingest serves the AlertIngest gRPC service for high-throughput
+// alert producers. The schema lives in ingestpb/ingest.proto.
+package grpcingest
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/auth"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/grpcingest/ingestpb"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Pipeline connects the service to alert storage; the functions match
+// handlers.IngestAlert and handlers.FingerprintAlert.
+type Pipeline struct {
+	Ingest      func(ctx context.Context, alert models.DbAlert) (models.DbAlert, bool, error)
+	Fingerprint func(alert models.DbAlert, fields []string) string
+}
+
+// Config holds the listen address and optional TLS key pair.
+type Config struct {
+	Addr    string
+	TLSCert string
+	TLSKey  string
+}
+
+// ConfigFromEnv reads GRPC_ADDR (e.g. ":9090"; unset or "off" disables
+// the server) and GRPC_TLS_CERT / GRPC_TLS_KEY.
+func ConfigFromEnv() Config {
+	addr := os.Getenv("GRPC_ADDR")
+	if addr == "off" {
+		addr = ""
+	}
+	return Config{
+		Addr:    addr,
+		TLSCert: os.Getenv("GRPC_TLS_CERT"),
+		TLSKey:  os.Getenv("GRPC_TLS_KEY"),
+	}
+}
+
+// Start opens the gRPC listener and serves it in the background.
+func Start(cfg Config, pipeline Pipeline) error {
+	if cfg.Addr == "" {
+		return nil
+	}
+
+	opts := []grpc.ServerOption{grpc.StreamInterceptor(authStreamInterceptor)}
+	if cfg.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})))
+	}
+
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer(opts...)
+	ingestpb.RegisterAlertIngestServer(srv, &server{pipeline: pipeline})
+
+	log.Printf("gRPC ingestion listening on %s", cfg.Addr)
+	go func() {
+		if err := srv.Serve(ln); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// authStreamInterceptor accepts the same JWT as the HTTP API, sent in the
+// "authorization" metadata key.
+func authStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "authorization metadata required")
+	}
+	claims, err := auth.ParseJWT(strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil || claims == nil {
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return handler(srv, ss)
+}
+
+type server struct {
+	ingestpb.UnimplementedAlertIngestServer
+	pipeline Pipeline
+}
+
+// Ingest stores each streamed alert as it arrives and acknowledges all of
+// them when the client closes its side of the stream.
+func (s *server) Ingest(stream ingestpb.AlertIngest_IngestServer) error {
+	resp := &ingestpb.IngestResponse{Acks: []*ingestpb.Ack{}}
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return err
+		}
+
+		resp.Received++
+		ack := s.ingestOne(stream.Context(), req)
+		switch {
+		case !ack.Ok:
+			resp.Failed++
+		case ack.Created:
+			resp.Created++
+		default:
+			resp.Updated++
+		}
+		resp.Acks = append(resp.Acks, ack)
+	}
+}
+
+// IngestStream stores each streamed alert as it arrives and acknowledges it
+// before reading the next one.
+func (s *server) IngestStream(stream ingestpb.AlertIngest_IngestStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(s.ingestOne(stream.Context(), req)); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) ingestOne(ctx context.Context, req *ingestpb.IngestRequest) *ingestpb.Ack {
+	ack := &ingestpb.Ack{Sequence: req.GetSequence()}
+
+	alert, err := AlertFromProto(req.GetAlert())
+	if err != nil {
+		ack.Error = err.Error()
+		return ack
+	}
+	if alert.AlertSource == "" {
+		alert.AlertSource = "grpc"
+	}
+	if alert.Fingerprint == "" {
+		alert.Fingerprint = s.pipeline.Fingerprint(alert, req.GetDedupFields())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	stored, created, err := s.pipeline.Ingest(ctx, alert)
+	if err != nil {
+		ack.Error = err.Error()
+		return ack
+	}
+	ack.Ok = true
+	ack.Created = created
+	ack.Id = stored.ID.Hex()
+	ack.AlertId = stored.AlertId
+	ack.Fingerprint = stored.Fingerprint
+	ack.AlertCount = int32(stored.AlertCount)
+	return ack
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// maxNDJSONLine bounds a single NDJSON record in a batch ingest request
+const maxNDJSONLine = 1024 * 1024
+
+// IngestAlertHandler accepts a single alert in DbAlert JSON form.
+// The dedup key is computed from ALERT_DEDUP_FIELDS unless the caller passes
+// ?dedup=field1,field2 or sends its own fingerprint.
+func IngestAlertHandler(c *gin.Context) {
+	var alert models.DbAlert
+	if err := c.ShouldBindJSON(&alert); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if alert.AlertSource == "" {
+		alert.AlertSource = "api"
+	}
+	if alert.Fingerprint == "" {
+		alert.Fingerprint = alertFingerprint(alert, requestDedupFields(c))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stored, created, err := IngestAlert(ctx, alert)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"id":          stored.ID.Hex(),
+		"alertid":     stored.AlertId,
+		"fingerprint": stored.Fingerprint,
+		"alertcount":  stored.AlertCount,
+		"created":     created,
+	})
+}
+
+// IngestAlertBatchHandler accepts newline-delimited JSON, one DbAlert per line,
+// and writes the whole batch with a single Mongo bulk write.
+// Lines that fail to parse are reported back and skipped.
+func IngestAlertBatchHandler(c *gin.Context) {
+	dedupFields := requestDedupFields(c)
+
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+
+	alerts := []models.DbAlert{}
+	lineErrors := []gin.H{}
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var alert models.DbAlert
+		if err := json.Unmarshal([]byte(text), &alert); err != nil {
+			lineErrors = append(lineErrors, gin.H{"line": line, "error": err.Error()})
+			continue
+		}
+		if alert.AlertSource == "" {
+			alert.AlertSource = "api"
+		}
+		if alert.Fingerprint == "" {
+			alert.Fingerprint = alertFingerprint(alert, dedupFields)
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := scanner.Err(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("reading line %d: %v", line+1, err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	created, updated, err := IngestAlertBatch(ctx, alerts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"received": len(alerts) + len(lineErrors),
+		"created":  created,
+		"updated":  updated,
+		"errors":   lineErrors,
+	})
+}
+
+// requestDedupFields lets a caller override the configured dedup fields per request
+func requestDedupFields(c *gin.Context) []string {
+	if q := c.Query("dedup"); q != "" {
+		return splitFields(q)
+	}
+	return dedupFieldsFromEnv()
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// AlertmanagerWebhook receives notifications from a Prometheus Alertmanager
+// webhook receiver. Firing alerts are ingested (deduplicated on the Alertmanager
+// fingerprint), resolved alerts close the matching open alert. Requests
+// must carry ALERTMANAGER_TOKEN as a bearer token; without it set the
+// receiver refuses every request.
+func AlertmanagerWebhook(c *gin.Context) {
+	token := os.Getenv("ALERTMANAGER_TOKEN")
+	if token == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Alertmanager receiver is disabled: ALERTMANAGER_TOKEN is not set"})
+		return
+	}
+	sent, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid bearer token"})
+		return
+	}
+
+	var payload models.AlertmanagerWebhook
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if payload.Version != "" && payload.Version != "4" {
+		log.Printf("Alertmanager webhook version %s received, expected 4", payload.Version)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	created, updated, resolved := 0, 0, 0
+	for _, amAlert := range payload.Alerts {
+		fingerprint := amAlert.Fingerprint
+		if fingerprint == "" {
+			fingerprint = labelsFingerprint(amAlert.Labels)
+		}
+
+		if amAlert.Status == "resolved" {
+			found, err := ResolveAlert(ctx, fingerprint, amAlert.EndsAt, "Alert resolved by Alertmanager")
+			if err != nil {
+				// Alertmanager retries the whole notification on a non-2xx response
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if found {
+				resolved++
+			}
+			continue
+		}
+
+		alert := alertFromAlertmanager(payload, amAlert)
+		alert.Fingerprint = fingerprint
+		_, isNew, err := IngestAlert(ctx, alert)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if isNew {
+			created++
+		} else {
+			updated++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"created": created, "updated": updated, "resolved": resolved})
+}
+
+// alertFromAlertmanager maps Alertmanager labels and annotations onto a DbAlert.
+// All labels and annotations are kept in AdditionalDetails so rules can key off them.
+func alertFromAlertmanager(payload models.AlertmanagerWebhook, amAlert models.AlertmanagerAlert) models.DbAlert {
+	labels := amAlert.Labels
+	annotations := amAlert.Annotations
+
+	details := map[string]interface{}{}
+	for k, v := range annotations {
+		details[k] = v
+	}
+	for k, v := range labels {
+		details[k] = v
+	}
+	details["generatorURL"] = amAlert.GeneratorURL
+	details["receiver"] = payload.Receiver
+	details["externalURL"] = payload.ExternalURL
+
+	summary := firstNonEmpty(annotations["summary"], annotations["description"], annotations["message"], labels["alertname"])
+
+	return models.DbAlert{
+		Entity:            alertmanagerEntity(labels),
+		AlertFirstTime:    models.CustomTime{Time: amAlert.StartsAt},
+		AlertLastTime:     models.CustomTime{Time: time.Now()},
+		AlertSource:       "alertmanager",
+		ServiceName:       firstNonEmpty(labels["service"], labels["job"]),
+		AlertSummary:      summary,
+		AlertNotes:        annotations["description"],
+		Severity:          labels["severity"],
+		AlertPriority:     labels["priority"],
+		AlertType:         labels["alertname"],
+		AdditionalDetails: details,
+	}
+}
+
+// alertmanagerEntity picks the label that best identifies the affected host.
+// The instance label usually carries a scrape port which is stripped.
+func alertmanagerEntity(labels map[string]string) string {
+	if v := firstNonEmpty(labels["entity"], labels["host"], labels["hostname"], labels["node"]); v != "" {
+		return v
+	}
+	if instance := labels["instance"]; instance != "" {
+		if host, _, err := net.SplitHostPort(instance); err == nil {
+			return host
+		}
+		return instance
+	}
+	return firstNonEmpty(labels["pod"], labels["job"])
+}
+
+// labelsFingerprint derives a stable fingerprint from the label set for
+// Alertmanager versions that do not send one.
+func labelsFingerprint(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, k := range names {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(labels[k]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -24,6 +24,10 @@ func New(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+    if rejectInvalidRule(c, &alertRule) {
+        return
+    }
+    alertRule.MigrateActions()
 
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -32,6 +36,7 @@ func New(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordRevision(ctx, "alertrules", result.InsertedID.(primitive.ObjectID), "create", revisionAuthor(c), 0)
 	c.JSON(http.StatusOK, gin.H{"result": result.InsertedID})
 }
 
@@ -61,6 +66,7 @@ func Index(c *gin.Context) {
     if records == nil {
 		records = []bson.M{}
 	} 
+    attachRuleStats(ctx, "alertrules", records)
 
     c.JSON(http.StatusOK, records)
 }
@@ -105,6 +111,10 @@ func Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+    if rejectInvalidRule(c, &alertRule) {
+        return
+    }
+    alertRule.MigrateActions()
 
     id := c.Param("id")
     // Convert string ID to BSON ObjectID
@@ -117,23 +127,51 @@ func Update(c *gin.Context) {
     collection := db.GetCollection("alertrules")
 	updatefilter := bson.M{"_id": objectID }
     // Prepare the update document using the $set operator
-	update := bson.M{"$set": alertRule}
+	update, err := ruleUpdate("alertrules", alertRule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
+    ensureBaseline(context.TODO(), "alertrules", objectID)
     updateResult , updateerr := collection.UpdateOne(context.TODO(), updatefilter, update)
     if updateerr != nil {
         panic(updateerr)
     }
     if updateResult.ModifiedCount > 0 {
+        recordRevision(context.TODO(), "alertrules", objectID, "update", revisionAuthor(c), 0)
         fmt.Printf("Matched %v documents and updated %v documents.\n", updateResult.MatchedCount, updateResult.ModifiedCount)
     }
 	c.JSON(http.StatusOK, gin.H{"modified": updateResult.ModifiedCount})
 }
 
+// MigrateAlertRules moves the alert rules still stored in the
+// SetField/SetValue form into Actions, recording a "migrate" revision for
+// each, and returns how many it moved. It runs at startup; once every rule
+// is migrated it finds nothing to do.
+func MigrateAlertRules(ctx context.Context) (int, error) {
+	collection := db.GetCollection("alertrules")
+	cursor, err := collection.Find(ctx, bson.M{"actions": bson.M{"$exists": false}, "setfield": bson.M{"$nin": bson.A{"", nil}}})
+	if err != nil {
+		return 0, err
+	}
+	var records []models.DbAlertRule
+	if err := cursor.All(ctx, &records); err != nil {
+		return 0, err
+	}
 
-
-
-
-
-
-
-
+	migrated := 0
+	for _, r := range records {
+		if !r.MigrateActions() {
+			continue
+		}
+		ensureBaseline(ctx, "alertrules", r.ID)
+		update := bson.M{"$set": bson.M{"actions": r.Actions, "setfield": "", "setvalue": ""}}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": r.ID, "actions": bson.M{"$exists": false}}, update); err != nil {
+			return migrated, err
+		}
+		recordRevision(ctx, "alertrules", r.ID, "migrate", "system", 0)
+		migrated++
+	}
+	return migrated, nil
+}
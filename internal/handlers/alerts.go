@@ -14,10 +14,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rulestats"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -196,13 +198,26 @@ func Clear(c *gin.Context) {
         },
         "$set": bson.M{
             "alertstatus": "CLOSED",
-        }}
+        },
+        "$unset": bson.M{"open": ""},
+    }
     _, err = collection.UpdateOne(context.TODO(), filter, update)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
+    cascadeClose(ctx, collection, alert)
+
+    c.JSON(http.StatusOK, newComment)
+}
+
+// cascadeClose propagates the closure of alert through its group: closing a
+// parent closes its children, and closing the last open child closes the parent.
+func cascadeClose(ctx context.Context, collection *mongo.Collection, alert models.DbAlert) {
+    // Closed alerts are no longer similarity candidates
+    unindexAlerts(ctx, append([]primitive.ObjectID{alert.ID}, alert.GroupAlerts...)...)
+
     // Logic 1: If this is a parent alert, close all child alerts
     if alert.Parent && len(alert.GroupAlerts) > 0 {
         childComment := models.WorkLog{
@@ -219,13 +234,15 @@ func Clear(c *gin.Context) {
             },
             "$set": bson.M{
                 "alertstatus": "CLOSED",
-            }}
+            },
+            "$unset": bson.M{"open": ""},
+        }
 
-        _, err = collection.UpdateMany(context.TODO(), childFilter, childUpdate)
+        _, err := collection.UpdateMany(context.TODO(), childFilter, childUpdate)
         if err != nil {
             log.Printf("Error closing child alerts: %v", err)
         } else {
-            log.Printf("Closed %d child alerts for parent alert %s", len(alert.GroupAlerts), id)
+            log.Printf("Closed %d child alerts for parent alert %s", len(alert.GroupAlerts), alert.ID.Hex())
         }
     }
 
@@ -237,11 +254,11 @@ func Clear(c *gin.Context) {
         var parentAlert models.DbAlert
         parentFilter := bson.M{
             "parent": true,
-            "groupalerts": bson.M{"$in": []primitive.ObjectID{objectID}},
+            "groupalerts": bson.M{"$in": []primitive.ObjectID{alert.ID}},
         }
-        err = collection.FindOne(ctx, parentFilter).Decode(&parentAlert)
+        err := collection.FindOne(ctx, parentFilter).Decode(&parentAlert)
         if err != nil {
-            log.Printf("Could not find parent alert containing child %s: %v", objectID.Hex(), err)
+            log.Printf("Could not find parent alert containing child %s: %v", alert.ID.Hex(), err)
         } else {
             log.Printf("Found parent alert %s with %d children", parentAlert.ID.Hex(), len(parentAlert.GroupAlerts))
             
@@ -289,7 +306,9 @@ func Clear(c *gin.Context) {
                             },
                             "$set": bson.M{
                                 "alertstatus": "CLOSED",
-                            }}
+                            },
+                            "$unset": bson.M{"open": ""},
+                        }
 
                         _, err = collection.UpdateOne(context.TODO(), parentUpdateFilter, parentUpdate)
                         if err != nil {
@@ -313,8 +332,6 @@ func Clear(c *gin.Context) {
     } else {
         log.Printf("Alert is not a grouped child. Grouped: %v", alert.Grouped)
     }
-
-    c.JSON(http.StatusOK, newComment)
 }
 
 func Notify(c *gin.Context) {
@@ -355,6 +372,23 @@ func Notify(c *gin.Context) {
         c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
         return
     }
+    if !models.RuleEnabled(notifyrecord.Enabled) {
+        c.JSON(http.StatusConflict, gin.H{"error": "Notification rule is disabled"})
+        return
+    }
+    calendars, err := loadCalendars(ctx)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if window, err := calendars.Check(notifyrecord.Calendar, time.Now()); err != nil {
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        return
+    } else if window != nil && !window.Active {
+        c.JSON(http.StatusConflict, gin.H{"error": "Notification rule is not active now", "calendar": window})
+        return
+    }
+    rulestats.Hit(ctx, "notifyrules", notifyrecord.ID, record.AlertId)
     record.AlertDestination = notifyrecord.RuleName
     // Convert DbAlert to byte
     byteSlice, err := json.Marshal(record)
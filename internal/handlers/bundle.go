@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/bundle"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rules"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxBundleSize bounds an uploaded rule bundle
+const maxBundleSize = 8 * 1024 * 1024
+
+// bundleModels gives the stored document type of every bundle section, so
+// that entries are read and written with the same fields as the REST API.
+var bundleModels = map[string]func() interface{}{
+	"alertrules":                    func() interface{} { return &models.DbAlertRule{} },
+	"tagrules":                      func() interface{} { return &models.DbTagRule{} },
+	"healrules":                     func() interface{} { return &models.DbHealRule{} },
+	"notifyrules":                   func() interface{} { return &models.DbNotifyRule{} },
+	"correlationrules":              func() interface{} { return &models.DbCorrelationRule{} },
+	"lookuptables":                  func() interface{} { return &models.DbLookupTable{} },
+	"calendars":                     func() interface{} { return &models.DbCalendar{} },
+	"pagerduty_services":            func() interface{} { return &models.DbPagerDutyService{} },
+	"pagerduty_escalation_policies": func() interface{} { return &models.DbPagerDutyEscalationPolicy{} },
+}
+
+// storedBundle is the database content as a bundle, plus the ID of each entry.
+type storedBundle struct {
+	bundle *bundle.Bundle
+	ids    map[string]map[string]primitive.ObjectID // section -> key -> _id
+}
+
+// loadStoredBundle reads every bundle section from the database.
+func loadStoredBundle(ctx context.Context) (*storedBundle, error) {
+	stored := &storedBundle{bundle: bundle.New(), ids: map[string]map[string]primitive.ObjectID{}}
+	for _, s := range bundle.Sections {
+		cursor, err := db.GetCollection(s.Name).Find(ctx, bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		stored.bundle.Sections[s.Name] = []bundle.Entry{}
+		stored.ids[s.Name] = map[string]primitive.ObjectID{}
+		for cursor.Next(ctx) {
+			var id struct {
+				ID primitive.ObjectID `bson:"_id"`
+			}
+			doc := bundleModels[s.Name]()
+			if err := cursor.Decode(&id); err != nil {
+				cursor.Close(ctx)
+				return nil, err
+			}
+			if err := cursor.Decode(doc); err != nil {
+				cursor.Close(ctx)
+				return nil, err
+			}
+			entry, err := bundle.Normalize(doc)
+			if err != nil {
+				cursor.Close(ctx)
+				return nil, err
+			}
+			stored.bundle.Sections[s.Name] = append(stored.bundle.Sections[s.Name], entry)
+			stored.ids[s.Name][s.KeyOf(entry)] = id.ID
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stored, nil
+}
+
+// canonicalBundle passes every entry through its stored type, so that fields
+// left out of the bundle compare equal to their zero value in the database,
+// and rejects mistyped fields and invalid rules before anything is written.
+func canonicalBundle(ctx context.Context, b *bundle.Bundle) (*bundle.Bundle, error) {
+	var tables map[string]bool
+	out := bundle.New()
+	for name, entries := range b.Sections {
+		out.Sections[name] = make([]bundle.Entry, 0, len(entries))
+		for _, e := range entries {
+			doc, err := entryDocument(name, e)
+			if err != nil {
+				return nil, err
+			}
+			errs := rules.Validate(doc)
+			if rule, ok := doc.(*models.DbTagRule); ok && len(lookupTableNames(*rule)) > 0 {
+				if tables == nil {
+					if tables, err = bundleLookupTables(ctx, b); err != nil {
+						return nil, err
+					}
+				}
+				errs = unknownLookupTables(*rule, tables, errs)
+			}
+			if errs != nil {
+				s, _ := bundle.SectionByName(name)
+				return nil, fmt.Errorf("%s %q: %v", name, s.KeyOf(e), errs)
+			}
+			if table, ok := doc.(*models.DbLookupTable); ok {
+				if err := validateLookupTable(*table); err != nil {
+					return nil, fmt.Errorf("%s %q: %v", name, table.Name, err)
+				}
+			}
+			if rule, ok := doc.(*models.DbAlertRule); ok {
+				rule.MigrateActions()
+			}
+			if calendar, ok := doc.(*models.DbCalendar); ok {
+				if err := validateCalendar(*calendar); err != nil {
+					return nil, fmt.Errorf("%s %q: %v", name, calendar.Name, err)
+				}
+			}
+			entry, err := bundle.Normalize(doc)
+			if err != nil {
+				return nil, err
+			}
+			out.Sections[name] = append(out.Sections[name], entry)
+		}
+	}
+	return out, nil
+}
+
+// bundleLookupTables returns the lookup tables that exist once b is
+// applied: the bundle's own when it has a lookuptables section, which
+// replaces the stored ones, and the stored tables otherwise.
+func bundleLookupTables(ctx context.Context, b *bundle.Bundle) (map[string]bool, error) {
+	entries, ok := b.Sections["lookuptables"]
+	if !ok {
+		return storedLookupTables(ctx, nil)
+	}
+	s, _ := bundle.SectionByName("lookuptables")
+	tables := map[string]bool{}
+	for _, e := range entries {
+		tables[s.KeyOf(e)] = true
+	}
+	return tables, nil
+}
+
+// entryDocument decodes an entry into the stored type of its section.
+func entryDocument(section string, e bundle.Entry) (interface{}, error) {
+	s, _ := bundle.SectionByName(section)
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	doc := bundleModels[section]()
+	if err := json.Unmarshal(raw, doc); err != nil {
+		return nil, fmt.Errorf("%s %q: %v", section, s.KeyOf(e), err)
+	}
+	return doc, nil
+}
+
+// ExportRuleBundle returns every rule and PagerDuty mapping as a bundle.
+func ExportRuleBundle(ctx context.Context) (*bundle.Bundle, error) {
+	stored, err := loadStoredBundle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return stored.bundle, nil
+}
+
+// PlanRuleBundle reports the changes that applying b would make.
+func PlanRuleBundle(ctx context.Context, b *bundle.Bundle) (*bundle.Plan, error) {
+	desired, err := canonicalBundle(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := loadStoredBundle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Diff(desired, stored.bundle)
+}
+
+// ApplyRuleBundle makes the database match b in a single transaction, which
+// needs MongoDB running as a replica set. Rule changes get revisions by author.
+func ApplyRuleBundle(ctx context.Context, b *bundle.Bundle, author string) (*bundle.Plan, error) {
+	desired, err := canonicalBundle(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	// Indexes cannot be created inside a transaction
+	ensureRevisionIndex(ctx)
+
+	session, err := db.DB.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	// A rule saved concurrently can claim a revision version this
+	// transaction picked; that aborts it, so start over with fresh versions
+	for attempt := 0; ; attempt++ {
+		plan, err := applyBundleTransaction(ctx, session, desired, author)
+		if err == nil || !mongo.IsDuplicateKeyError(err) || attempt == 2 {
+			invalidateEnrichment()
+			return plan, err
+		}
+	}
+}
+
+func applyBundleTransaction(ctx context.Context, session mongo.Session, desired *bundle.Bundle, author string) (*bundle.Plan, error) {
+	result, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		stored, err := loadStoredBundle(sc)
+		if err != nil {
+			return nil, err
+		}
+		plan, err := bundle.Diff(desired, stored.bundle)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range plan.Changes {
+			if err := applyBundleChange(sc, stored, change, author); err != nil {
+				return nil, fmt.Errorf("%s %s %q: %w", change.Action, change.Section, change.Key, err)
+			}
+		}
+		return plan, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*bundle.Plan), nil
+}
+
+func applyBundleChange(ctx context.Context, stored *storedBundle, change bundle.Change, author string) error {
+	col := db.GetCollection(change.Section)
+	versioned := isRuleCollection(change.Section)
+
+	if change.Action == bundle.Delete {
+		id := stored.ids[change.Section][change.Key]
+		if versioned {
+			var doc bson.M
+			if err := col.FindOne(ctx, bson.M{"_id": id}).Decode(&doc); err != nil {
+				return err
+			}
+			if err := insertRevision(ctx, change.Section, id, doc, "delete", author, 0); err != nil {
+				return err
+			}
+		}
+		_, err := col.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	}
+
+	doc, err := entryDocument(change.Section, change.Entry)
+	if err != nil {
+		return err
+	}
+	var id primitive.ObjectID
+	if change.Action == bundle.Create {
+		res, err := col.InsertOne(ctx, doc)
+		if err != nil {
+			return err
+		}
+		id = res.InsertedID.(primitive.ObjectID)
+	} else {
+		id = stored.ids[change.Section][change.Key]
+		if versioned {
+			if err := saveBaseline(ctx, change.Section, id); err != nil {
+				return err
+			}
+		}
+		update, err := ruleUpdate(change.Section, doc)
+		if err != nil {
+			return err
+		}
+		if _, err := col.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+			return err
+		}
+	}
+	if versioned {
+		return saveRevision(ctx, change.Section, id, "import", author, 0)
+	}
+	return nil
+}
+
+// isRuleCollection reports whether a collection keeps revisions.
+func isRuleCollection(name string) bool {
+	for _, c := range RuleCollections {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// readBundle decodes a YAML or JSON bundle from the request body.
+func readBundle(c *gin.Context) (*bundle.Bundle, error) {
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBundleSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxBundleSize {
+		return nil, fmt.Errorf("bundle larger than %d bytes", maxBundleSize)
+	}
+	return bundle.Decode(raw)
+}
+
+// ExportBundle serves every rule collection and the PagerDuty mappings as one
+// bundle (GET /api/bundle?format=yaml|json).
+func ExportBundle(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	b, err := ExportRuleBundle(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	format := c.DefaultQuery("format", "yaml")
+	out, err := bundle.Encode(b, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	contentType := "application/yaml"
+	if format == "json" {
+		contentType = "application/json"
+	}
+	c.Header("Content-Disposition", "attachment; filename=rules."+format)
+	c.Data(http.StatusOK, contentType, out)
+}
+
+// PlanBundle reports the changes a bundle would make (POST /api/bundle/plan).
+func PlanBundle(c *gin.Context) {
+	b, err := readBundle(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	plan, err := PlanRuleBundle(ctx, b)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// ApplyBundle applies a bundle atomically and returns the executed plan
+// (POST /api/bundle/apply).
+func ApplyBundle(c *gin.Context) {
+	b, err := readBundle(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	plan, err := ApplyRuleBundle(ctx, b, revisionAuthor(c))
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/bundle"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rules"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// validateCalendar checks a calendar before it is stored.
+func validateCalendar(calendar models.DbCalendar) error {
+	if !sourceNamePattern.MatchString(calendar.Name) {
+		return fmt.Errorf("name must be non-empty and contain only letters, digits, '-' and '_'")
+	}
+	_, err := rules.NewCalendar(calendar)
+	return err
+}
+
+// bindCalendar reads and checks a calendar from the request body.
+func bindCalendar(c *gin.Context) (models.DbCalendar, bool) {
+	var calendar models.DbCalendar
+	if err := c.ShouldBindJSON(&calendar); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return calendar, false
+	}
+	if err := validateCalendar(calendar); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return calendar, false
+	}
+	if calendar.Hours == nil {
+		calendar.Hours = []models.CalendarHours{}
+	}
+	if calendar.Holidays == nil {
+		calendar.Holidays = []models.CalendarHoliday{}
+	}
+	return calendar, true
+}
+
+func NewCalendar(c *gin.Context) {
+	calendar, ok := bindCalendar(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := db.GetCollection("calendars")
+	if err := collection.FindOne(ctx, bson.M{"name": calendar.Name}).Err(); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Calendar name already taken"})
+		return
+	} else if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := collection.InsertOne(ctx, calendar)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invalidateEnrichment()
+	c.JSON(http.StatusOK, gin.H{"result": result.InsertedID})
+}
+
+// Handler function to fetch all calendars
+func IndexCalendar(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := db.GetCollection("calendars").Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var records []models.DbCalendar
+	if err := cursor.All(ctx, &records); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if records == nil {
+		records = []models.DbCalendar{}
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+// Handler function to get a single calendar by id
+func EditCalendar(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	var record models.DbCalendar
+	if err := db.GetCollection("calendars").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&record); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// Handler function to update a calendar. Renaming is refused while rules
+// refer to the old name.
+func UpdateCalendar(c *gin.Context) {
+	calendar, ok := bindCalendar(c)
+	if !ok {
+		return
+	}
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	calendar.ID = objectID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.GetCollection("calendars")
+	var current models.DbCalendar
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&current); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+		return
+	}
+	if current.Name != calendar.Name {
+		if collection.FindOne(ctx, bson.M{"name": calendar.Name}).Err() == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Calendar name already taken"})
+			return
+		}
+		if rejectCalendarInUse(ctx, c, current.Name) {
+			return
+		}
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": calendar})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invalidateEnrichment()
+	c.JSON(http.StatusOK, gin.H{"modified": result.ModifiedCount})
+}
+
+// Handler function to delete a calendar no rule refers to
+func DeleteCalendar(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.GetCollection("calendars")
+	var current models.DbCalendar
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&current); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+		return
+	}
+	if rejectCalendarInUse(ctx, c, current.Name) {
+		return
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invalidateEnrichment()
+	c.JSON(http.StatusOK, gin.H{"deleted": result.DeletedCount})
+}
+
+// rejectCalendarInUse answers 409 with the rules of any collection limited
+// to the named calendar, and reports whether it did.
+func rejectCalendarInUse(ctx context.Context, c *gin.Context, name string) bool {
+	users := gin.H{}
+	for _, collection := range RuleCollections {
+		section, _ := bundle.SectionByName(collection)
+		cursor, err := db.GetCollection(collection).Find(ctx, bson.M{"calendar.name": name})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return true
+		}
+		var docs []bundle.Entry
+		if err := cursor.All(ctx, &docs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return true
+		}
+		if len(docs) == 0 {
+			continue
+		}
+		names := make([]string, len(docs))
+		for i, d := range docs {
+			names[i] = section.KeyOf(d)
+		}
+		users[collection] = names
+	}
+	if len(users) == 0 {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "Calendar " + name + " is used by rules", "rules": users})
+	return true
+}
+
+// CalendarStatus shows whether a calendar is open now or at ?at= (RFC 3339)
+// (GET /api/calendars/:id/status).
+func CalendarStatus(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	at := time.Now()
+	if s := c.Query("at"); s != "" {
+		if at, err = time.Parse(time.RFC3339, s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC 3339 time"})
+			return
+		}
+	}
+
+	var record models.DbCalendar
+	if err := db.GetCollection("calendars").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&record); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+		return
+	}
+	calendars, errs := rules.NewCalendars([]models.DbCalendar{record})
+	if len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": errs[0].Error()})
+		return
+	}
+	window, _ := calendars.Check(&models.CalendarCondition{Name: record.Name, Mode: models.CalendarActive}, at)
+	c.JSON(http.StatusOK, window)
+}
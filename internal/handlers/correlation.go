@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/alias"
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
     "github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
@@ -142,14 +144,16 @@ func fetchNodeAlerts(node string) ([]AlertDetail, string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// Alerts stored before their entity had an alias still use the alias
+	names := alias.Names(ctx, node)
 	filter := bson.M{
 		"$or": []bson.M{
-			{"entity": node},
-			{"host": node},
+			{"entity": bson.M{"$in": names}},
+			{"host": bson.M{"$in": names}},
 		},
 	}
 
-	cursor, err := col.Find(ctx, filter)
+	cursor, err := col.Find(ctx, filter, options.Find().SetCollation(alias.Collation))
 	if err != nil {
 		return nil, ""
 	}
@@ -225,6 +229,9 @@ func BuildEntityGraph(root string) (*GraphResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
 
+	// An IP, FQDN or instance ID finds the node it belongs to
+	root = alias.Resolve(ctx, root)
+
 	session := db.GetNeo4jDriver().NewSession(ctx, neo4j.SessionConfig{DatabaseName: "neo4j"})
 	defer session.Close(ctx)
 
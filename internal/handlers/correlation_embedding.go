@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/ai"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/similarity"
+)
+
+const (
+	embeddingCachePrefix = "correlation:embedding:"
+
+	// embeddingRetryAfter is how long EMBEDDING rules use the text metric
+	// after the embedding service failed, so ingestion does not wait on it
+	// for every alert
+	embeddingRetryAfter = time.Minute
+
+	// embeddingTimeout bounds the embedding requests made for one alert, and
+	// maxEmbeddingRequests how many are made; candidates beyond that are
+	// embedded in the background and left out of this alert's scoring
+	embeddingTimeout     = 5 * time.Second
+	maxEmbeddingRequests = 10
+
+	// embeddingQueueSize bounds the alerts waiting for the background
+	// worker; more are dropped and embedded when next scored
+	embeddingQueueSize = 1000
+)
+
+var (
+	embeddingMu        sync.Mutex
+	embeddingDownUntil time.Time
+)
+
+// similarityScores scores every candidate against source on the rule's
+// fields: with the rule's text metric for SIMILARITY rules, by cosine of
+// embeddings for EMBEDDING rules. When source cannot be embedded, all
+// candidates are scored with the text metric instead, so scores stay
+// comparable; candidates without an embedding yet score 0. The method used
+// is returned for the grouping reason, with the threshold on the scale of
+// the scores.
+func similarityScores(ctx context.Context, source models.DbAlert, candidates []models.DbAlert, rule models.DbCorrelationRule) ([]float64, string, float64) {
+	cfg := rule.Similarity
+	if rule.CorrelationMode == models.CorrelationModeEmbedding {
+		if vectors, ok := alertEmbeddings(ctx, append([]models.DbAlert{source}, candidates...), cfg.Fields, cfg.Normalize); ok {
+			scores := make([]float64, len(candidates))
+			for i := range candidates {
+				scores[i] = cosineSimilarity(vectors[0], vectors[i+1])
+			}
+			return scores, "cosine", cfg.CosineThreshold()
+		}
+	}
+	metric := cfg.Metric
+	if metric == "" {
+		metric = similarity.Jaccard
+	}
+	if rule.CorrelationMode == models.CorrelationModeEmbedding {
+		metric += " fallback"
+	}
+	return textScores(source, candidates, cfg), metric, cfg.Threshold
+}
+
+// alertEmbeddings returns the embedding of each alert's text; an alert with
+// no text, or one that could not be embedded in time, gets a nil vector.
+// Alerts not cached yet are embedded, the first one always and at most
+// maxEmbeddingRequests in all; the others are queued for the background
+// worker. ok is false when the first alert, the one being correlated,
+// has no embedding.
+func alertEmbeddings(ctx context.Context, alerts []models.DbAlert, fields, normalize []string) ([][]float64, bool) {
+	if embeddingDown() {
+		return nil, false
+	}
+
+	vectors := make([][]float64, len(alerts))
+	texts := make([]string, len(alerts))
+	var missing []int
+	for i, a := range alerts {
+		texts[i] = strings.TrimSpace(similarityText(a, fields, normalize))
+		if texts[i] == "" {
+			continue
+		}
+		if vectors[i] = cachedEmbedding(ctx, a, texts[i]); vectors[i] == nil {
+			missing = append(missing, i)
+		}
+	}
+
+	// Ingestion waits on these, so bound how many and for how long
+	rctx, cancel := context.WithTimeout(ctx, embeddingTimeout)
+	defer cancel()
+	failed := false
+	for n, i := range missing {
+		if failed || n >= maxEmbeddingRequests {
+			queueEmbedding(alerts[i], fields, normalize)
+			continue
+		}
+		v, err := embedText(rctx, texts[i])
+		if err != nil {
+			failed = true
+			continue
+		}
+		vectors[i] = v
+		storeEmbedding(ctx, alerts[i], texts[i], v)
+	}
+	return vectors, len(alerts) > 0 && vectors[0] != nil
+}
+
+// embeddingDown reports whether the embedding service failed less than
+// embeddingRetryAfter ago.
+func embeddingDown() bool {
+	embeddingMu.Lock()
+	defer embeddingMu.Unlock()
+	return time.Now().Before(embeddingDownUntil)
+}
+
+// embedText asks the embedding service for the vector of text. A failure
+// marks the service down for embeddingRetryAfter.
+func embedText(ctx context.Context, text string) ([]float64, error) {
+	v, err := ai.GetEmbeddingContext(ctx, text)
+	if err == nil && len(v) == 0 {
+		err = fmt.Errorf("empty embedding")
+	}
+	if err != nil {
+		log.Printf("Embedding service unavailable, using the text metric for %s: %v", embeddingRetryAfter, err)
+		embeddingMu.Lock()
+		embeddingDownUntil = time.Now().Add(embeddingRetryAfter)
+		embeddingMu.Unlock()
+		return nil, err
+	}
+	return v, nil
+}
+
+// embeddingJob is an alert text for the background worker to embed.
+type embeddingJob struct {
+	alert     models.DbAlert
+	fields    []string
+	normalize []string
+}
+
+var embeddingQueue = make(chan embeddingJob, embeddingQueueSize)
+
+// queueEmbedding has the background worker embed alert on the given
+// fields, so that alerts scored after it find its vector cached. Alerts
+// without an ID (dry runs) are not cached, and so not queued.
+func queueEmbedding(alert models.DbAlert, fields, normalize []string) {
+	if alert.ID.IsZero() {
+		return
+	}
+	select {
+	case embeddingQueue <- embeddingJob{alert: alert, fields: fields, normalize: normalize}:
+	default:
+	}
+}
+
+// queueRuleEmbeddings queues alert for every EMBEDDING rule, for stored
+// alerts that no EMBEDDING rule embedded while correlating them.
+func queueRuleEmbeddings(alert models.DbAlert, rules []models.DbCorrelationRule) {
+	for _, r := range rules {
+		if r.CorrelationMode == models.CorrelationModeEmbedding {
+			queueEmbedding(alert, r.Similarity.Fields, r.Similarity.Normalize)
+		}
+	}
+}
+
+// StartEmbeddingWorker embeds queued alerts in the background, one at a
+// time, while the embedding service is up.
+func StartEmbeddingWorker() {
+	go func() {
+		for job := range embeddingQueue {
+			if embeddingDown() {
+				continue
+			}
+			text := strings.TrimSpace(similarityText(job.alert, job.fields, job.normalize))
+			if text == "" {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), embeddingTimeout)
+			if cachedEmbedding(ctx, job.alert, text) == nil {
+				if v, err := embedText(ctx, text); err == nil {
+					storeEmbedding(ctx, job.alert, text, v)
+				}
+			}
+			cancel()
+		}
+	}()
+}
+
+// embeddingKey is the Redis key of the embedding of text for alert: the
+// alert's ID and a hash of the text, so an alert whose fields change is
+// embedded again. Alerts without an ID (dry runs) are not cached.
+func embeddingKey(alert models.DbAlert, text string) string {
+	if alert.ID.IsZero() {
+		return ""
+	}
+	sum := sha1.Sum([]byte(ai.OllamaStub.Model + "\x00" + text))
+	return embeddingCachePrefix + alert.ID.Hex() + ":" + hex.EncodeToString(sum[:8])
+}
+
+// cachedEmbedding returns the cached embedding of text for alert, or nil.
+func cachedEmbedding(ctx context.Context, alert models.DbAlert, text string) []float64 {
+	key := embeddingKey(alert, text)
+	if key == "" {
+		return nil
+	}
+	rctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	cached, err := db.RedisClient.Get(rctx, key).Bytes()
+	cancel()
+	var v []float64
+	if err != nil || json.Unmarshal(cached, &v) != nil || len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+func storeEmbedding(ctx context.Context, alert models.DbAlert, text string, v []float64) {
+	key := embeddingKey(alert, text)
+	if key == "" {
+		return
+	}
+	if raw, err := json.Marshal(v); err == nil {
+		rctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		db.RedisClient.Set(rctx, key, raw, embeddingCacheTTL())
+		cancel()
+	}
+}
+
+func embeddingCacheTTL() time.Duration {
+	if s := os.Getenv("EMBEDDING_CACHE_TTL"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("Ignoring EMBEDDING_CACHE_TTL %q", s)
+	}
+	return 24 * time.Hour
+}
+
+// cosineSimilarity is 0 for missing vectors and vectors of different sizes.
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/lsh"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/similarity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// maxIndexCandidates bounds the near duplicates of an alert in the
+	// rule's scope that are scored, and the matches fetched per query
+	maxIndexCandidates = 200
+
+	// maxScanCandidates bounds the most recent alerts scored by EMBEDDING
+	// rules, and by SIMILARITY rules while their index is being built
+	maxScanCandidates = 50
+
+	textIndexPrefix = "correlation:lsh:"
+)
+
+// textIndex is the near-duplicate index of one similarity profile: the
+// open, ungrouped alerts tokenized from the same fields and normalization
+// and banded for the same threshold. Rules with equal profiles share it.
+type textIndex struct {
+	index     *lsh.Index
+	fields    []string
+	normalize []string
+	metric    string
+}
+
+func (t *textIndex) tokens(a models.DbAlert) []string {
+	return similarity.Tokens(t.metric, similarityText(a, t.fields, t.normalize))
+}
+
+var (
+	textIndexMu sync.Mutex
+	textIndexes = map[string]*textIndex{} // ready, by profile key
+	textBuilds  = map[string]*textIndex{} // being built, by profile key
+)
+
+// indexedAlerts matches the alerts a text index holds: those a SIMILARITY
+// rule could group an alert with.
+var indexedAlerts = bson.M{
+	"alertstatus":  bson.M{"$ne": "CLOSED"},
+	"alertdropped": bson.M{"$ne": models.AlertDroppedValue},
+	"parent":       bson.M{"$ne": true},
+	"grouped":      bson.M{"$ne": true},
+}
+
+func indexable(a models.DbAlert) bool {
+	return a.AlertStatus != "CLOSED" && !a.Dropped() && !a.Parent && !a.Grouped
+}
+
+// textProfile returns the index key of a rule's similarity settings and
+// an empty index for them.
+func textProfile(cfg models.SimilarityConfig) (string, *textIndex) {
+	metric := similarity.Jaccard
+	if cfg.Metric == similarity.NGram || cfg.Metric == similarity.Levenshtein {
+		metric = similarity.NGram
+	}
+	steps := append([]string(nil), cfg.Normalize...)
+	sort.Strings(steps)
+	rows := lsh.RowsFor(cfg.Threshold)
+
+	sum := sha1.Sum([]byte(strings.Join(cfg.Fields, ",") + "|" + strings.Join(steps, ",") + "|" + metric + "|" + strconv.Itoa(rows)))
+	key := hex.EncodeToString(sum[:6])
+
+	var store lsh.Store = lsh.NewMemoryStore()
+	if strings.EqualFold(os.Getenv("CORRELATION_INDEX"), "redis") {
+		store = lsh.NewRedisStore(db.RedisClient, textIndexPrefix+key+":")
+	}
+	return key, &textIndex{index: lsh.New(rows, store), fields: cfg.Fields, normalize: steps, metric: metric}
+}
+
+// textIndexFor returns the ready index for a rule's similarity settings.
+// A missing one is built in the background and nil returned meanwhile.
+func textIndexFor(cfg models.SimilarityConfig) *textIndex {
+	key, fresh := textProfile(cfg)
+	textIndexMu.Lock()
+	ix := textIndexes[key]
+	textIndexMu.Unlock()
+	if ix == nil {
+		go buildTextIndex(key, fresh)
+	}
+	return ix
+}
+
+// buildTextIndex loads every indexable alert into the new index ix and
+// makes it the ready index of key. Alerts ingested or closed during the
+// build are applied to it as well.
+func buildTextIndex(key string, ix *textIndex) {
+	textIndexMu.Lock()
+	if textBuilds[key] != nil || textIndexes[key] != nil {
+		textIndexMu.Unlock()
+		return
+	}
+	textBuilds[key] = ix
+	textIndexMu.Unlock()
+
+	n, err := loadTextIndex(ix)
+
+	textIndexMu.Lock()
+	delete(textBuilds, key)
+	if err == nil {
+		textIndexes[key] = ix
+	}
+	textIndexMu.Unlock()
+	if err != nil {
+		log.Printf("Building similarity index %s failed: %v", key, err)
+		return
+	}
+	log.Printf("Similarity index %s built with %d alerts", key, n)
+}
+
+// loadTextIndex adds every indexable alert to ix and removes the entries
+// of alerts that no longer are, other than those added since it started.
+func loadTextIndex(ix *textIndex) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	started := time.Now()
+	cursor, err := db.GetCollection("alerts").Find(ctx, indexedAlerts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+	seen := map[string]bool{}
+	for cursor.Next(ctx) {
+		var a models.DbAlert
+		if err := cursor.Decode(&a); err != nil {
+			continue
+		}
+		if err := ix.index.Add(ctx, a.ID.Hex(), ix.tokens(a), a.AlertFirstTime.Time); err != nil {
+			return len(seen), err
+		}
+		seen[a.ID.Hex()] = true
+	}
+	if err := cursor.Err(); err != nil {
+		return len(seen), err
+	}
+	return len(seen), ix.index.Retain(ctx, func(e lsh.Entry) bool {
+		return seen[e.ID] || !e.Time.Before(started)
+	})
+}
+
+// liveTextIndexes returns the ready indexes and those being built.
+func liveTextIndexes() []*textIndex {
+	textIndexMu.Lock()
+	defer textIndexMu.Unlock()
+	out := make([]*textIndex, 0, len(textIndexes)+len(textBuilds))
+	for _, ix := range textIndexes {
+		out = append(out, ix)
+	}
+	for _, ix := range textBuilds {
+		out = append(out, ix)
+	}
+	return out
+}
+
+// indexAlert adds a newly stored alert to every text index.
+func indexAlert(ctx context.Context, alert models.DbAlert) {
+	if !indexable(alert) {
+		return
+	}
+	for _, ix := range liveTextIndexes() {
+		if err := ix.index.Add(ctx, alert.ID.Hex(), ix.tokens(alert), alert.AlertFirstTime.Time); err != nil {
+			log.Printf("Indexing alert %s failed: %v", alert.ID.Hex(), err)
+		}
+	}
+}
+
+// unindexAlerts removes alerts that were grouped or closed from every text
+// index.
+func unindexAlerts(ctx context.Context, ids ...primitive.ObjectID) {
+	for _, ix := range liveTextIndexes() {
+		for _, id := range ids {
+			if err := ix.index.Remove(ctx, id.Hex()); err != nil {
+				log.Printf("Removing alert %s from the similarity index failed: %v", id.Hex(), err)
+			}
+		}
+	}
+}
+
+// similarityCandidates returns the alerts a similarity rule scores source
+// against: for text metrics the nearest duplicates found by the rule's
+// index among all open alerts in the window, for embeddings (and while the
+// index is built) the most recent ones. filter holds the window and scope.
+func similarityCandidates(ctx context.Context, source models.DbAlert, rule models.DbCorrelationRule, col *mongo.Collection, filter bson.M) ([]models.DbAlert, error) {
+	if rule.CorrelationMode != models.CorrelationModeEmbedding {
+		if ix := textIndexFor(rule.Similarity); ix != nil {
+			since := time.Now().Add(time.Duration(-rule.GroupWindow) * time.Minute)
+			matches, err := ix.index.Query(ctx, ix.tokens(source), since, source.ID.Hex(), 0)
+			if err == nil {
+				return scopedMatches(ctx, col, filter, source, matches)
+			}
+			log.Printf("Similarity index query for rule %s failed, scanning: %v", rule.GroupName, err)
+		}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "alertfirsttime.time", Value: -1}}).SetLimit(maxScanCandidates)
+	return findAlerts(ctx, col, filter, opts)
+}
+
+// scopedMatches fetches the index matches that pass filter, nearest first,
+// until maxIndexCandidates are found. The scope is only known to MongoDB,
+// so matches are fetched a page at a time rather than cut beforehand.
+func scopedMatches(ctx context.Context, col *mongo.Collection, filter bson.M, source models.DbAlert, matches []lsh.Match) ([]models.DbAlert, error) {
+	rank := make(map[primitive.ObjectID]int, len(matches))
+	var found []models.DbAlert
+	for start := 0; start < len(matches) && len(found) < maxIndexCandidates; start += maxIndexCandidates {
+		page := matches[start:min(start+maxIndexCandidates, len(matches))]
+		ids := make([]primitive.ObjectID, 0, len(page))
+		for i, m := range page {
+			if id, err := primitive.ObjectIDFromHex(m.ID); err == nil {
+				ids = append(ids, id)
+				rank[id] = start + i
+			}
+		}
+		scoped := bson.M{}
+		for k, v := range filter {
+			scoped[k] = v
+		}
+		scoped["_id"] = bson.M{"$in": ids, "$ne": source.ID}
+		alerts, err := findAlerts(ctx, col, scoped, options.Find())
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, alerts...)
+	}
+	sort.SliceStable(found, func(i, j int) bool { return rank[found[i].ID] < rank[found[j].ID] })
+	if len(found) > maxIndexCandidates {
+		found = found[:maxIndexCandidates]
+	}
+	return found, nil
+}
+
+func findAlerts(ctx context.Context, col *mongo.Collection, filter bson.M, opts *options.FindOptions) ([]models.DbAlert, error) {
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var alerts []models.DbAlert
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// StartSimilarityIndexes builds the text indexes of the enabled SIMILARITY
+// rules now and refreshes them every CORRELATION_INDEX_REBUILD (a Go
+// duration, default 1h), which also drops the indexes no rule uses any
+// more and repairs changes made outside ingestion.
+func StartSimilarityIndexes() {
+	interval := time.Hour
+	if s := os.Getenv("CORRELATION_INDEX_REBUILD"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			interval = d
+		} else {
+			log.Printf("Ignoring CORRELATION_INDEX_REBUILD %q", s)
+		}
+	}
+	go func() {
+		for {
+			rebuildTextIndexes()
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func rebuildTextIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	correlationRules, err := loadCorrelationRules(ctx)
+	cancel()
+	if err != nil {
+		log.Printf("Loading correlation rules for the similarity index failed: %v", err)
+		return
+	}
+
+	wanted := map[string]*textIndex{}
+	for _, r := range correlationRules {
+		if r.CorrelationMode == models.CorrelationModeSimilarity {
+			key, ix := textProfile(r.Similarity)
+			wanted[key] = ix
+		}
+	}
+
+	textIndexMu.Lock()
+	var unused []*textIndex
+	ready := map[string]*textIndex{}
+	for key, ix := range textIndexes {
+		if wanted[key] == nil {
+			unused = append(unused, ix)
+			delete(textIndexes, key)
+		} else {
+			ready[key] = ix
+		}
+	}
+	textIndexMu.Unlock()
+	for _, ix := range unused {
+		ix.index.Reset(context.Background())
+	}
+
+	for key, ix := range wanted {
+		if live := ready[key]; live != nil {
+			if _, err := loadTextIndex(live); err != nil {
+				log.Printf("Refreshing similarity index %s failed: %v", key, err)
+			}
+			continue
+		}
+		buildTextIndex(key, ix)
+	}
+}
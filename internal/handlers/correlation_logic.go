@@ -3,11 +3,16 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rules"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rulestats"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/similarity"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/topology"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -18,54 +23,265 @@ import (
 // It should be called after an alert is ingested or updated.
 func CorrelateAlert(ctx context.Context, alert models.DbAlert) error {
 	// 1. Fetch all active Correlation Rules
-	rulesCol := db.GetCollection("correlationrules")
-	cursor, err := rulesCol.Find(ctx, bson.M{})
+	rules, err := loadCorrelationRules(ctx)
 	if err != nil {
 		return err
 	}
-	var rules []models.DbCorrelationRule
-	if err := cursor.All(ctx, &rules); err != nil {
-		return err
+
+	calendars, err := loadCalendars(ctx)
+	if err != nil {
+		log.Printf("Loading calendars failed: %v", err)
 	}
 
 	alertsCol := db.GetCollection("alerts")
+	now := time.Now()
 
 	for _, rule := range rules {
-		// Skip if rule has no window defined (safety)
-		if rule.GroupWindow <= 0 {
+		// Rules limited to a calendar only group within (or outside) it
+		if window, err := calendars.Check(rule.Calendar, now); err != nil {
+			log.Printf("Correlation rule %s skipped: %v", rule.GroupName, err)
+			continue
+		} else if window != nil && !window.Active {
 			continue
 		}
+		matched, reason, score := findCorrelationMatch(ctx, alert, rule, alertsCol)
+		if matched != nil {
+			rulestats.Hit(ctx, "correlationrules", rule.ID, alert.AlertId)
+			if err := groupAlerts(ctx, alertsCol, *matched, alert, rule, reason, score); err != nil {
+				return err
+			}
+			// Grouped alerts are no longer similarity candidates
+			unindexAlerts(ctx, matched.ID, alert.ID)
+			return nil
+		}
+	}
+	// Ungrouped alerts are candidates of EMBEDDING rules from now on
+	queueRuleEmbeddings(alert, rules)
+	return nil
+}
+
+// loadCorrelationRules fetches the enabled correlation rules in evaluation order.
+func loadCorrelationRules(ctx context.Context) ([]models.DbCorrelationRule, error) {
+	cursor, err := db.GetCollection("correlationrules").Find(ctx, enabledRules, options.Find().SetSort(bson.D{{Key: "order", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var rules []models.DbCorrelationRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// findCorrelationMatch returns the open alert the rule would group alert
+// with, without modifying anything.
+func findCorrelationMatch(ctx context.Context, alert models.DbAlert, rule models.DbCorrelationRule, alertsCol *mongo.Collection) (*models.DbAlert, *models.GroupingReason, float64) {
+	// Skip disabled rules and rules without a window (safety)
+	if !models.RuleEnabled(rule.Enabled) || rule.GroupWindow <= 0 {
+		return nil, nil, 0
+	}
+
+	// Calculate time window
+	cutoff := time.Now().Add(time.Duration(-rule.GroupWindow) * time.Minute)
+
+	// 2. Find Candidates: Active alerts (not cleared) within time window
+	// We look for alerts that are NOT the current alert
+	filter := bson.M{
+		"_id":             bson.M{"$ne": alert.ID},
+		"alertstatus":     bson.M{"$ne": "CLOSED"}, // Only correlate open alerts
+		"alertfirsttime.time": bson.M{"$gte": cutoff}, // Within window (CustomTime is stored as {time: <date>})
+		"alertdropped":    bson.M{"$ne": models.AlertDroppedValue}, // Dropped alerts are never grouped
+		"grouped":         false,                   // Only look for ungrouped alerts? Or parents? 
+		                                            // Complex topic: usually we look for open Groups (parents) first.
+	}
+
+	// Logic split based on Mode
+	switch rule.CorrelationMode {
+	case models.CorrelationModeSimilarity, models.CorrelationModeEmbedding:
+		return findSimilarityMatch(ctx, alert, rule, alertsCol, filter)
+	case models.CorrelationModeTagBased:
+		return findTagMatch(ctx, alert, rule, alertsCol, filter)
+	case models.CorrelationModeTopology:
+		return findTopologyMatch(ctx, alert, rule, alertsCol, filter)
+	}
+	return nil, nil, 0
+}
+
+// findTagMatch looks for an open alert with the same values as alert for
+// every scope and group tag. Alerts that are already grouped are tried
+// first, so the new alert joins their open parent through groupAlerts
+// rather than starting a second group for the same tags.
+func findTagMatch(ctx context.Context, alert models.DbAlert, rule models.DbCorrelationRule, col *mongo.Collection, baseFilter bson.M) (*models.DbAlert, *models.GroupingReason, float64) {
+	filter := bson.M{}
+	for k, v := range baseFilter {
+		filter[k] = v
+	}
+	delete(filter, "grouped")
+	filter["parent"] = bson.M{"$ne": true}
+
+	if _, ok := sameValues(alert, rule.ScopeTags, filter); !ok {
+		return nil, nil, 0
+	}
+	reasons, ok := sameValues(alert, rule.GroupTags, filter)
+	if !ok || len(reasons) == 0 {
+		return nil, nil, 0
+	}
 
-		// Calculate time window
-		cutoff := time.Now().Add(time.Duration(-rule.GroupWindow) * time.Minute)
-
-		// 2. Find Candidates: Active alerts (not cleared) within time window
-		// We look for alerts that are NOT the current alert
-		filter := bson.M{
-			"_id":             bson.M{"$ne": alert.ID},
-			"alertstatus":     bson.M{"$ne": "CLOSED"}, // Only correlate open alerts
-			"alertfirsttime":  bson.M{"$gte": cutoff},  // Within window
-            "grouped":         false,                   // Only look for ungrouped alerts? Or parents? 
-                                                        // Complex topic: usually we look for open Groups (parents) first.
+	opts := options.FindOne().SetSort(bson.D{{Key: "grouped", Value: -1}, {Key: "alertfirsttime.time", Value: 1}})
+	var match models.DbAlert
+	if err := col.FindOne(ctx, filter, opts).Decode(&match); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Tag correlation for rule %s failed: %v", rule.GroupName, err)
 		}
+		return nil, nil, 0
+	}
+	reason := &models.GroupingReason{
+		Type:        models.CorrelationModeTagBased,
+		Description: "Grouped by tag rule: " + rule.GroupName,
+		Reasons:     reasons,
+	}
+	return &match, reason, 1
+}
 
-        // Logic split based on Mode
-		if rule.CorrelationMode == "SIMILARITY" {
-			matched, reason, score := findSimilarityMatch(ctx, alert, rule, alertsCol, filter)
-			if matched != nil {
-				return groupAlerts(ctx, alertsCol, *matched, alert, rule, reason, score)
-			}
-		} else {
-            // Default TAG_BASED (Simple implementation hook)
-            // Implementation omitted as per user request focus on SIMILARITY, 
-            // but structure is here for backward compatibility.
-            // matched := findTagMatch(ctx, alert, rule, alertsCol, filter)
+// maxTopologyCandidates bounds the open alerts a TOPOLOGY rule looks up in
+// Neo4j for one incoming alert
+const maxTopologyCandidates = 500
+
+// findTopologyMatch groups alert with the open alert whose entity is the
+// fewest hops away in Neo4j, preferring alerts already in a group and then
+// the oldest. The reason names the probable cause: the group's current one,
+// replaced by the new entity when that lies upstream of it.
+func findTopologyMatch(ctx context.Context, alert models.DbAlert, rule models.DbCorrelationRule, col *mongo.Collection, baseFilter bson.M) (*models.DbAlert, *models.GroupingReason, float64) {
+	if alert.Entity == "" || rule.Topology == nil || !topology.Available() {
+		return nil, nil, 0
+	}
+	filter := bson.M{}
+	for k, v := range baseFilter {
+		filter[k] = v
+	}
+	delete(filter, "grouped")
+	filter["parent"] = bson.M{"$ne": true}
+	filter["entity"] = bson.M{"$nin": bson.A{"", nil}}
+
+	reasons, ok := sameValues(alert, rule.ScopeTags, filter)
+	if !ok {
+		return nil, nil, 0
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "alertfirsttime.time", Value: 1}}).SetLimit(maxTopologyCandidates)
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, nil, 0
+	}
+	var candidates []models.DbAlert
+	if err := cursor.All(ctx, &candidates); err != nil || len(candidates) == 0 {
+		return nil, nil, 0
+	}
+	entities := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		entities = append(entities, c.Entity)
+	}
+
+	pathOpts := topology.PathOptions{
+		MaxHops:           rule.Topology.MaxHops,
+		RelationshipTypes: rule.Topology.RelationshipTypes,
+		UpstreamOut:       rule.Topology.Upstream != models.UpstreamIn,
+	}
+	links, err := topology.Links(ctx, alert.Entity, entities, pathOpts)
+	if err != nil {
+		log.Printf("Topology correlation for rule %s failed: %v", rule.GroupName, err)
+		return nil, nil, 0
+	}
+
+	var best *models.DbAlert
+	var bestLink topology.Link
+	for i, c := range candidates {
+		link, ok := links[strings.ToLower(c.Entity)]
+		if !ok {
+			continue
+		}
+		if best == nil || link.Hops < bestLink.Hops || link.Hops == bestLink.Hops && c.Grouped && !best.Grouped {
+			best, bestLink = &candidates[i], link
 		}
 	}
-	return nil
+	if best == nil {
+		return nil, nil, 0
+	}
+
+	// The cause so far: the group's, or the matched alert's entity
+	cause := best.Entity
+	if best.Grouped {
+		var parent models.DbAlert
+		if col.FindOne(ctx, bson.M{"groupalerts": best.ID}).Decode(&parent) == nil && parent.GroupingReason != nil && parent.GroupingReason.ProbableCause != "" {
+			cause = parent.GroupingReason.ProbableCause
+		}
+	}
+	// The cause is usually not among the candidates, so its relation to the
+	// new entity needs its own lookup
+	if !strings.EqualFold(cause, best.Entity) {
+		link, ok, err := topology.LinkTo(ctx, alert.Entity, cause, pathOpts)
+		if err != nil {
+			log.Printf("Topology lookup of probable cause %s for rule %s failed: %v", cause, rule.GroupName, err)
+		} else if ok && link.Relation == topology.Downstream {
+			cause = alert.Entity
+		}
+	} else if bestLink.Relation == topology.Downstream {
+		cause = alert.Entity
+	}
+
+	reasons = append(reasons,
+		fmt.Sprintf("%s is %d hops from %s", alert.Entity, bestLink.Hops, best.Entity),
+		"Probable cause: "+cause)
+	reason := &models.GroupingReason{
+		Type:          models.CorrelationModeTopology,
+		Description:   "Grouped by topology rule: " + rule.GroupName,
+		Reasons:       reasons,
+		ProbableCause: cause,
+		Path:          bestLink.Path,
+	}
+	return best, reason, 1 / float64(1+bestLink.Hops)
+}
+
+// sameValues requires in filter the alert's value of each tag and describes
+// the conditions; ok is false when the alert has no value for one of them.
+func sameValues(alert models.DbAlert, tags []string, filter bson.M) ([]string, bool) {
+	var reasons []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		key, value, ok := scopeCondition(alert, tag)
+		if !ok {
+			return nil, false
+		}
+		filter[key] = value
+		reasons = append(reasons, fmt.Sprintf("Same %s: %v", tag, value))
+	}
+	return reasons, true
+}
+
+// scopeCondition returns the filter key and value that match alerts with the
+// same value of tag as alert, in the type it is stored with: alertcount is
+// an int, and time fields are dates (stored as {time: <date>}, to the
+// millisecond). ok is false when alert has no value for tag.
+func scopeCondition(alert models.DbAlert, tag string) (string, interface{}, bool) {
+	value, found := alert.FieldValue(tag)
+	if !found || value == nil || fmt.Sprint(value) == "" {
+		return "", nil, false
+	}
+	key := rules.FieldKey(tag)
+	if t, ok := value.(time.Time); ok {
+		if t.IsZero() {
+			return "", nil, false
+		}
+		return key + ".time", t.Truncate(time.Millisecond), true
+	}
+	return key, value, true
 }
 
-// findSimilarityMatch searches for a candidate alert/group that matches the similarity rule.
+// findSimilarityMatch searches for a candidate alert/group that matches the
+// similarity rule; EMBEDDING rules compare the same fields semantically.
 func findSimilarityMatch(ctx context.Context, sourceAlert models.DbAlert, rule models.DbCorrelationRule, col *mongo.Collection, baseFilter bson.M) (*models.DbAlert, *models.GroupingReason, float64) {
 	
     // 1. Scope Filtering (Hard Constraint)
@@ -73,52 +289,38 @@ func findSimilarityMatch(ctx context.Context, sourceAlert models.DbAlert, rule m
     scopeFilter := baseFilter
     reasons := []string{}
 
-    for _, tag := range rule.ScopeTags {
-        val := getFieldOrTag(sourceAlert, tag)
-        if val == "" {
-            // Source alert missing required scope tag -> cannot match this rule
-            return nil, nil, 0
-        }
-        // Enforce candidate has same value
-        // Note: Logic allows checking struct fields or AdditionalDetails
-        if isStructField(tag) {
-             scopeFilter[strings.ToLower(tag)] = val
-        } else {
-             scopeFilter["additionaldetails."+tag] = val
-        }
-        reasons = append(reasons, fmt.Sprintf("Same %s: %s", tag, val))
+    // Enforce candidate has same value, on the alert field or the
+    // AdditionalDetails key the tag names; a source alert missing a required
+    // scope tag cannot match this rule
+    scopeReasons, ok := sameValues(sourceAlert, rule.ScopeTags, scopeFilter)
+    if !ok {
+        return nil, nil, 0
     }
+    reasons = append(reasons, scopeReasons...)
 
-    // Fetch candidates passing scope
-    // Optimization: limit candidates?
-    candidatesCur, err := col.Find(ctx, scopeFilter, options.Find().SetLimit(50))
+    // Fetch candidates passing scope: the near duplicates from the index
+    // of open alerts, or the most recent alerts
+    candidates, err := similarityCandidates(ctx, sourceAlert, rule, col, scopeFilter)
     if err != nil {
         return nil, nil, 0
     }
-    defer candidatesCur.Close(ctx)
 
     var bestMatch *models.DbAlert
     var bestScore float64 = 0
 
     // 2. Similarity Calculation (Soft Constraint)
-    for candidatesCur.Next(ctx) {
-        var candidate models.DbAlert
-        if err := candidatesCur.Decode(&candidate); err != nil {
-            continue
-        }
-
-        score := calculateSimilarity(sourceAlert, candidate, rule.Similarity.Fields)
-        if score >= rule.Similarity.Threshold && score > bestScore {
+    scores, method, threshold := similarityScores(ctx, sourceAlert, candidates, rule)
+    for i, score := range scores {
+        if score >= threshold && score > bestScore {
             bestScore = score
-            matchCopy := candidate
-            bestMatch = &matchCopy
+            bestMatch = &candidates[i]
         }
     }
 
     if bestMatch != nil {
-        reasons = append(reasons, fmt.Sprintf("Similar content (Score: %.2f)", bestScore))
+        reasons = append(reasons, fmt.Sprintf("Similar content (Score: %.2f, %s)", bestScore, method))
         reasonObj := &models.GroupingReason{
-            Type:        "SIMILARITY",
+            Type:        rule.CorrelationMode,
             Description: "Grouped by similarity rule: " + rule.GroupName,
             Reasons:     reasons,
         }
@@ -128,70 +330,66 @@ func findSimilarityMatch(ctx context.Context, sourceAlert models.DbAlert, rule m
     return nil, nil, 0
 }
 
-func calculateSimilarity(a, b models.DbAlert, fields []string) float64 {
-    var textA, textB string
-    for _, f := range fields {
-        textA += " " + getFieldOrTag(a, f)
-        textB += " " + getFieldOrTag(b, f)
-    }
-    return jaccardSimilarity(textA, textB)
-}
-
-func jaccardSimilarity(s1, s2 string) float64 {
-    tokens1 := tokenize(s1)
-    tokens2 := tokenize(s2)
-
-    if len(tokens1) == 0 && len(tokens2) == 0 {
-        return 0
+// textScores scores candidates against source with the rule's metric after
+// normalizing the compared fields. Without weights the fields are compared
+// as one text; with weights each field is compared on its own and the
+// scores are averaged by weight (1 for fields not listed), leaving out
+// fields both alerts lack.
+func textScores(source models.DbAlert, candidates []models.DbAlert, cfg models.SimilarityConfig) []float64 {
+    if len(cfg.Weights) == 0 {
+        texts := make([]string, len(candidates))
+        for i, c := range candidates {
+            texts[i] = similarityText(c, cfg.Fields, cfg.Normalize)
+        }
+        return similarity.Scores(cfg.Metric, similarityText(source, cfg.Fields, cfg.Normalize), texts)
     }
 
-    intersection := 0
-    seen := make(map[string]bool)
-    for _, t := range tokens1 {
-        seen[t] = true
-    }
-    for _, t := range tokens2 {
-        if seen[t] {
-            intersection++
-            // Avoid double counting if using set logic? 
-            // Jaccard is |A ^ B| / |A v B|.
-            // Using boolean map for set A.
+    scores := make([]float64, len(candidates))
+    total := make([]float64, len(candidates))
+    for _, f := range cfg.Fields {
+        w, ok := cfg.Weights[f]
+        if !ok {
+            w = 1
+        }
+        if w <= 0 {
+            continue
+        }
+        src := similarityText(source, []string{f}, cfg.Normalize)
+        texts := make([]string, len(candidates))
+        for i, c := range candidates {
+            texts[i] = similarityText(c, []string{f}, cfg.Normalize)
+        }
+        for i, score := range similarity.Scores(cfg.Metric, src, texts) {
+            if strings.TrimSpace(src) == "" && strings.TrimSpace(texts[i]) == "" {
+                continue
+            }
+            scores[i] += w * score
+            total[i] += w
         }
     }
-    
-    // Correct Jaccard Set Implementation
-    setA := make(map[string]struct{})
-    for _, t := range tokens1 { setA[t] = struct{}{} }
-    setB := make(map[string]struct{})
-    for _, t := range tokens2 { setB[t] = struct{}{} }
-    
-    inter := 0
-    for k := range setA {
-        if _, ok := setB[k]; ok {
-            inter++
+    for i := range scores {
+        if total[i] > 0 {
+            scores[i] /= total[i]
         }
     }
-    
-    union := len(setA) + len(setB) - inter
-    if union == 0 { return 0 }
-    return float64(inter) / float64(union)
+    return scores
 }
 
-func tokenize(s string) []string {
-    s = strings.ToLower(s)
-    return strings.Fields(s)
+// similarityText joins the values of the compared fields, normalized.
+func similarityText(a models.DbAlert, fields []string, normalize []string) string {
+    var text string
+    for _, f := range fields {
+        text += " " + getFieldOrTag(a, f)
+    }
+    return similarity.Normalize(text, normalize)
 }
 
 func getFieldOrTag(alert models.DbAlert, key string) string {
-    // Check known struct fields first (case insensitive mapping)
-    switch strings.ToLower(key) {
-    case "summary", "alertsummary": return alert.AlertSummary
-    case "servicename", "service": return alert.ServiceName
-    case "entity": return alert.Entity
-    case "source", "alertsource": return alert.AlertSource
-    case "severity": return alert.Severity
-    case "notes", "alertnotes": return alert.AlertNotes
-    // Add more mappings as needed
+    // Alert fields and their aliases come from the same table the scope
+    // filters use (models.CanonicalAlertField)
+    if field := models.CanonicalAlertField(key); field != "" {
+        v, _ := alert.FieldValue(field)
+        return fmt.Sprintf("%v", v)
     }
     // Fallback to AdditionalDetails
     if v, ok := alert.AdditionalDetails[key]; ok {
@@ -200,14 +398,6 @@ func getFieldOrTag(alert models.DbAlert, key string) string {
     return ""
 }
 
-func isStructField(key string) bool {
-    switch strings.ToLower(key) {
-    case "summary", "alertsummary", "servicename", "service", "entity", "source", "alertsource", "severity", "notes", "alertnotes":
-        return true
-    }
-    return false
-}
-
 // groupAlerts handles creating a parent or merging into existing parent
 func groupAlerts(ctx context.Context, col *mongo.Collection, match models.DbAlert, current models.DbAlert, rule models.DbCorrelationRule, reason *models.GroupingReason, score float64) error {
     
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func scopeAlert() models.DbAlert {
+	return models.DbAlert{
+		Entity:         "web-01",
+		ServiceName:    "checkout",
+		AlertCount:     3,
+		AlertFirstTime: models.CustomTime{Time: time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)},
+		AdditionalDetails: map[string]interface{}{
+			"region": "eu",
+			"k8s":    map[string]interface{}{"namespace": "shop"},
+			"empty":  "",
+		},
+	}
+}
+
+func TestScopeCondition(t *testing.T) {
+	tests := []struct {
+		tag       string
+		wantKey   string
+		wantValue interface{}
+		wantOK    bool
+	}{
+		{"entity", "entity", "web-01", true},
+		{"service", "servicename", "checkout", true},
+		{"alertcount", "alertcount", 3, true},
+		{"alertfirsttime", "alertfirsttime.time", time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.UTC), true},
+		{"region", "additionaldetails.region", "eu", true},
+		{"additionaldetails.region", "additionaldetails.region", "eu", true},
+		{"k8s.namespace", "additionaldetails.k8s.namespace", "shop", true},
+		{"empty", "", nil, false},
+		{"missing", "", nil, false},
+		{"ipaddress", "", nil, false},
+		{"alertcleartime", "", nil, false},
+	}
+	alert := scopeAlert()
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			key, value, ok := scopeCondition(alert, tt.tag)
+			if key != tt.wantKey || !reflect.DeepEqual(value, tt.wantValue) || ok != tt.wantOK {
+				t.Errorf("scopeCondition(%q) = %q, %#v, %v; want %q, %#v, %v", tt.tag, key, value, ok, tt.wantKey, tt.wantValue, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSameValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        []string
+		wantFilter  bson.M
+		wantReasons []string
+		wantOK      bool
+	}{
+		{
+			name:       "no tags",
+			wantFilter: bson.M{"alertstatus": "OPEN"},
+			wantOK:     true,
+		},
+		{
+			name:        "field and detail",
+			tags:        []string{"service", " region ", ""},
+			wantFilter:  bson.M{"alertstatus": "OPEN", "servicename": "checkout", "additionaldetails.region": "eu"},
+			wantReasons: []string{"Same service: checkout", "Same region: eu"},
+			wantOK:      true,
+		},
+		{
+			name:   "alert without the tag",
+			tags:   []string{"region", "missing"},
+			wantOK: false,
+		},
+	}
+	alert := scopeAlert()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := bson.M{"alertstatus": "OPEN"}
+			reasons, ok := sameValues(alert, tt.tags, filter)
+			if ok != tt.wantOK || !reflect.DeepEqual(reasons, tt.wantReasons) {
+				t.Errorf("sameValues = %q, %v; want %q, %v", reasons, ok, tt.wantReasons, tt.wantOK)
+			}
+			// The filter of a rule the alert cannot match is thrown away
+			if ok && !reflect.DeepEqual(filter, tt.wantFilter) {
+				t.Errorf("filter = %v, want %v", filter, tt.wantFilter)
+			}
+		})
+	}
+}
@@ -26,6 +26,9 @@ func NewCorrelation(c *gin.Context) {
     if rule.CorrelationMode == "" {
         rule.CorrelationMode = "TAG_BASED"
     }
+    if rejectInvalidRule(c, &rule) {
+        return
+    }
 
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
@@ -34,6 +37,7 @@ func NewCorrelation(c *gin.Context) {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
+    recordRevision(ctx, "correlationrules", result.InsertedID.(primitive.ObjectID), "create", revisionAuthor(c), 0)
     c.JSON(http.StatusOK, gin.H{"result": result.InsertedID})
 }
 
@@ -50,7 +54,14 @@ func IndexCorrelation(c *gin.Context) {
     }
     defer cur.Close(ctx)
 
-    var records []models.DbCorrelationRule
+    // Rules are served with their hit statistics (null when never matched)
+    type ruleWithStats struct {
+        models.DbCorrelationRule
+        Stats *models.DbRuleStats `json:"stats"`
+    }
+    stats := loadRuleStats(ctx, "correlationrules")
+
+    var records []ruleWithStats
     for cur.Next(ctx) {
         var record models.DbCorrelationRule
         if err := cur.Decode(&record); err != nil {
@@ -66,10 +77,10 @@ func IndexCorrelation(c *gin.Context) {
         if record.GroupTags == nil {
             record.GroupTags = []string{}
         }
-        records = append(records, record)
+        records = append(records, ruleWithStats{record, stats[record.ID]})
     }
     if records == nil {
-        records = []models.DbCorrelationRule{}
+        records = []ruleWithStats{}
     }
 
     c.JSON(http.StatusOK, records)
@@ -125,16 +136,25 @@ func UpdateCorrelation(c *gin.Context) {
     if rule.CorrelationMode == "" {
         rule.CorrelationMode = "TAG_BASED"
     }
+    if rejectInvalidRule(c, &rule) {
+        return
+    }
 
     collection := db.GetCollection("correlationrules")
     updatefilter := bson.M{"_id": objectID}
-    update := bson.M{"$set": rule}
+    update, err := ruleUpdate("correlationrules", rule)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
 
+    ensureBaseline(context.TODO(), "correlationrules", objectID)
     updateResult, updateerr := collection.UpdateOne(context.TODO(), updatefilter, update)
     if updateerr != nil {
         panic(updateerr)
     }
     if updateResult.ModifiedCount > 0 {
+        recordRevision(context.TODO(), "correlationrules", objectID, "update", revisionAuthor(c), 0)
         fmt.Printf("Matched %v documents and updated %v documents.\n", updateResult.MatchedCount, updateResult.ModifiedCount)
     }
     c.JSON(http.StatusOK, gin.H{"modified": updateResult.ModifiedCount})
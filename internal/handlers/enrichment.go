@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rules"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rulestats"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/topology"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// enrichment holds the rules applied to alerts before they are stored,
+// loaded once per ingestion call.
+type enrichment struct {
+	enricher *rules.Enricher
+	topology *topology.Resolver
+}
+
+// enricherTTL is how long compiled rules are shared between ingestion calls.
+// Rule, lookup table and calendar writes made through this server drop them
+// at once; writes made elsewhere apply within the TTL.
+const enricherTTL = 10 * time.Second
+
+var (
+	enricherMu      sync.Mutex
+	enricherCache   *rules.Enricher
+	enricherExpires time.Time
+)
+
+// loadEnrichment returns the compiled alert and tag rules, compiling them
+// when the cached ones expired. Rules compiled after a failed lookup serve
+// only this call. The topology resolver is per call.
+func loadEnrichment(ctx context.Context) *enrichment {
+	enricherMu.Lock()
+	defer enricherMu.Unlock()
+	enricher := enricherCache
+	if enricher == nil || time.Now().After(enricherExpires) {
+		var complete bool
+		enricher, complete = compileEnricher(ctx)
+		if complete {
+			enricherCache, enricherExpires = enricher, time.Now().Add(enricherTTL)
+		}
+	}
+	return &enrichment{enricher: enricher, topology: topology.NewResolver()}
+}
+
+// invalidateEnrichment makes the next ingestion call recompile the rules.
+func invalidateEnrichment() {
+	enricherMu.Lock()
+	enricherCache = nil
+	enricherMu.Unlock()
+}
+
+// compileEnricher fetches and compiles the alert and tag rules, and reports
+// whether every lookup succeeded. Failed lookups and broken rules are logged
+// and the alert is still stored.
+func compileEnricher(ctx context.Context) (*rules.Enricher, bool) {
+	complete := true
+	alertRules, err := loadAlertRules(ctx)
+	if err != nil {
+		log.Printf("Loading alert rules failed: %v", err)
+		complete = false
+	}
+	tagRules, err := loadTagRules(ctx)
+	if err != nil {
+		log.Printf("Loading tag rules failed: %v", err)
+		complete = false
+	}
+
+	tables, err := loadLookupTables(ctx)
+	if err != nil {
+		log.Printf("Loading lookup tables failed: %v", err)
+		complete = false
+	}
+
+	calendars, err := loadCalendars(ctx)
+	if err != nil {
+		log.Printf("Loading calendars failed: %v", err)
+		complete = false
+	}
+
+	enricher, errs := rules.NewEnricher(alertRules, tagRules, tables, calendars)
+	for _, err := range errs {
+		log.Printf("Skipping rule: %v", err)
+	}
+	return enricher, complete
+}
+
+// ruleHit is a rule that matched an alert during enrichment.
+type ruleHit struct {
+	collection string
+	id         primitive.ObjectID
+}
+
+// apply resolves the entity of alert, stamps its topology so rules can use
+// it and enriches it. It returns the rules that matched, for recordHits once
+// the alert is stored. A repeat of an open alert is only kept for its count,
+// so its topology is not looked up.
+func (e *enrichment) apply(ctx context.Context, alert *models.DbAlert, repeat bool) []ruleHit {
+	if entity := canonicalEntity(ctx, *alert); entity != alert.Entity {
+		if alert.Entity != "" {
+			alert.SetDetail("original_entity", alert.Entity)
+		}
+		alert.Entity = entity
+	}
+	if !repeat {
+		e.topology.Stamp(ctx, alert)
+	}
+	var hits []ruleHit
+	for _, res := range e.enricher.Apply(alert) {
+		if !res.Matched {
+			continue
+		}
+		hits = append(hits, ruleHit{res.RuleType + "rules", res.RuleID})
+		// Compile errors were already logged by compileEnricher
+		if res.Error != "" {
+			log.Printf("%s rule %s failed on alert %s: %s", res.RuleType, res.RuleName, alert.AlertId, res.Error)
+		}
+	}
+	return hits
+}
+
+// recordHits counts the hits of rules that matched an alert, against the
+// alert as stored: a repeat counts against the open alert it was folded
+// into.
+func recordHits(ctx context.Context, hits []ruleHit, alertID string) {
+	for _, h := range hits {
+		rulestats.Hit(ctx, h.collection, h.id, alertID)
+	}
+}
+
+// loadLookupTables fetches the lookup tables tag rules refer to.
+func loadLookupTables(ctx context.Context) ([]models.DbLookupTable, error) {
+	cursor, err := db.GetCollection("lookuptables").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var records []models.DbLookupTable
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// loadCalendars fetches and compiles the business calendars rules may be
+// limited to. Broken calendars are logged and left out.
+func loadCalendars(ctx context.Context) (rules.Calendars, error) {
+	cursor, err := db.GetCollection("calendars").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var records []models.DbCalendar
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	calendars, errs := rules.NewCalendars(records)
+	for _, err := range errs {
+		log.Printf("Skipping %v", err)
+	}
+	return calendars, nil
+}
+
+// loadAlertRules fetches the enabled alert rules in evaluation order.
+func loadAlertRules(ctx context.Context) ([]models.DbAlertRule, error) {
+	cursor, err := db.GetCollection("alertrules").Find(ctx, enabledRules, options.Find().SetSort(bson.D{{Key: "order", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var records []models.DbAlertRule
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// loadTagRules fetches the enabled tag rules in evaluation order.
+func loadTagRules(ctx context.Context) ([]models.DbTagRule, error) {
+	cursor, err := db.GetCollection("tagrules").Find(ctx, enabledRules, options.Find().SetSort(bson.D{{Key: "order", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var records []models.DbTagRule
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/alias"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// bindEntityAlias reads and checks an alias from the request body.
+func bindEntityAlias(c *gin.Context) (models.DbEntityAlias, bool) {
+	var record models.DbEntityAlias
+	if err := c.ShouldBindJSON(&record); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return record, false
+	}
+	record.Alias = alias.Normalize(record.Alias)
+	if record.Alias == "" || record.Entity == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "alias and entity are required"})
+		return record, false
+	}
+	if record.Kind == "" {
+		record.Kind = alias.Kind(record.Alias)
+	}
+	record.Source = models.AliasSourceManual
+	record.UpdatedAt = time.Now().UTC()
+	return record, true
+}
+
+// reloadAliases makes an API change visible to this instance right away;
+// other instances pick it up on their next refresh.
+func reloadAliases(ctx context.Context) {
+	if err := alias.Reload(ctx); err != nil {
+		log.Printf("Reloading entity aliases failed: %v", err)
+	}
+}
+
+// NewEntityAlias adds a manual alias. An alias synced from Neo4j is taken
+// over and kept from then on.
+func NewEntityAlias(c *gin.Context) {
+	record, ok := bindEntityAlias(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	col := alias.Collection(ctx)
+	var existing models.DbEntityAlias
+	err := col.FindOne(ctx, bson.M{"alias": record.Alias}).Decode(&existing)
+	switch {
+	case err == nil && existing.Source == models.AliasSourceManual:
+		c.JSON(http.StatusConflict, gin.H{"error": "Alias already exists", "id": existing.ID})
+		return
+	case err != nil && err != mongo.ErrNoDocuments:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := col.UpdateOne(ctx, bson.M{"alias": record.Alias}, bson.M{"$set": record}, options.Update().SetUpsert(true))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id := existing.ID
+	if result.UpsertedID != nil {
+		id = result.UpsertedID.(primitive.ObjectID)
+	}
+	reloadAliases(ctx)
+	c.JSON(http.StatusOK, gin.H{"result": id})
+}
+
+// IndexEntityAlias lists aliases, optionally of one entity (?entity=) or
+// containing a string (?q=).
+func IndexEntityAlias(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if entity := c.Query("entity"); entity != "" {
+		filter["entity"] = entity
+	}
+	if q := c.Query("q"); q != "" {
+		filter["alias"] = bson.M{"$regex": regexp.QuoteMeta(alias.Normalize(q))}
+	}
+	cursor, err := alias.Collection(ctx).Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "entity", Value: 1}, {Key: "alias", Value: 1}}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var records []models.DbEntityAlias
+	if err := cursor.All(ctx, &records); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if records == nil {
+		records = []models.DbEntityAlias{}
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+// UpdateEntityAlias replaces an alias; it becomes a manual one.
+func UpdateEntityAlias(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	record, ok := bindEntityAlias(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	col := alias.Collection(ctx)
+	if col.FindOne(ctx, bson.M{"alias": record.Alias, "_id": bson.M{"$ne": objectID}}).Err() == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Alias already exists"})
+		return
+	}
+	result, err := col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": record})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+		return
+	}
+	reloadAliases(ctx)
+	c.JSON(http.StatusOK, gin.H{"modified": result.ModifiedCount})
+}
+
+// DeleteEntityAlias removes an alias. Aliases synced from Neo4j return with
+// the next sync while the node still carries them.
+func DeleteEntityAlias(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := alias.Collection(ctx).DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	reloadAliases(ctx)
+	c.JSON(http.StatusOK, gin.H{"deleted": result.DeletedCount})
+}
+
+// SyncEntityAliases runs the Neo4j alias sync now (POST /api/aliases/sync).
+func SyncEntityAliases(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := alias.SyncNeo4j(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ResolveEntityAlias shows what a name resolves to
+// (GET /api/aliases/resolve?name=10.0.3.7).
+func ResolveEntityAlias(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entity, found := alias.Canonical(ctx, name)
+	if !found {
+		entity = name
+	}
+	c.JSON(http.StatusOK, gin.H{"name": name, "entity": entity, "found": found, "aliases": alias.Names(ctx, entity)})
+}
+
+// canonicalEntity returns the canonical entity of an alert: Entity resolved
+// through the alias registry, or the entity of IpAddress when the alert has
+// no other name.
+func canonicalEntity(ctx context.Context, alert models.DbAlert) string {
+	if alert.Entity == "" || alert.Entity == alert.IpAddress {
+		if entity, ok := alias.Canonical(ctx, alert.IpAddress); ok {
+			return entity
+		}
+		return alert.Entity
+	}
+	return alias.Resolve(ctx, alert.Entity)
+}
+
+// aliasIndex maps every lower-cased name of the given entities, aliases
+// included, to the entity, for matching records stored under an alias.
+func aliasIndex(ctx context.Context, entities []string) map[string]string {
+	index := make(map[string]string, len(entities))
+	for _, e := range entities {
+		for _, name := range alias.Names(ctx, e) {
+			name = strings.ToLower(name)
+			if _, ok := index[name]; !ok {
+				index[name] = e
+			}
+		}
+	}
+	return index
+}
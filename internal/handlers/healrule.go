@@ -3,13 +3,17 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
+	"reflect"
 	"time"
 
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rulestats"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,13 +21,16 @@ import (
 
 
 func NewHeal(c *gin.Context) {
-    var alertRule  models.DbAlertRule
-    collection := db.GetCollection("alertrules")
+    var alertRule  models.DbHealRule
+    collection := db.GetCollection("healrules")
 
     if err := c.ShouldBindJSON(&alertRule); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+    if rejectInvalidRule(c, &alertRule) {
+        return
+    }
 
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -32,13 +39,14 @@ func NewHeal(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordRevision(ctx, "healrules", result.InsertedID.(primitive.ObjectID), "create", revisionAuthor(c), 0)
 	c.JSON(http.StatusOK, gin.H{"result": result.InsertedID})
 }
 
 
 // Handler function to fetch all records
 func IndexHeal(c *gin.Context) {
-    collection := db.GetCollection("alertrules")
+    collection := db.GetCollection("healrules")
     ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
     defer cancel()
 
@@ -61,6 +69,7 @@ func IndexHeal(c *gin.Context) {
     if records == nil {
 		records = []bson.M{}
 	} 
+    attachRuleStats(ctx, "healrules", records)
 
     c.JSON(http.StatusOK, records)
 }
@@ -75,7 +84,7 @@ func EditHeal(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
         return
     }
-    collection := db.GetCollection("alertrules")
+    collection := db.GetCollection("healrules")
     ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
     defer cancel()
 
@@ -86,7 +95,7 @@ func EditHeal(c *gin.Context) {
     }
     defer cur.Close(ctx)
 
-    var record models.DbAlertRule
+    var record models.DbHealRule
     err1 := collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&record)
     if err1 != nil {
         fmt.Println("Error is ", err1)
@@ -100,11 +109,14 @@ func EditHeal(c *gin.Context) {
 
 // Handler function to update a record.
 func UpdateHeal(c *gin.Context) {
-    var alertRule  models.DbAlertRule
+    var alertRule  models.DbHealRule
     if err := c.ShouldBindJSON(&alertRule); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+    if rejectInvalidRule(c, &alertRule) {
+        return
+    }
 
     id := c.Param("id")
     // Convert string ID to BSON ObjectID
@@ -114,26 +126,107 @@ func UpdateHeal(c *gin.Context) {
         return
     }
 
-    collection := db.GetCollection("alertrules")
+    collection := db.GetCollection("healrules")
 	updatefilter := bson.M{"_id": objectID }
     // Prepare the update document using the $set operator
-	update := bson.M{"$set": alertRule}
+	update, err := ruleUpdate("healrules", alertRule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
+    ensureBaseline(context.TODO(), "healrules", objectID)
     updateResult , updateerr := collection.UpdateOne(context.TODO(), updatefilter, update)
     if updateerr != nil {
         panic(updateerr)
     }
     if updateResult.ModifiedCount > 0 {
+        recordRevision(context.TODO(), "healrules", objectID, "update", revisionAuthor(c), 0)
         fmt.Printf("Matched %v documents and updated %v documents.\n", updateResult.MatchedCount, updateResult.ModifiedCount)
     }
 	c.JSON(http.StatusOK, gin.H{"modified": updateResult.ModifiedCount})
 }
 
+// RecordHealHit counts a heal rule hit reported by the executor that ran
+// the rule (POST /api/healrules/:id/hits), since the server never evaluates
+// heal rules itself.
+func RecordHealHit(c *gin.Context) {
+    objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+        return
+    }
+    var body struct {
+        AlertID string `json:"alert_id" binding:"required"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
 
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    if err := db.GetCollection("healrules").FindOne(ctx, bson.M{"_id": objectID}).Err(); err != nil {
+        if err == mongo.ErrNoDocuments {
+            c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+            return
+        }
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    rulestats.Hit(ctx, "healrules", objectID, body.AlertID)
+    c.JSON(http.StatusOK, gin.H{"recorded": true})
+}
 
+// MigrateHealRules moves the heal rules that earlier versions saved in
+// alertrules into healrules, keeping their ids and recording a "migrate"
+// revision for each, and returns how many it moved. It runs at startup;
+// once every heal rule is moved it finds nothing to do.
+//
+// A heal rule is an alertrules document with neither a setfield nor
+// actions: alert rules are validated to have one of them since rule
+// validation, and the action migration never leaves a rule with neither.
+// A document is deleted from alertrules only once healrules holds an
+// identical copy; a different rule stored under the same id is reported
+// and left alone.
+func MigrateHealRules(ctx context.Context) (int, error) {
+	alertRules := db.GetCollection("alertrules")
+	healRules := db.GetCollection("healrules")
+
+	filter := bson.M{"setfield": bson.M{"$in": bson.A{"", nil}}, "actions": bson.M{"$exists": false}}
+	cursor, err := alertRules.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return 0, err
+	}
 
-
-
-
-
-
+	moved := 0
+	for _, doc := range docs {
+		id, _ := doc["_id"].(primitive.ObjectID)
+		// Alert rule leftovers of the SetField form have no meaning here
+		delete(doc, "setfield")
+
+		if _, err := healRules.InsertOne(ctx, doc); err != nil {
+			if !mongo.IsDuplicateKeyError(err) {
+				return moved, err
+			}
+			var stored bson.M
+			if err := healRules.FindOne(ctx, bson.M{"_id": id}).Decode(&stored); err != nil {
+				return moved, err
+			}
+			if !reflect.DeepEqual(stored, doc) {
+				log.Printf("Heal rule %s not moved: healrules already has a different rule with this id", id.Hex())
+				continue
+			}
+		}
+		if _, err := alertRules.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+			return moved, err
+		}
+		recordRevision(ctx, "healrules", id, "migrate", "system", 0)
+		moved++
+	}
+	return moved, nil
+}
@@ -0,0 +1,493 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IngestAlert runs an incoming alert through the ingestion pipeline.
+// Alerts are deduplicated on their Fingerprint: a repeat of an open alert bumps
+// its AlertCount and AlertLastTime, anything else is stored as a new alert and
+// handed to CorrelateAlert. It returns the stored alert and whether it was new.
+func IngestAlert(ctx context.Context, alert models.DbAlert) (models.DbAlert, bool, error) {
+	clearServerFields(&alert)
+	prepareAlert(&alert)
+	col := db.GetCollection("alerts")
+	repeat := openFingerprints(ctx, col, []string{alert.Fingerprint})[alert.Fingerprint]
+	hits := loadEnrichment(ctx).apply(ctx, &alert, repeat)
+	alert.Open = alert.AlertStatus != "CLOSED"
+
+	// Without a fingerprint there is nothing to deduplicate on
+	if alert.Fingerprint == "" {
+		if _, err := col.InsertOne(ctx, alert); err != nil {
+			return alert, false, err
+		}
+		recordHits(ctx, hits, alert.AlertId)
+		afterInsert(ctx, alert)
+		return alert, true, nil
+	}
+
+	update, err := dedupUpdate(alert)
+	if err != nil {
+		return alert, false, err
+	}
+
+	var stored models.DbAlert
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err = col.FindOneAndUpdate(ctx, dedupFilter(alert.Fingerprint), update, opts).Decode(&stored)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent upsert inserted the alert first; this one is a repeat
+		err = col.FindOneAndUpdate(ctx, dedupFilter(alert.Fingerprint), update, opts).Decode(&stored)
+	}
+	if err != nil {
+		return alert, false, err
+	}
+
+	recordHits(ctx, hits, stored.AlertId)
+	created := stored.ID == alert.ID
+	if created {
+		afterInsert(ctx, stored)
+	}
+	return stored, created, nil
+}
+
+// IngestAlertBatch ingests many alerts with a single ordered bulk write,
+// applying the same deduplication as IngestAlert. Repeats within the batch
+// collapse onto the first occurrence.
+func IngestAlertBatch(ctx context.Context, alerts []models.DbAlert) (created int, updated int, err error) {
+	if len(alerts) == 0 {
+		return 0, 0, nil
+	}
+
+	col := db.GetCollection("alerts")
+	fingerprints := make([]string, len(alerts))
+	for i, a := range alerts {
+		fingerprints[i] = a.Fingerprint
+	}
+	// Repeats of an open alert or of an earlier alert of the batch
+	repeats := openFingerprints(ctx, col, fingerprints)
+
+	enrich := loadEnrichment(ctx)
+	writes := make([]mongo.WriteModel, 0, len(alerts))
+	inserts := map[int]bool{}
+	hits := make([][]ruleHit, len(alerts))
+	for i := range alerts {
+		fingerprint := alerts[i].Fingerprint
+		clearServerFields(&alerts[i])
+		prepareAlert(&alerts[i])
+		hits[i] = enrich.apply(ctx, &alerts[i], repeats[fingerprint])
+		alerts[i].Open = alerts[i].AlertStatus != "CLOSED"
+		if fingerprint != "" {
+			repeats[fingerprint] = true
+		}
+		if alerts[i].Fingerprint == "" {
+			inserts[i] = true
+			writes = append(writes, mongo.NewInsertOneModel().SetDocument(alerts[i]))
+			continue
+		}
+		update, err := dedupUpdate(alerts[i])
+		if err != nil {
+			return 0, 0, err
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(dedupFilter(alerts[i].Fingerprint)).
+			SetUpdate(update).
+			SetUpsert(true))
+	}
+
+	// Ordered so that a repeat later in the batch sees the alert upserted earlier
+	retried := -1
+	for start := 0; start < len(writes); {
+		result, err := col.BulkWrite(ctx, writes[start:], options.BulkWrite().SetOrdered(true))
+		if result != nil {
+			for idx := range result.UpsertedIDs {
+				inserts[start+int(idx)] = true
+			}
+		}
+		if err == nil {
+			break
+		}
+		// A concurrent upsert inserted one of the alerts first: resume from
+		// the failed write, which now finds and updates it
+		failed, ok := duplicateWrite(err)
+		if !ok || start+failed == retried {
+			return 0, 0, err
+		}
+		start += failed
+		retried = start
+	}
+
+	// Repeats count their hits against the open alert they were folded into
+	storedIDs, err := openAlertIDs(ctx, col, alerts, inserts, hits)
+	if err != nil {
+		log.Printf("Looking up deduplicated alerts for rule stats failed: %v", err)
+	}
+	for i := range alerts {
+		if inserts[i] {
+			recordHits(ctx, hits[i], alerts[i].AlertId)
+		} else if id, ok := storedIDs[alerts[i].Fingerprint]; ok {
+			recordHits(ctx, hits[i], id)
+		}
+	}
+
+	for i := range alerts {
+		if inserts[i] {
+			afterInsert(ctx, alerts[i])
+		}
+	}
+	created = len(inserts)
+	return created, len(alerts) - created, nil
+}
+
+// openFingerprints reports which of fingerprints an open alert carries. A
+// failed query is logged and reports none, so the alerts are enriched in full.
+func openFingerprints(ctx context.Context, col *mongo.Collection, fingerprints []string) map[string]bool {
+	open := map[string]bool{}
+	var query []string
+	for _, f := range fingerprints {
+		if f != "" {
+			query = append(query, f)
+		}
+	}
+	if len(query) == 0 {
+		return open
+	}
+	values, err := col.Distinct(ctx, "fingerprint", bson.M{"fingerprint": bson.M{"$in": query}, "open": true})
+	if err != nil {
+		log.Printf("Looking up open alerts by fingerprint failed: %v", err)
+		return open
+	}
+	for _, v := range values {
+		if f, ok := v.(string); ok {
+			open[f] = true
+		}
+	}
+	return open
+}
+
+// openAlertIDs returns the AlertId of the open alert each repeat in a batch
+// was folded into, by fingerprint. Only repeats that matched rules are
+// looked up.
+func openAlertIDs(ctx context.Context, col *mongo.Collection, alerts []models.DbAlert, inserts map[int]bool, hits [][]ruleHit) (map[string]string, error) {
+	var fingerprints []string
+	for i, a := range alerts {
+		if !inserts[i] && len(hits[i]) > 0 {
+			fingerprints = append(fingerprints, a.Fingerprint)
+		}
+	}
+	ids := map[string]string{}
+	if len(fingerprints) == 0 {
+		return ids, nil
+	}
+	filter := bson.M{"fingerprint": bson.M{"$in": fingerprints}, "open": true}
+	opts := options.Find().SetProjection(bson.M{"fingerprint": 1, "alertid": 1})
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return ids, err
+	}
+	var stored []models.DbAlert
+	if err := cursor.All(ctx, &stored); err != nil {
+		return ids, err
+	}
+	for _, a := range stored {
+		ids[a.Fingerprint] = a.AlertId
+	}
+	return ids, nil
+}
+
+// ResolveAlert closes the open alert carrying fingerprint, stamping its clear
+// time and propagating the closure through its correlation group.
+// It reports whether an open alert was found.
+func ResolveAlert(ctx context.Context, fingerprint string, clearTime time.Time, comment string) (bool, error) {
+	col := db.GetCollection("alerts")
+
+	var alert models.DbAlert
+	err := col.FindOne(ctx, dedupFilter(fingerprint)).Decode(&alert)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if clearTime.IsZero() {
+		clearTime = time.Now()
+	}
+	worklog := models.WorkLog{
+		ID:        primitive.NewObjectID(),
+		Author:    "System",
+		Comment:   comment,
+		CreatedAt: time.Now(),
+	}
+	update := bson.M{
+		"$push": bson.M{"worklogs": worklog},
+		"$set": bson.M{
+			"alertstatus":    "CLOSED",
+			"alertcleartime": models.CustomTime{Time: clearTime},
+		},
+		"$unset": bson.M{"open": ""},
+	}
+	if _, err := col.UpdateOne(ctx, bson.M{"_id": alert.ID}, update); err != nil {
+		return true, err
+	}
+
+	cascadeClose(ctx, col, alert)
+	return true, nil
+}
+
+// clearServerFields drops what a producer may not set on an incoming alert:
+// its identity, lifecycle and grouping state, and what integrations and
+// users add later. Otherwise an alert could overwrite another by _id, arrive
+// already closed and bypass deduplication, or hide itself in a group.
+func clearServerFields(alert *models.DbAlert) {
+	alert.ID = primitive.NilObjectID
+	alert.AlertId = ""
+	alert.AlertStatus = ""
+	alert.AlertAcked = ""
+	alert.AlertCount = 0
+	alert.AlertClearTime = models.CustomTime{}
+	alert.AlertDropped = ""
+	alert.Open = false
+	alert.WorkLogs = nil
+	alert.GroupIdentifier = ""
+	alert.Grouped = false
+	alert.GroupIncidentId = ""
+	alert.GroupAlerts = nil
+	alert.Parent = false
+	alert.ChildAlerts = nil
+	alert.GroupingReason = nil
+	alert.AIRCA = nil
+	alert.Feedback = nil
+	alert.PagerDutyIncidentNumber = 0
+	alert.PagerDutyIncidentId = ""
+	alert.PagerDutyPriority = ""
+	alert.PagerDutyUrgency = ""
+	alert.PagerDutyHtmlUrl = ""
+	alert.PagerDutyService = ""
+	alert.PagerDutyEscalationPolicy = ""
+	alert.Major_incident_number = 0
+	alert.Major_incident_id = ""
+	alert.Major_incident_url = ""
+	alert.Major_incident_status = ""
+}
+
+// prepareAlert fills in the defaults every stored alert is expected to carry.
+func prepareAlert(alert *models.DbAlert) {
+	now := time.Now()
+	if alert.ID.IsZero() {
+		alert.ID = primitive.NewObjectID()
+	}
+	if alert.AlertId == "" {
+		alert.AlertId = alert.ID.Hex()
+	}
+	if alert.AlertFirstTime.IsZero() {
+		alert.AlertFirstTime = models.CustomTime{Time: now}
+	}
+	if alert.AlertLastTime.IsZero() {
+		alert.AlertLastTime = models.CustomTime{Time: now}
+	}
+	if alert.AlertStatus == "" {
+		alert.AlertStatus = "OPEN"
+	}
+	if alert.AlertAcked == "" {
+		alert.AlertAcked = "NO"
+	}
+	if alert.AlertCount < 1 {
+		alert.AlertCount = 1
+	}
+	alert.Severity = normalizeSeverity(alert.Severity)
+	if alert.AlertPriority == "" {
+		alert.AlertPriority = priorityForSeverity(alert.Severity)
+	}
+	if alert.AdditionalDetails == nil {
+		alert.AdditionalDetails = map[string]interface{}{}
+	}
+	// $push / $addToSet fail on null, so store empty arrays
+	if alert.WorkLogs == nil {
+		alert.WorkLogs = []models.WorkLog{}
+	}
+	if alert.GroupAlerts == nil {
+		alert.GroupAlerts = []primitive.ObjectID{}
+	}
+}
+
+// afterInsert runs the post-ingestion steps for a newly stored alert.
+// Alerts dropped by a rule are kept but not correlated.
+func afterInsert(ctx context.Context, alert models.DbAlert) {
+	if alert.Dropped() {
+		return
+	}
+	indexAlert(ctx, alert)
+	if err := CorrelateAlert(ctx, alert); err != nil {
+		log.Printf("Correlation failed for alert %s: %v", alert.ID.Hex(), err)
+	}
+}
+
+// dedupFilter matches the open alert carrying fingerprint. It uses the same
+// predicate as the unique index, so that every alert it can match is
+// protected from concurrent duplicates.
+func dedupFilter(fingerprint string) bson.M {
+	return bson.M{
+		"fingerprint": fingerprint,
+		"open":        true,
+	}
+}
+
+// duplicateWrite returns the position of the write an ordered bulk write
+// stopped at, when it stopped on a duplicate key.
+func duplicateWrite(err error) (int, bool) {
+	var bulk mongo.BulkWriteException
+	if !errors.As(err, &bulk) || len(bulk.WriteErrors) == 0 || !mongo.IsDuplicateKeyError(bulk.WriteErrors[0]) {
+		return 0, false
+	}
+	return bulk.WriteErrors[0].Index, true
+}
+
+// EnsureAlertIndexes creates the unique index that stops two concurrent
+// upserts from storing the same open alert twice. Only alerts flagged open
+// with a fingerprint are indexed, so closed repeats are kept. Alerts stored
+// before the flag existed are flagged from their status first.
+func EnsureAlertIndexes(ctx context.Context) error {
+	col := db.GetCollection("alerts")
+	backfill := bson.M{
+		"fingerprint": bson.M{"$exists": true},
+		"alertstatus": bson.M{"$ne": "CLOSED"},
+		"open":        bson.M{"$exists": false},
+	}
+	if _, err := col.UpdateMany(ctx, backfill, bson.M{"$set": bson.M{"open": true}}); err != nil {
+		return err
+	}
+	// The earlier index only covered alertstatus "OPEN"
+	if _, err := col.Indexes().DropOne(ctx, "fingerprint_open_unique"); err != nil {
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || cmdErr.Name != "IndexNotFound" {
+			return err
+		}
+	}
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "fingerprint", Value: 1}},
+		Options: options.Index().
+			SetName("fingerprint_live_unique").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{
+				"fingerprint": bson.M{"$exists": true},
+				"open":        true,
+			}),
+	})
+	return err
+}
+
+// dedupUpdate builds the upsert that inserts alert when no open duplicate
+// exists, and otherwise only bumps the duplicate's count and last-seen time.
+func dedupUpdate(alert models.DbAlert) (bson.M, error) {
+	raw, err := bson.Marshal(alert)
+	if err != nil {
+		return nil, err
+	}
+	var onInsert bson.M
+	if err := bson.Unmarshal(raw, &onInsert); err != nil {
+		return nil, err
+	}
+	// These are maintained by $set/$inc and must not appear twice in the update
+	delete(onInsert, "alertcount")
+	delete(onInsert, "alertlasttime")
+
+	return bson.M{
+		"$setOnInsert": onInsert,
+		"$set":         bson.M{"alertlasttime": alert.AlertLastTime},
+		"$inc":         bson.M{"alertcount": alert.AlertCount},
+	}, nil
+}
+
+// dedupFieldsFromEnv returns the alert fields the dedup key is computed from,
+// configurable through ALERT_DEDUP_FIELDS (comma separated).
+func dedupFieldsFromEnv() []string {
+	fields := os.Getenv("ALERT_DEDUP_FIELDS")
+	if fields == "" {
+		fields = "entity,alerttype,alertsource"
+	}
+	return splitFields(fields)
+}
+
+func splitFields(s string) []string {
+	fields := []string{}
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// FingerprintAlert computes the dedup key for an alert ingested outside the
+// HTTP handlers. Empty fields fall back to ALERT_DEDUP_FIELDS.
+func FingerprintAlert(alert models.DbAlert, fields []string) string {
+	if len(fields) == 0 {
+		fields = dedupFieldsFromEnv()
+	}
+	return alertFingerprint(alert, fields)
+}
+
+// alertFingerprint hashes the values of fields into a dedup key. Fields may
+// name alert fields or AdditionalDetails keys. An alert carrying none of the
+// fields gets no fingerprint and is never deduplicated.
+func alertFingerprint(alert models.DbAlert, fields []string) string {
+	h := sha256.New()
+	empty := true
+	for _, f := range fields {
+		v := getFieldOrTag(alert, f)
+		if v != "" {
+			empty = false
+		}
+		h.Write([]byte(strings.ToLower(f)))
+		h.Write([]byte{0})
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	if empty {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// normalizeSeverity maps the many vendor spellings of severity onto the
+// CRITICAL / ERROR / WARN / INFO scale used across the UI.
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "crit", "fatal", "emergency", "emerg", "alert", "disaster", "high":
+		return "CRITICAL"
+	case "error", "err", "major":
+		return "ERROR"
+	case "warning", "warn", "minor", "average", "medium":
+		return "WARN"
+	case "", "info", "informational", "information", "notice", "low", "none", "debug", "ok":
+		return "INFO"
+	}
+	return strings.ToUpper(severity)
+}
+
+// priorityForSeverity gives alerts that arrive without a priority a sensible default.
+func priorityForSeverity(severity string) string {
+	switch severity {
+	case "CRITICAL":
+		return "P1"
+	case "ERROR":
+		return "P2"
+	case "WARN":
+		return "P3"
+	}
+	return "P4"
+}
@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/jsonpath"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var sourceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// IngestFromSource receives a vendor webhook on /ingest/:source and maps it
+// onto alerts using the source's stored JSONPath mapping.
+func IngestFromSource(c *gin.Context) {
+	name := c.Param("source")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var source models.DbIngestSource
+	err := db.GetCollection("ingestsources").FindOne(ctx, bson.M{"name": name}).Decode(&source)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown ingest source " + name})
+		return
+	}
+
+	// Only the header is accepted: query strings end up in proxy and access logs
+	if source.Token != "" {
+		token := c.GetHeader("X-Ingest-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(source.Token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid ingest token"})
+			return
+		}
+	}
+
+	var payload interface{}
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.UseNumber() // keep large ids and epoch timestamps exact
+	if err := decoder.Decode(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// SNS subscriptions must be confirmed by visiting SubscribeURL. We do not
+	// fetch URLs taken from an unauthenticated payload, so leave it to an operator.
+	if m, ok := payload.(map[string]interface{}); ok && m["Type"] == "SubscriptionConfirmation" {
+		log.Printf("Ingest source %s received an SNS subscription confirmation: %v", name, m["SubscribeURL"])
+		c.JSON(http.StatusOK, gin.H{"status": "subscription_confirmation_logged"})
+		return
+	}
+
+	items, err := sourceItems(source, payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, updated, resolved := 0, 0, 0
+	itemErrors := []gin.H{}
+	for i, item := range items {
+		alert, closed, err := alertFromSourceItem(source, item)
+		if err != nil {
+			itemErrors = append(itemErrors, gin.H{"item": i, "error": err.Error()})
+			continue
+		}
+
+		if closed {
+			found, err := ResolveAlert(ctx, alert.Fingerprint, alert.AlertClearTime.Time, "Alert resolved by "+source.Name)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if found {
+				resolved++
+			}
+			continue
+		}
+
+		_, isNew, err := IngestAlert(ctx, alert)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if isNew {
+			created++
+		} else {
+			updated++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"created":  created,
+		"updated":  updated,
+		"resolved": resolved,
+		"errors":   itemErrors,
+	})
+}
+
+// sourceItems unwraps the payload and splits it into individual alert items.
+func sourceItems(source models.DbIngestSource, payload interface{}) ([]interface{}, error) {
+	doc := payload
+	if source.UnwrapPath != "" {
+		v, ok, err := jsonpath.Get(doc, source.UnwrapPath)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if s, isString := v.(string); isString {
+				decoder := json.NewDecoder(strings.NewReader(s))
+				decoder.UseNumber()
+				var inner interface{}
+				if err := decoder.Decode(&inner); err != nil {
+					return nil, fmt.Errorf("unwrap %s: %v", source.UnwrapPath, err)
+				}
+				doc = inner
+			} else {
+				doc = v
+			}
+		}
+	}
+
+	if source.ItemsPath == "" {
+		return []interface{}{doc}, nil
+	}
+	path, err := jsonpath.Compile(source.ItemsPath)
+	if err != nil {
+		return nil, err
+	}
+	items := path.Find(doc)
+	// "$.alerts" selects the array itself rather than its elements
+	if len(items) == 1 {
+		if arr, ok := items[0].([]interface{}); ok {
+			return arr, nil
+		}
+	}
+	return items, nil
+}
+
+// alertFromSourceItem applies the source mapping to one item. It reports
+// closed when the translated status says the alert has recovered.
+func alertFromSourceItem(source models.DbIngestSource, item interface{}) (models.DbAlert, bool, error) {
+	alert := models.DbAlert{AdditionalDetails: map[string]interface{}{}}
+	closed := false
+
+	for field, expr := range source.FieldMappings {
+		v, ok, err := jsonpath.Get(item, expr)
+		if err != nil {
+			return alert, false, err
+		}
+		if !ok || v == nil {
+			continue
+		}
+		value := stringifyValue(v)
+
+		switch models.CanonicalAlertField(field) {
+		case "severity":
+			value = translateValue(source.SeverityMap, value)
+		case "alertstatus":
+			value = strings.ToUpper(translateValue(source.StatusMap, value))
+			if value == "CLOSED" || value == "RESOLVED" {
+				closed = true
+				value = "CLOSED"
+			} else {
+				value = "OPEN"
+			}
+		}
+		if err := alert.SetField(field, value); err != nil {
+			return alert, false, err
+		}
+	}
+
+	for key, expr := range source.DetailMappings {
+		v, ok, err := jsonpath.Get(item, expr)
+		if err != nil {
+			return alert, false, err
+		}
+		if ok {
+			alert.AdditionalDetails[models.DetailKey(key)] = plainValue(v)
+		}
+	}
+
+	if alert.AlertSource == "" {
+		alert.AlertSource = source.Name
+	}
+	if alert.Fingerprint == "" {
+		fields := source.DedupFields
+		if len(fields) == 0 {
+			fields = dedupFieldsFromEnv()
+		}
+		alert.Fingerprint = alertFingerprint(alert, fields)
+	}
+	if closed && alert.Fingerprint == "" {
+		return alert, false, fmt.Errorf("recovery without a fingerprint cannot be matched to an alert")
+	}
+	return alert, closed, nil
+}
+
+// translateValue looks value up case-insensitively in table, returning it unchanged when absent.
+func translateValue(table map[string]string, value string) string {
+	if v, ok := table[value]; ok {
+		return v
+	}
+	for k, v := range table {
+		if strings.EqualFold(k, value) {
+			return v
+		}
+	}
+	return value
+}
+
+func stringifyValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	case nil:
+		return ""
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// plainValue converts json.Number leaves into int64/float64 so they store as BSON numbers.
+func plainValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			out[k] = plainValue(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, child := range val {
+			out[i] = plainValue(child)
+		}
+		return out
+	}
+	return v
+}
+
+// validateIngestSource checks that a source can be used before it is stored.
+func validateIngestSource(source models.DbIngestSource) error {
+	if !sourceNamePattern.MatchString(source.Name) {
+		return fmt.Errorf("name must be non-empty and contain only letters, digits, '-' and '_'")
+	}
+	for _, expr := range []string{source.UnwrapPath, source.ItemsPath} {
+		if expr == "" {
+			continue
+		}
+		if _, err := jsonpath.Compile(expr); err != nil {
+			return err
+		}
+	}
+	for field, expr := range source.FieldMappings {
+		if models.CanonicalAlertField(field) == "" {
+			return fmt.Errorf("field_mappings: %q is not an alert field", field)
+		}
+		if _, err := jsonpath.Compile(expr); err != nil {
+			return err
+		}
+	}
+	for key, expr := range source.DetailMappings {
+		// Mongo refuses field names starting with '$'
+		if key == "" || strings.HasPrefix(key, "$") {
+			return fmt.Errorf("detail_mappings: %q is not a valid key", key)
+		}
+		if _, err := jsonpath.Compile(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewIngestSource(c *gin.Context) {
+	var source models.DbIngestSource
+	collection := db.GetCollection("ingestsources")
+
+	if err := c.ShouldBindJSON(&source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateIngestSource(source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := collection.FindOne(ctx, bson.M{"name": source.Name}).Err(); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ingest source name already taken"})
+		return
+	} else if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := collection.InsertOne(ctx, source)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": result.InsertedID})
+}
+
+// Handler function to fetch all ingest sources
+func IndexIngestSource(c *gin.Context) {
+	collection := db.GetCollection("ingestsources")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cur, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer cur.Close(ctx)
+
+	var records []models.DbIngestSource
+	if err := cur.All(ctx, &records); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if records == nil {
+		records = []models.DbIngestSource{}
+	}
+	for i := range records {
+		records[i].Redact()
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+// Handler function to get a single ingest source by id
+func EditIngestSource(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	collection := db.GetCollection("ingestsources")
+
+	var record models.DbIngestSource
+	if err := collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&record); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+		return
+	}
+	record.Redact()
+
+	c.JSON(http.StatusOK, record)
+}
+
+// Handler function to update an ingest source. Responses never carry the
+// token, so an empty token keeps the stored one and clear_token removes it.
+func UpdateIngestSource(c *gin.Context) {
+	var source models.DbIngestSource
+	if err := c.ShouldBindJSON(&source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if source.ClearToken && source.Token != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token and clear_token cannot be combined"})
+		return
+	}
+	if err := validateIngestSource(source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	source.ID = objectID
+
+	collection := db.GetCollection("ingestsources")
+	taken := collection.FindOne(context.TODO(), bson.M{"name": source.Name, "_id": bson.M{"$ne": objectID}}).Err()
+	if taken == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ingest source name already taken"})
+		return
+	}
+
+	update := bson.M{"$set": source}
+	if source.ClearToken {
+		update["$unset"] = bson.M{"token": ""}
+	}
+	updateResult, err := collection.UpdateOne(context.TODO(), bson.M{"_id": objectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"modified": updateResult.ModifiedCount})
+}
+
+// Handler function to delete an ingest source
+func DeleteIngestSource(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+
+	collection := db.GetCollection("ingestsources")
+	result, err := collection.DeleteOne(context.TODO(), bson.M{"_id": objectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": result.DeletedCount})
+}
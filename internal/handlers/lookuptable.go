@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxLookupCSVSize bounds an uploaded lookup table CSV
+const maxLookupCSVSize = 8 * 1024 * 1024
+
+// validateLookupTable checks a lookup table before it is stored.
+func validateLookupTable(table models.DbLookupTable) error {
+	if !sourceNamePattern.MatchString(table.Name) {
+		return fmt.Errorf("name must be non-empty and contain only letters, digits, '-' and '_'")
+	}
+	switch table.Match {
+	case "", models.LookupMatchExact, models.LookupMatchPrefix:
+	default:
+		return fmt.Errorf("match must be %q or %q", models.LookupMatchExact, models.LookupMatchPrefix)
+	}
+	for i, e := range table.Entries {
+		if e.Key == "" {
+			return fmt.Errorf("entries[%d]: key is required", i)
+		}
+	}
+	return nil
+}
+
+func NewLookupTable(c *gin.Context) {
+	var table models.DbLookupTable
+	collection := db.GetCollection("lookuptables")
+
+	if err := c.ShouldBindJSON(&table); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateLookupTable(table); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if table.Entries == nil {
+		table.Entries = []models.LookupEntry{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := collection.FindOne(ctx, bson.M{"name": table.Name}).Err(); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Lookup table name already taken"})
+		return
+	} else if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := collection.InsertOne(ctx, table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invalidateEnrichment()
+	c.JSON(http.StatusOK, gin.H{"result": result.InsertedID})
+}
+
+// Handler function to fetch all lookup tables
+func IndexLookupTable(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	records, err := loadLookupTables(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if records == nil {
+		records = []models.DbLookupTable{}
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+// Handler function to get a single lookup table by id
+func EditLookupTable(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	collection := db.GetCollection("lookuptables")
+
+	var record models.DbLookupTable
+	if err := collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&record); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// Handler function to update a lookup table. Renaming is refused while tag
+// rules refer to the old name.
+func UpdateLookupTable(c *gin.Context) {
+	var table models.DbLookupTable
+	if err := c.ShouldBindJSON(&table); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateLookupTable(table); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if table.Entries == nil {
+		table.Entries = []models.LookupEntry{}
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	table.ID = objectID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.GetCollection("lookuptables")
+	var current models.DbLookupTable
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&current); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+		return
+	}
+	if current.Name != table.Name {
+		if collection.FindOne(ctx, bson.M{"name": table.Name}).Err() == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Lookup table name already taken"})
+			return
+		}
+		if rejectLookupInUse(ctx, c, current.Name) {
+			return
+		}
+	}
+
+	updateResult, err := collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": table})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invalidateEnrichment()
+	c.JSON(http.StatusOK, gin.H{"modified": updateResult.ModifiedCount})
+}
+
+// Handler function to delete a lookup table no tag rule refers to
+func DeleteLookupTable(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.GetCollection("lookuptables")
+	var current models.DbLookupTable
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&current); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+		return
+	}
+	if rejectLookupInUse(ctx, c, current.Name) {
+		return
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invalidateEnrichment()
+	c.JSON(http.StatusOK, gin.H{"deleted": result.DeletedCount})
+}
+
+// rejectLookupInUse answers 409 with the tag rules using the named table, and
+// reports whether it did.
+func rejectLookupInUse(ctx context.Context, c *gin.Context, name string) bool {
+	cursor, err := db.GetCollection("tagrules").Find(ctx, bson.M{"extractions.lookups.table": name})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return true
+	}
+	var users []models.DbTagRule
+	if err := cursor.All(ctx, &users); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return true
+	}
+	if len(users) == 0 {
+		return false
+	}
+	names := make([]string, len(users))
+	for i, r := range users {
+		names[i] = r.RuleName
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "Lookup table " + name + " is used by tag rules", "rules": names})
+	return true
+}
+
+// ImportLookupTable loads entries from a CSV of key,value rows
+// (POST /api/lookuptables/:id/import?mode=replace|merge). The CSV is the
+// request body or a multipart "file" field; a first row of "key,value" is
+// taken as a header. replace (default) drops the current entries, merge keeps
+// them and overwrites matching keys.
+func ImportLookupTable(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+	mode := c.DefaultQuery("mode", "replace")
+	if mode != "replace" && mode != "merge" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be replace or merge"})
+		return
+	}
+
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+	imported, err := readLookupCSV(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	collection := db.GetCollection("lookuptables")
+	var table models.DbLookupTable
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&table); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+		return
+	}
+
+	entries := imported
+	if mode == "merge" {
+		entries = mergeLookupEntries(table.Entries, imported)
+	}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"entries": entries}}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invalidateEnrichment()
+	c.JSON(http.StatusOK, gin.H{"imported": len(imported), "entries": len(entries)})
+}
+
+// readLookupCSV parses key,value rows; later rows win over earlier ones with
+// the same key and extra columns are ignored.
+func readLookupCSV(r io.Reader) ([]models.LookupEntry, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, maxLookupCSVSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxLookupCSVSize {
+		return nil, fmt.Errorf("CSV larger than %d bytes", maxLookupCSVSize)
+	}
+
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(raw), "\ufeff")))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && len(rows[0]) >= 2 && strings.EqualFold(strings.TrimSpace(rows[0][0]), "key") && strings.EqualFold(strings.TrimSpace(rows[0][1]), "value") {
+		rows = rows[1:]
+	}
+
+	var entries []models.LookupEntry
+	for i, row := range rows {
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		if len(row) < 2 {
+			return nil, fmt.Errorf("row %d: expected key,value", i+1)
+		}
+		key := strings.TrimSpace(row[0])
+		if key == "" {
+			return nil, fmt.Errorf("row %d: key is required", i+1)
+		}
+		entries = append(entries, models.LookupEntry{Key: key, Value: strings.TrimSpace(row[1])})
+	}
+	return mergeLookupEntries(nil, entries), nil
+}
+
+// mergeLookupEntries overwrites the entries of current with those of update
+// by key and appends the new keys.
+func mergeLookupEntries(current, update []models.LookupEntry) []models.LookupEntry {
+	out := append([]models.LookupEntry{}, current...)
+	index := make(map[string]int, len(out))
+	for i, e := range out {
+		index[e.Key] = i
+	}
+	for _, e := range update {
+		if i, ok := index[e.Key]; ok {
+			out[i].Value = e.Value
+			continue
+		}
+		index[e.Key] = len(out)
+		out = append(out, e)
+	}
+	return out
+}
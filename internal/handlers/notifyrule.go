@@ -24,6 +24,9 @@ func NewNotify(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+    if rejectInvalidRule(c, &notifyRule) {
+        return
+    }
 
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -32,6 +35,7 @@ func NewNotify(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordRevision(ctx, "notifyrules", result.InsertedID.(primitive.ObjectID), "create", revisionAuthor(c), 0)
 	c.JSON(http.StatusOK, gin.H{"result": result.InsertedID})
 }
 
@@ -61,6 +65,7 @@ func IndexNotify(c *gin.Context) {
     if records == nil {
 		records = []bson.M{}
 	} 
+    attachRuleStats(ctx, "notifyrules", records)
 
     c.JSON(http.StatusOK, records)
 }
@@ -105,6 +110,9 @@ func UpdateNotify(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+    if rejectInvalidRule(c, &notifyRule) {
+        return
+    }
 
     id := c.Param("id")
     // Convert string ID to BSON ObjectID
@@ -117,13 +125,19 @@ func UpdateNotify(c *gin.Context) {
     collection := db.GetCollection("notifyrules")
 	updatefilter := bson.M{"_id": objectID }
     // Prepare the update document using the $set operator
-	update := bson.M{"$set": notifyRule}
+	update, err := ruleUpdate("notifyrules", notifyRule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
+    ensureBaseline(context.TODO(), "notifyrules", objectID)
     updateResult , updateerr := collection.UpdateOne(context.TODO(), updatefilter, update)
     if updateerr != nil {
         panic(updateerr)
     }
     if updateResult.ModifiedCount > 0 {
+        recordRevision(context.TODO(), "notifyrules", objectID, "update", revisionAuthor(c), 0)
         fmt.Printf("Matched %v documents and updated %v documents.\n", updateResult.MatchedCount, updateResult.ModifiedCount)
     }
 	c.JSON(http.StatusOK, gin.H{"modified": updateResult.ModifiedCount})
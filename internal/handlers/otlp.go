@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/otlp"
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxOTLPBody bounds a decompressed OTLP export request
+const maxOTLPBody = 16 * 1024 * 1024
+
+// otlpMinSeverity reads OTLP_MIN_SEVERITY (number or name, default ERROR).
+func otlpMinSeverity() int {
+	if s := os.Getenv("OTLP_MIN_SEVERITY"); s != "" {
+		n, err := otlp.ParseSeverity(s)
+		if err == nil {
+			return n
+		}
+		log.Printf("Ignoring OTLP_MIN_SEVERITY: %v", err)
+	}
+	return otlp.SeverityError
+}
+
+// OTLPLogs implements the OTLP/HTTP logs endpoint (POST /v1/logs). Records at
+// or above OTLP_MIN_SEVERITY become alerts; repeats of a trace or of a body
+// template collapse into one alert through the usual fingerprint dedup.
+// When OTLP_TOKEN is set exporters must send it as X-Ingest-Token.
+func OTLPLogs(c *gin.Context) {
+	if token := os.Getenv("OTLP_TOKEN"); token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Ingest-Token")), []byte(token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid ingest token"})
+		return
+	}
+
+	var body io.Reader = c.Request.Body
+	if strings.EqualFold(c.GetHeader("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	raw, err := io.ReadAll(io.LimitReader(body, maxOTLPBody+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(raw) > maxOTLPBody {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "OTLP export request too large"})
+		return
+	}
+
+	isProtobuf := strings.HasPrefix(c.ContentType(), "application/x-protobuf")
+	var records []otlp.Record
+	if isProtobuf {
+		records, err = otlp.DecodeProtobuf(raw)
+	} else {
+		records, err = otlp.DecodeJSON(raw)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	minSeverity := otlpMinSeverity()
+	alerts := []models.DbAlert{}
+	for _, r := range records {
+		if r.Severity() >= minSeverity {
+			alerts = append(alerts, otlp.ToAlert(r))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if _, _, err := IngestAlertBatch(ctx, alerts); err != nil {
+		// 503 tells OTLP exporters to retry the export
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	// An empty ExportLogsServiceResponse signals full success
+	if isProtobuf {
+		out, _ := proto.Marshal(&collogs.ExportLogsServiceResponse{})
+		c.Data(http.StatusOK, "application/x-protobuf", out)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
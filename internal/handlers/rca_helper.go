@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/alias"
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
@@ -13,7 +15,7 @@ import (
 
 // BuildRCAGraph constructs the RCA graph for a given alert
 func BuildRCAGraph(ctx context.Context, alert models.DbAlert) (models.RCAGraphPayload, error) {
-	rootEntityID := alert.Entity
+	rootEntityID := canonicalEntity(ctx, alert)
 	
 	// 1. Build Graph Nodes & Edges
 	nodes := []models.RCANode{}
@@ -134,11 +136,19 @@ func BuildRCAGraph(ctx context.Context, alert models.DbAlert) (models.RCAGraphPa
     // (Time condition) AND (Entity condition)
     // Entity condition is: affected_entities == root OR affected_entities IN neighbors
     
+    // Changes may name an entity by any of its aliases
+    rootNames := alias.Names(ctx, rootEntityID)
+    neighborNames := aliasIndex(ctx, neighborEntities)
+    neighborKeys := make([]string, 0, len(neighborNames))
+    for k := range neighborNames {
+        neighborKeys = append(neighborKeys, k)
+    }
+
     entityCondition := bson.A{
-        bson.M{"affected_entities": rootEntityID},
+        bson.M{"affected_entities": bson.M{"$in": rootNames}},
     }
-    if len(neighborEntities) > 0 {
-        entityCondition = append(entityCondition, bson.M{"affected_entities": bson.M{"$in": neighborEntities}})
+    if len(neighborKeys) > 0 {
+        entityCondition = append(entityCondition, bson.M{"affected_entities": bson.M{"$in": neighborKeys}})
     }
 
     // Final Filter construction
@@ -156,7 +166,7 @@ func BuildRCAGraph(ctx context.Context, alert models.DbAlert) (models.RCAGraphPa
     }
 
 	changesCollection := db.GetCollection("changes")
-	cursor, err := changesCollection.Find(ctx, finalFilter, options.Find().SetLimit(100)) // Cap changes
+	cursor, err := changesCollection.Find(ctx, finalFilter, options.Find().SetLimit(100).SetCollation(alias.Collation)) // Cap changes
 	if err == nil {
 		var changes []models.Change
 		if err := cursor.All(ctx, &changes); err == nil {
@@ -169,10 +179,11 @@ func BuildRCAGraph(ctx context.Context, alert models.DbAlert) (models.RCAGraphPa
                 // Check if direct
 				isDirect := false
 				for _, aff := range ch.AffectedEntities {
-					if aff == rootEntityID {
-						isDirect = true
-                        targetEntity = rootEntityID
-						break
+					for _, name := range rootNames {
+						if strings.EqualFold(aff, name) {
+							isDirect = true
+							targetEntity = rootEntityID
+						}
 					}
 				}
 				
@@ -183,10 +194,11 @@ func BuildRCAGraph(ctx context.Context, alert models.DbAlert) (models.RCAGraphPa
 					// Find closest neighbor
 					minDist := 100
 					for _, aff := range ch.AffectedEntities {
-						if d, ok := neighborsMap[aff]; ok {
+						entity := neighborNames[strings.ToLower(aff)]
+						if d, ok := neighborsMap[entity]; ok {
 							if d < minDist {
 								minDist = d
-								targetEntity = aff
+								targetEntity = entity
 							}
 						}
 					}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/alias"
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
 	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
@@ -43,7 +45,7 @@ func GetRelatedChanges(c *gin.Context) {
 		return
 	}
 
-	rootEntityID := alert.Entity
+	rootEntityID := canonicalEntity(ctx, alert)
 	alertStartTime := alert.AlertFirstTime.Time
 	alertEndTime := alert.AlertClearTime.Time
 	effectiveEndTime := alertEndTime
@@ -106,11 +108,12 @@ func GetRelatedChanges(c *gin.Context) {
 	}
 
 	changesCollection := db.GetCollection("changes")
-	findOptions := options.Find().SetSort(bson.D{{Key: "start_time", Value: -1}})
+	// Aliases are lower-cased; changes keep the case they were filed with
+	findOptions := options.Find().SetSort(bson.D{{Key: "start_time", Value: -1}}).SetCollation(alias.Collation)
 
 	// 4. Query & Process Direct Changes
 	directFilter := cloneMap(baseFilter)
-	directFilter["affected_entities"] = rootEntityID
+	directFilter["affected_entities"] = bson.M{"$in": alias.Names(ctx, rootEntityID)}
 	
 	// Initialize as empty slice so JSON returns [] instead of null
 	directChanges := make([]models.RelatedChange, 0)
@@ -135,9 +138,15 @@ func GetRelatedChanges(c *gin.Context) {
 		for k := range neighborsMap {
 			neighborEntities = append(neighborEntities, k)
 		}
+		// Changes may name a neighbor by any of its aliases
+		neighborNames := aliasIndex(ctx, neighborEntities)
+		neighborKeys := make([]string, 0, len(neighborNames))
+		for k := range neighborNames {
+			neighborKeys = append(neighborKeys, k)
+		}
 		
 		neighborFilter := cloneMap(baseFilter)
-		neighborFilter["affected_entities"] = bson.M{"$in": neighborEntities}
+		neighborFilter["affected_entities"] = bson.M{"$in": neighborKeys}
 		
 		cursorNeighbor, err := changesCollection.Find(ctx, neighborFilter, findOptions)
 		if err == nil {
@@ -151,7 +160,8 @@ func GetRelatedChanges(c *gin.Context) {
 					
 					// Check overlap between change.AffectedEntities and neighborsMap
 					foundMatch := false
-					for _, affected := range ch.AffectedEntities {
+					for _, name := range ch.AffectedEntities {
+						affected := neighborNames[strings.ToLower(name)]
 						if dist, ok := neighborsMap[affected]; ok {
 							if dist < bestHop {
 								bestHop = dist
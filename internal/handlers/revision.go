@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RuleCollections are the rule collections whose changes are versioned. The
+// names double as the API path segments.
+var RuleCollections = []string{"alertrules", "tagrules", "healrules", "notifyrules", "correlationrules"}
+
+const revisionsCollection = "rulerevisions"
+
+var revisionIndexOnce sync.Once
+
+// ensureRevisionIndex makes (collection, rule_id, version) unique so that two
+// concurrent saves cannot claim the same version.
+func ensureRevisionIndex(ctx context.Context) {
+	revisionIndexOnce.Do(func() {
+		_, err := db.GetCollection(revisionsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "collection", Value: 1}, {Key: "rule_id", Value: 1}, {Key: "version", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			log.Printf("Creating rule revision index failed: %v", err)
+		}
+	})
+}
+
+// revisionAuthor is the JWT username of the caller.
+func revisionAuthor(c *gin.Context) string {
+	if username := c.GetString("username"); username != "" {
+		return username
+	}
+	return "unknown"
+}
+
+// recordRevision snapshots the rule as currently stored. Failures are logged;
+// the change itself has already been saved.
+func recordRevision(ctx context.Context, collection string, ruleID primitive.ObjectID, action, author string, restored int) {
+	if err := saveRevision(ctx, collection, ruleID, action, author, restored); err != nil {
+		log.Printf("Recording %s revision of %s %s failed: %v", action, collection, ruleID.Hex(), err)
+	}
+}
+
+func saveRevision(ctx context.Context, collection string, ruleID primitive.ObjectID, action, author string, restored int) error {
+	ensureRevisionIndex(ctx)
+
+	var doc bson.M
+	if err := db.GetCollection(collection).FindOne(ctx, bson.M{"_id": ruleID}).Decode(&doc); err != nil {
+		return err
+	}
+	return insertRevision(ctx, collection, ruleID, doc, action, author, restored)
+}
+
+// insertRevision stores doc as the next revision of the rule, retrying when
+// a concurrent save claimed the version. Inside a transaction the first
+// error aborts it, so the duplicate key is returned for the caller to retry
+// the whole transaction (see ApplyRuleBundle).
+func insertRevision(ctx context.Context, collection string, ruleID primitive.ObjectID, doc bson.M, action, author string, restored int) error {
+	// Every rule change is followed by a revision, so compiled rules are dropped here
+	invalidateEnrichment()
+	revisions := db.GetCollection(revisionsCollection)
+	inTransaction := mongo.SessionFromContext(ctx) != nil
+	for attempt := 0; ; attempt++ {
+		latest, err := latestRevision(ctx, collection, ruleID)
+		if err != nil {
+			return err
+		}
+		rev := models.DbRuleRevision{
+			Collection:      collection,
+			RuleID:          ruleID,
+			Version:         latest + 1,
+			Action:          action,
+			Author:          author,
+			CreatedAt:       time.Now(),
+			RestoredVersion: restored,
+			Document:        doc,
+		}
+		_, err = revisions.InsertOne(ctx, rev)
+		if err == nil || !mongo.IsDuplicateKeyError(err) || inTransaction || attempt == 2 {
+			return err
+		}
+	}
+}
+
+// ensureBaseline records the stored rule as its first revision when it
+// predates versioning, so the first edit can still be rolled back.
+func ensureBaseline(ctx context.Context, collection string, ruleID primitive.ObjectID) {
+	if err := saveBaseline(ctx, collection, ruleID); err != nil {
+		log.Printf("Recording baseline revision of %s %s failed: %v", collection, ruleID.Hex(), err)
+	}
+}
+
+// saveBaseline is ensureBaseline for callers that must not ignore errors,
+// such as transactions.
+func saveBaseline(ctx context.Context, collection string, ruleID primitive.ObjectID) error {
+	latest, err := latestRevision(ctx, collection, ruleID)
+	if err != nil || latest > 0 {
+		return err
+	}
+	return saveRevision(ctx, collection, ruleID, "baseline", "system", 0)
+}
+
+// latestRevision returns the highest version of a rule, 0 when there is none.
+func latestRevision(ctx context.Context, collection string, ruleID primitive.ObjectID) (int, error) {
+	var rev models.DbRuleRevision
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"version": 1})
+	err := db.GetCollection(revisionsCollection).FindOne(ctx, bson.M{"collection": collection, "rule_id": ruleID}, opts).Decode(&rev)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return rev.Version, nil
+}
+
+func findRevision(ctx context.Context, collection string, ruleID primitive.ObjectID, version int) (models.DbRuleRevision, error) {
+	var rev models.DbRuleRevision
+	err := db.GetCollection(revisionsCollection).FindOne(ctx, bson.M{"collection": collection, "rule_id": ruleID, "version": version}).Decode(&rev)
+	return rev, err
+}
+
+// ListRevisions returns the revisions of a rule, newest first
+// (GET /api/<collection>/:id/revisions).
+func ListRevisions(collection string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+		cursor, err := db.GetCollection(revisionsCollection).Find(ctx, bson.M{"collection": collection, "rule_id": objectID}, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		records := []models.DbRuleRevision{}
+		if err := cursor.All(ctx, &records); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, records)
+	}
+}
+
+// GetRevision returns one revision (GET /api/<collection>/:id/revisions/:version).
+func GetRevision(collection string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+			return
+		}
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		rev, err := findRevision(ctx, collection, objectID, version)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Revision not found"})
+			return
+		}
+		c.JSON(http.StatusOK, rev)
+	}
+}
+
+// DiffRevisions compares two revisions field by field
+// (GET /api/<collection>/:id/diff?from=N&to=M). to defaults to the latest.
+func DiffRevisions(collection string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		from, err := strconv.Atoi(c.Query("from"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a revision number"})
+			return
+		}
+		to := 0
+		if s := c.Query("to"); s != "" {
+			if to, err = strconv.Atoi(s); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a revision number"})
+				return
+			}
+		} else if to, err = latestRevision(ctx, collection, objectID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		older, err := findRevision(ctx, collection, objectID, from)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Revision " + strconv.Itoa(from) + " not found"})
+			return
+		}
+		newer, err := findRevision(ctx, collection, objectID, to)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Revision " + strconv.Itoa(to) + " not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"from":    older.Version,
+			"to":      newer.Version,
+			"changes": diffDocuments(older.Document, newer.Document),
+		})
+	}
+}
+
+// diffDocuments lists the top-level fields that differ, sorted by name.
+func diffDocuments(from, to bson.M) []models.RevisionChange {
+	fields := map[string]bool{}
+	for k := range from {
+		fields[k] = true
+	}
+	for k := range to {
+		fields[k] = true
+	}
+	delete(fields, "_id")
+
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	changes := []models.RevisionChange{}
+	for _, k := range names {
+		if !reflect.DeepEqual(from[k], to[k]) {
+			changes = append(changes, models.RevisionChange{Field: k, From: from[k], To: to[k]})
+		}
+	}
+	return changes
+}
+
+// RollbackRevision restores a rule to an earlier revision and records the
+// rollback as a new revision (POST /api/<collection>/:id/rollback/:version).
+func RollbackRevision(collection string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+			return
+		}
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		rev, err := findRevision(ctx, collection, objectID, version)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Revision not found"})
+			return
+		}
+
+		doc := bson.M{}
+		for k, v := range rev.Document {
+			doc[k] = v
+		}
+		doc["_id"] = objectID
+		// Upsert so that a deleted rule can be brought back
+		opts := options.Replace().SetUpsert(true)
+		if _, err := db.GetCollection(collection).ReplaceOne(ctx, bson.M{"_id": objectID}, doc, opts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := saveRevision(ctx, collection, objectID, "rollback", revisionAuthor(c), version); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"restored": version})
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiffDocuments(t *testing.T) {
+	id := primitive.NewObjectID()
+	tests := []struct {
+		name     string
+		from, to bson.M
+		want     []models.RevisionChange
+	}{
+		{
+			name: "identical",
+			from: bson.M{"_id": id, "rulename": "cpu", "order": int32(1)},
+			to:   bson.M{"_id": id, "rulename": "cpu", "order": int32(1)},
+			want: []models.RevisionChange{},
+		},
+		{
+			name: "changed fields sorted by name",
+			from: bson.M{"rulename": "cpu", "order": int32(1), "ruleobject": "a"},
+			to:   bson.M{"rulename": "cpu high", "order": int32(2), "ruleobject": "a"},
+			want: []models.RevisionChange{
+				{Field: "order", From: int32(1), To: int32(2)},
+				{Field: "rulename", From: "cpu", To: "cpu high"},
+			},
+		},
+		{
+			name: "added and removed fields",
+			from: bson.M{"calendar": bson.M{"name": "office"}},
+			to:   bson.M{"enabled": false},
+			want: []models.RevisionChange{
+				{Field: "calendar", From: bson.M{"name": "office"}},
+				{Field: "enabled", To: false},
+			},
+		},
+		{
+			name: "nested values compared deeply",
+			from: bson.M{"actions": bson.A{bson.M{"setfield": "severity", "setvalue": "WARN"}}},
+			to:   bson.M{"actions": bson.A{bson.M{"setfield": "severity", "setvalue": "ERROR"}}},
+			want: []models.RevisionChange{{
+				Field: "actions",
+				From:  bson.A{bson.M{"setfield": "severity", "setvalue": "WARN"}},
+				To:    bson.A{bson.M{"setfield": "severity", "setvalue": "ERROR"}},
+			}},
+		},
+		{
+			name: "ids are ignored",
+			from: bson.M{"_id": id},
+			to:   bson.M{"_id": primitive.NewObjectID()},
+			want: []models.RevisionChange{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffDocuments(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffDocuments =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
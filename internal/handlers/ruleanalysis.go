@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rules"
+)
+
+// AnalyzeRules reports conflicts between the enabled rules
+// (GET /api/rules/analysis[?type=alert|tag|notify|heal|correlation]).
+func AnalyzeRules(c *gin.Context) {
+	ruleType := c.Query("type")
+	switch ruleType {
+	case "", "alert", "tag", "notify", "heal", "correlation":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown rule type " + ruleType})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	set, err := loadRuleSet(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	findings := []rules.Finding{}
+	counts := map[string]int{}
+	for _, f := range rules.Analyze(set.alert, set.tag, set.notify, set.heal, set.correlation) {
+		if ruleType != "" && f.Rule.Type != ruleType {
+			continue
+		}
+		findings = append(findings, f)
+		counts[f.Kind]++
+	}
+	c.JSON(http.StatusOK, gin.H{"findings": findings, "counts": counts})
+}
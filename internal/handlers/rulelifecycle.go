@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rules"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// enabledRules selects the rules evaluators should use; rules saved before
+// the enabled flag existed have none and count as enabled.
+var enabledRules = bson.M{"enabled": bson.M{"$ne": false}}
+
+// optionalRuleFields are the rule fields stored with omitempty that a save
+// may clear, by collection. A $set of the rule leaves them out when they are
+// empty, which would keep the stored value.
+var optionalRuleFields = map[string][]string{
+	"alertrules":       {"calendar", "actions"},
+	"tagrules":         {"calendar", "extractions"},
+	"healrules":        {"calendar"},
+	"notifyrules":      {"calendar"},
+	"correlationrules": {"calendar"},
+}
+
+// ruleUpdate builds the update that saves rule over the stored one in
+// collection: $set of its fields and $unset of the optional fields it
+// leaves empty.
+func ruleUpdate(collection string, rule interface{}) (bson.M, error) {
+	update := bson.M{"$set": rule}
+	optional := optionalRuleFields[collection]
+	if len(optional) == 0 {
+		return update, nil
+	}
+	raw, err := bson.Marshal(rule)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	unset := bson.M{}
+	for _, field := range optional {
+		if _, ok := doc[field]; !ok {
+			unset[field] = ""
+		}
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update, nil
+}
+
+// rejectInvalidRule answers 400 with the per-field validation errors when
+// rule (a pointer to any rule model) is invalid, and reports whether it did.
+// Tag rules are also checked against the stored lookup tables.
+func rejectInvalidRule(c *gin.Context, rule interface{}) bool {
+	errs := rules.Validate(rule)
+	if tagRule, ok := rule.(*models.DbTagRule); ok {
+		errs = checkLookupTables(c.Request.Context(), *tagRule, errs)
+	}
+	if len(errs) == 0 {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "fields": errs})
+	return true
+}
+
+// checkLookupTables adds an error for every lookup of r naming a table that
+// is not in the lookuptables collection, which would otherwise disable the
+// whole rule at ingest. A failed lookup is logged and the names are accepted.
+func checkLookupTables(ctx context.Context, r models.DbTagRule, errs rules.FieldErrors) rules.FieldErrors {
+	names := lookupTableNames(r)
+	if len(names) == 0 {
+		return errs
+	}
+	known, err := storedLookupTables(ctx, names)
+	if err != nil {
+		log.Printf("Checking lookup tables of tag rule %q failed: %v", r.RuleName, err)
+		return errs
+	}
+	return unknownLookupTables(r, known, errs)
+}
+
+// lookupTableNames lists the tables the lookups of r name.
+func lookupTableNames(r models.DbTagRule) []string {
+	names := []string{}
+	for _, x := range r.Extractions {
+		for _, l := range x.Lookups {
+			if l.Table != "" {
+				names = append(names, l.Table)
+			}
+		}
+	}
+	return names
+}
+
+// storedLookupTables returns which of names are in the lookuptables
+// collection; nil names returns every stored table.
+func storedLookupTables(ctx context.Context, names []string) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	filter := bson.M{}
+	if names != nil {
+		filter["name"] = bson.M{"$in": names}
+	}
+	opts := options.Find().SetProjection(bson.M{"name": 1})
+	cursor, err := db.GetCollection("lookuptables").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var tables []models.DbLookupTable
+	if err := cursor.All(ctx, &tables); err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, t := range tables {
+		known[t.Name] = true
+	}
+	return known, nil
+}
+
+// unknownLookupTables adds an error for every lookup of r naming a table
+// that is not in known.
+func unknownLookupTables(r models.DbTagRule, known map[string]bool, errs rules.FieldErrors) rules.FieldErrors {
+	for i, x := range r.Extractions {
+		for j, l := range x.Lookups {
+			if l.Table == "" || known[l.Table] {
+				continue
+			}
+			if errs == nil {
+				errs = rules.FieldErrors{}
+			}
+			errs[fmt.Sprintf("extractions[%d].lookups[%d].table", i, j)] = fmt.Sprintf("unknown lookup table %q", l.Table)
+		}
+	}
+	return errs
+}
+
+// The handlers below are shared by every collection in RuleCollections and
+// registered per collection in cmd/main.go.
+
+// DeleteRule removes a rule, keeping its last state as a "delete" revision so
+// it can be restored with a rollback (DELETE /api/<collection>/:id).
+func DeleteRule(collection string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		col := db.GetCollection(collection)
+		var doc bson.M
+		if err := col.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+			return
+		}
+		ensureRevisionIndex(ctx)
+		if err := insertRevision(ctx, collection, objectID, doc, "delete", revisionAuthor(c), 0); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		result, err := col.DeleteOne(ctx, bson.M{"_id": objectID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		invalidateEnrichment()
+		c.JSON(http.StatusOK, gin.H{"deleted": result.DeletedCount})
+	}
+}
+
+// SetRuleEnabled enables or disables a rule
+// (PUT /api/<collection>/:id/enabled with {"enabled": false}).
+func SetRuleEnabled(collection string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+			return
+		}
+		var body struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || body.Enabled == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "enabled (true or false) is required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		ensureBaseline(ctx, collection, objectID)
+		result, err := db.GetCollection(collection).UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"enabled": *body.Enabled}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+			return
+		}
+		if result.ModifiedCount > 0 {
+			action := "disable"
+			if *body.Enabled {
+				action = "enable"
+			}
+			recordRevision(ctx, collection, objectID, action, revisionAuthor(c), 0)
+		}
+		c.JSON(http.StatusOK, gin.H{"modified": result.ModifiedCount})
+	}
+}
+
+// ReorderRules rewrites the order of every rule in a collection in one
+// transaction (PUT /api/<collection>/order with {"ids": [...]}). ids must list
+// every rule exactly once; the first gets order 1.
+func ReorderRules(collection string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ids := make([]primitive.ObjectID, 0, len(body.IDs))
+		for _, hex := range body.IDs {
+			id, err := primitive.ObjectIDFromHex(hex)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format", "id": hex})
+				return
+			}
+			ids = append(ids, id)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		ensureRevisionIndex(ctx)
+		session, err := db.DB.Client().StartSession()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer session.EndSession(ctx)
+
+		author := revisionAuthor(c)
+		modified, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			return reorderRules(sc, collection, ids, author)
+		})
+		if err != nil {
+			if _, ok := err.(reorderError); ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"modified": modified})
+	}
+}
+
+// reorderError rejects a reorder request that does not match the stored rules.
+type reorderError string
+
+func (e reorderError) Error() string { return string(e) }
+
+// checkReorder requires ids to list every rule of current (the stored order
+// by rule id) exactly once.
+func checkReorder(ids []primitive.ObjectID, current map[primitive.ObjectID]int) error {
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return reorderError("Duplicate id " + id.Hex())
+		}
+		seen[id] = true
+	}
+	if len(ids) != len(current) {
+		return reorderError(fmt.Sprintf("ids must list all %d rules, got %d", len(current), len(ids)))
+	}
+	for _, id := range ids {
+		if _, ok := current[id]; !ok {
+			return reorderError("Unknown rule " + id.Hex())
+		}
+	}
+	return nil
+}
+
+func reorderRules(ctx context.Context, collection string, ids []primitive.ObjectID, author string) (int, error) {
+	col := db.GetCollection(collection)
+	cursor, err := col.Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	var stored []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Order int                `bson:"order"`
+	}
+	if err := cursor.All(ctx, &stored); err != nil {
+		return 0, err
+	}
+
+	current := make(map[primitive.ObjectID]int, len(stored))
+	for _, r := range stored {
+		current[r.ID] = r.Order
+	}
+	if err := checkReorder(ids, current); err != nil {
+		return 0, err
+	}
+
+	modified := 0
+	for i, id := range ids {
+		order := i + 1
+		if current[id] == order {
+			continue
+		}
+		if err := saveBaseline(ctx, collection, id); err != nil {
+			return 0, err
+		}
+		if _, err := col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"order": order}}); err != nil {
+			return 0, err
+		}
+		if err := saveRevision(ctx, collection, id, "reorder", author, 0); err != nil {
+			return 0, err
+		}
+		modified++
+	}
+	return modified, nil
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRuleUpdate(t *testing.T) {
+	calendar := &models.CalendarCondition{Name: "office", Mode: "active"}
+	actions := []models.AlertAction{{Type: "set_field", Field: "severity", Value: "WARN"}}
+	extractions := []models.TagExtraction{{FieldName: "entity", TagName: "host"}}
+	tests := []struct {
+		name       string
+		collection string
+		rule       interface{}
+		wantUnset  bson.M
+	}{
+		{"alert rule with everything", "alertrules", models.DbAlertRule{Calendar: calendar, Actions: actions}, nil},
+		{"alert rule without calendar", "alertrules", models.DbAlertRule{Actions: actions}, bson.M{"calendar": ""}},
+		{"alert rule without calendar or actions", "alertrules", models.DbAlertRule{SetField: "severity"}, bson.M{"calendar": "", "actions": ""}},
+		{"tag rule without extractions", "tagrules", models.DbTagRule{Calendar: calendar}, bson.M{"extractions": ""}},
+		{"tag rule with extractions", "tagrules", models.DbTagRule{Calendar: calendar, Extractions: extractions}, nil},
+		{"heal rule without calendar", "healrules", models.DbHealRule{}, bson.M{"calendar": ""}},
+		{"collection without optional fields", "lookuptables", models.DbLookupTable{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update, err := ruleUpdate(tt.collection, tt.rule)
+			if err != nil {
+				t.Fatalf("ruleUpdate error: %v", err)
+			}
+			want := bson.M{"$set": tt.rule}
+			if tt.wantUnset != nil {
+				want["$unset"] = tt.wantUnset
+			}
+			if !reflect.DeepEqual(update, want) {
+				t.Errorf("ruleUpdate =\n%v\nwant\n%v", update, want)
+			}
+		})
+	}
+}
+
+func TestCheckReorder(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	current := map[primitive.ObjectID]int{a: 1, b: 2}
+	tests := []struct {
+		name    string
+		ids     []primitive.ObjectID
+		wantErr string
+	}{
+		{"same order", []primitive.ObjectID{a, b}, ""},
+		{"swapped", []primitive.ObjectID{b, a}, ""},
+		{"missing rule", []primitive.ObjectID{b}, "ids must list all 2 rules, got 1"},
+		{"extra rule", []primitive.ObjectID{a, b, c}, "ids must list all 2 rules, got 3"},
+		{"unknown rule", []primitive.ObjectID{a, c}, "Unknown rule " + c.Hex()},
+		{"duplicate", []primitive.ObjectID{a, a}, "Duplicate id " + a.Hex()},
+		{"empty", nil, "ids must list all 2 rules, got 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReorder(tt.ids, current)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkReorder error: %v", err)
+				}
+				return
+			}
+			if _, ok := err.(reorderError); !ok || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkReorder = %v, want reorderError %q", err, tt.wantErr)
+			}
+		})
+	}
+}
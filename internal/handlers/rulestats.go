@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/bundle"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rulestats"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// loadRuleStats fetches the hit statistics of a collection for an index
+// response; failures are logged and the rules are served without them.
+func loadRuleStats(ctx context.Context, collection string) map[primitive.ObjectID]*models.DbRuleStats {
+	stats, err := rulestats.Load(ctx, collection)
+	if err != nil {
+		log.Printf("Loading %s stats failed: %v", collection, err)
+		return nil
+	}
+	return stats
+}
+
+// attachRuleStats adds each rule's statistics under "stats" (null when the
+// rule never matched).
+func attachRuleStats(ctx context.Context, collection string, records []bson.M) {
+	stats := loadRuleStats(ctx, collection)
+	for _, r := range records {
+		id, _ := r["_id"].(primitive.ObjectID)
+		r["stats"] = stats[id]
+	}
+}
+
+// hitSources says, for each rule collection, where its hits are recorded,
+// so that the stale report shows what a missing hit means.
+var hitSources = map[string]string{
+	"alertrules":       "enrichment at ingest",
+	"tagrules":         "enrichment at ingest",
+	"correlationrules": "grouping at ingest",
+	"notifyrules":      "notifications sent through POST /api/alerts/:id/notify/:notificationid",
+	"healrules":        "hits reported by the heal executor through POST /api/healrules/:id/hits",
+}
+
+// StaleRules reports rules that have not matched in the last N days
+// (GET /api/rules/stale?days=30[&collection=alertrules]).
+func StaleRules(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive number"})
+		return
+	}
+	collections := RuleCollections
+	if name := c.Query("collection"); name != "" {
+		if !isRuleCollection(name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown rule collection " + name})
+			return
+		}
+		collections = []string{name}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stale := []rulestats.Stale{}
+	sources := map[string]string{}
+	for _, name := range collections {
+		section, _ := bundle.SectionByName(name)
+		records, err := rulestats.StaleRules(ctx, name, section.Key, days)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		stale = append(stale, records...)
+		sources[name] = hitSources[name]
+	}
+	c.JSON(http.StatusOK, gin.H{"days": days, "rules": stale, "hit_sources": sources})
+}
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/rules"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/topology"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// simulateRequest is the body of POST /api/rules/simulate. Exactly one of
+// Alert and AlertID selects the alert; Drafts are unsaved rules evaluated in
+// place of (when ID names a saved rule) or in addition to the saved ones.
+// At is the time calendar conditions are evaluated at, now by default.
+type simulateRequest struct {
+	Alert   *models.DbAlert `json:"alert"`
+	AlertID string          `json:"alert_id"`
+	Drafts  []simulateDraft `json:"drafts"`
+	At      *time.Time      `json:"at"`
+}
+
+type simulateDraft struct {
+	Type string          `json:"type"` // alert | tag | correlation | notify | heal
+	ID   string          `json:"id"`
+	Rule json.RawMessage `json:"rule"`
+}
+
+// simulationStep is one rule in the trace, in evaluation order.
+type simulationStep struct {
+	Stage    string         `json:"stage"`
+	RuleID   string         `json:"rule_id"`
+	RuleName string         `json:"rule_name"`
+	Order    int            `json:"order"`
+	Draft    bool           `json:"draft,omitempty"`
+	Matched  bool           `json:"matched"`
+	Changes  []rules.Change `json:"changes,omitempty"`
+	Action   gin.H          `json:"action,omitempty"`
+	Calendar *rules.Window  `json:"calendar,omitempty"`
+	Stopped  bool           `json:"stopped,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// ruleSet is every saved rule with the drafts merged in.
+type ruleSet struct {
+	alert       []models.DbAlertRule
+	tag         []models.DbTagRule
+	correlation []models.DbCorrelationRule
+	notify      []models.DbNotifyRule
+	heal        []models.DbHealRule
+	lookups     []models.DbLookupTable
+	calendars   rules.Calendars
+	drafts      map[primitive.ObjectID]bool
+}
+
+// SimulateRules runs an alert through every rule type without persisting
+// anything and returns the trace: alert and tag rules with the fields they
+// would change, the correlation group it would join, and the notify and heal
+// rules that would fire.
+func SimulateRules(c *gin.Context) {
+	var req simulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var alert models.DbAlert
+	switch {
+	case req.Alert != nil && req.AlertID != "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Send either alert or alert_id, not both"})
+		return
+	case req.Alert != nil:
+		alert = *req.Alert
+	case req.AlertID != "":
+		objectID, err := primitive.ObjectIDFromHex(req.AlertID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+			return
+		}
+		if err := db.GetCollection("alerts").FindOne(ctx, bson.M{"_id": objectID}).Decode(&alert); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Item not found"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "alert or alert_id is required"})
+		return
+	}
+
+	set, err := loadRuleSet(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i, d := range req.Drafts {
+		if err := set.addDraft(d); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("drafts[%d]: %v", i, err)})
+			return
+		}
+	}
+
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+
+	input := alert
+	prepareAlert(&alert)
+	steps := simulate(ctx, set, &alert, at)
+
+	matched := 0
+	for _, s := range steps {
+		if s.Matched {
+			matched++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"input":   input,
+		"result":  alert,
+		"at":      at,
+		"matched": matched,
+		"steps":   steps,
+	})
+}
+
+func simulate(ctx context.Context, set *ruleSet, alert *models.DbAlert, at time.Time) []simulationStep {
+	steps := []simulationStep{}
+
+	if stamped := topology.NewResolver().Stamp(ctx, alert); len(stamped) > 0 {
+		step := simulationStep{Stage: "topology", RuleName: alert.Entity, Matched: true}
+		keys := make([]string, 0, len(stamped))
+		for k := range stamped {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			step.Changes = append(step.Changes, rules.Change{Field: "additionaldetails." + k, New: stamped[k]})
+		}
+		steps = append(steps, step)
+	}
+
+	enricher, _ := rules.NewEnricher(set.alert, set.tag, set.lookups, set.calendars)
+	for _, res := range enricher.ApplyAt(alert, at) {
+		steps = append(steps, simulationStep{
+			Stage:    res.RuleType,
+			RuleID:   res.RuleID.Hex(),
+			RuleName: res.RuleName,
+			Order:    res.Order,
+			Draft:    set.drafts[res.RuleID],
+			Matched:  res.Matched,
+			Changes:  res.Changes,
+			Calendar: res.Calendar,
+			Stopped:  res.Stopped,
+			Error:    res.Error,
+		})
+	}
+
+	// Only the first matching correlation rule groups the alert
+	grouped := false
+	alertsCol := db.GetCollection("alerts")
+	for _, rule := range set.correlation {
+		if !models.RuleEnabled(rule.Enabled) {
+			continue
+		}
+		step := simulationStep{Stage: "correlation", RuleID: rule.ID.Hex(), RuleName: rule.GroupName, Draft: set.drafts[rule.ID]}
+		if !grouped && !alert.Dropped() && set.inWindow(&step, rule.Calendar, at) {
+			if match, reason, score := findCorrelationMatch(ctx, *alert, rule, alertsCol); match != nil {
+				grouped = true
+				step.Matched = true
+				step.Action = gin.H{"group_with": match.ID.Hex(), "group_with_alertid": match.AlertId, "parent": match.Parent, "score": score, "grouping_reason": reason}
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	for _, rule := range set.notify {
+		if !models.RuleEnabled(rule.Enabled) {
+			continue
+		}
+		step := simulationStep{Stage: "notify", RuleID: rule.ID.Hex(), RuleName: rule.RuleName, Order: rule.Order, Draft: set.drafts[rule.ID]}
+		if set.inWindow(&step, rule.Calendar, at) {
+			step.Matched, step.Error = ruleMatches(rule.RuleObject, alert)
+		}
+		if step.Matched {
+			payload, err := rules.RenderTemplate(rule.PayLoad, alert)
+			if err != nil {
+				step.Error = "payload: " + err.Error()
+			}
+			step.Action = gin.H{"endpoint": rule.EndPoint, "destination": rule.RuleName, "payload": payload}
+			if rule.PagerDutyService != "" {
+				step.Action["pagerduty_service"] = rule.PagerDutyService
+				step.Action["pagerduty_escalation_policy"] = rule.PagerDutyEscalationPolicy
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	for _, rule := range set.heal {
+		if !models.RuleEnabled(rule.Enabled) {
+			continue
+		}
+		step := simulationStep{Stage: "heal", RuleID: rule.ID.Hex(), RuleName: rule.RuleName, Order: rule.Order, Draft: set.drafts[rule.ID]}
+		if set.inWindow(&step, rule.Calendar, at) {
+			step.Matched, step.Error = ruleMatches(rule.RuleObject, alert)
+		}
+		if step.Matched {
+			payload, err := rules.RenderTemplate(rule.Payload, alert)
+			if err != nil {
+				step.Error = "payload: " + err.Error()
+			}
+			step.Action = gin.H{"payload": payload, "setvalue": rule.SetValue}
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// inWindow evaluates a rule's calendar condition at at, recording the window
+// on the step, and reports whether the rule applies.
+func (s *ruleSet) inWindow(step *simulationStep, cond *models.CalendarCondition, at time.Time) bool {
+	window, err := s.calendars.Check(cond, at)
+	if err != nil {
+		step.Error = err.Error()
+		return false
+	}
+	step.Calendar = window
+	return window == nil || window.Active
+}
+
+func ruleMatches(ruleObject string, alert *models.DbAlert) (bool, string) {
+	pred, err := rules.Compile(ruleObject)
+	if err != nil {
+		return false, err.Error()
+	}
+	return pred.Match(alert), ""
+}
+
+func loadRuleSet(ctx context.Context) (*ruleSet, error) {
+	set := &ruleSet{drafts: map[primitive.ObjectID]bool{}}
+	var err error
+	if set.alert, err = loadAlertRules(ctx); err != nil {
+		return nil, err
+	}
+	if set.tag, err = loadTagRules(ctx); err != nil {
+		return nil, err
+	}
+	if set.correlation, err = loadCorrelationRules(ctx); err != nil {
+		return nil, err
+	}
+	if err = findSorted(ctx, "notifyrules", &set.notify); err != nil {
+		return nil, err
+	}
+	if err = findSorted(ctx, "healrules", &set.heal); err != nil {
+		return nil, err
+	}
+	if set.lookups, err = loadLookupTables(ctx); err != nil {
+		return nil, err
+	}
+	if set.calendars, err = loadCalendars(ctx); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// findSorted loads the enabled rules of a collection ordered by their order field.
+func findSorted(ctx context.Context, collection string, out interface{}) error {
+	cursor, err := db.GetCollection(collection).Find(ctx, enabledRules, options.Find().SetSort(bson.D{{Key: "order", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, out)
+}
+
+// addDraft decodes a draft rule and puts it in place of the saved rule with
+// the same ID, or appends it.
+func (s *ruleSet) addDraft(d simulateDraft) error {
+	id := primitive.NewObjectID()
+	if d.ID != "" {
+		var err error
+		if id, err = primitive.ObjectIDFromHex(d.ID); err != nil {
+			return fmt.Errorf("invalid id %q", d.ID)
+		}
+	}
+	s.drafts[id] = true
+
+	switch d.Type {
+	case "alert":
+		var r models.DbAlertRule
+		if err := json.Unmarshal(d.Rule, &r); err != nil {
+			return err
+		}
+		r.ID = id
+		s.alert = replaceOrAppend(s.alert, r, func(x models.DbAlertRule) bool { return x.ID == id })
+		sort.SliceStable(s.alert, func(i, j int) bool { return s.alert[i].Order < s.alert[j].Order })
+	case "tag":
+		var r models.DbTagRule
+		if err := json.Unmarshal(d.Rule, &r); err != nil {
+			return err
+		}
+		r.ID = id
+		s.tag = replaceOrAppend(s.tag, r, func(x models.DbTagRule) bool { return x.ID == id })
+		sort.SliceStable(s.tag, func(i, j int) bool { return s.tag[i].Order < s.tag[j].Order })
+	case "correlation":
+		var r models.DbCorrelationRule
+		if err := json.Unmarshal(d.Rule, &r); err != nil {
+			return err
+		}
+		r.ID = id
+		s.correlation = replaceOrAppend(s.correlation, r, func(x models.DbCorrelationRule) bool { return x.ID == id })
+		sort.SliceStable(s.correlation, func(i, j int) bool { return s.correlation[i].Order < s.correlation[j].Order })
+	case "notify":
+		var r models.DbNotifyRule
+		if err := json.Unmarshal(d.Rule, &r); err != nil {
+			return err
+		}
+		r.ID = id
+		s.notify = replaceOrAppend(s.notify, r, func(x models.DbNotifyRule) bool { return x.ID == id })
+		sort.SliceStable(s.notify, func(i, j int) bool { return s.notify[i].Order < s.notify[j].Order })
+	case "heal":
+		var r models.DbHealRule
+		if err := json.Unmarshal(d.Rule, &r); err != nil {
+			return err
+		}
+		r.ID = id
+		s.heal = replaceOrAppend(s.heal, r, func(x models.DbHealRule) bool { return x.ID == id })
+		sort.SliceStable(s.heal, func(i, j int) bool { return s.heal[i].Order < s.heal[j].Order })
+	default:
+		return fmt.Errorf("unknown rule type %q", d.Type)
+	}
+	return nil
+}
+
+func replaceOrAppend[T any](list []T, item T, same func(T) bool) []T {
+	for i := range list {
+		if same(list[i]) {
+			list[i] = item
+			return list
+		}
+	}
+	return append(list, item)
+}
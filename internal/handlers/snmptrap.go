@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/snmptrap"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// snmpTrapConfigTTL is how long the trap receiver reuses the stored
+// configuration. Saving it through this server drops the copy at once.
+const snmpTrapConfigTTL = 10 * time.Second
+
+var (
+	snmpTrapConfigMu      sync.Mutex
+	snmpTrapConfigCache   *models.DbSNMPTrapConfig
+	snmpTrapConfigExpires time.Time
+)
+
+// LoadSNMPTrapConfig returns the trap receiver configuration for an incoming
+// trap, read from Mongo at most once per snmpTrapConfigTTL.
+func LoadSNMPTrapConfig(ctx context.Context) (models.DbSNMPTrapConfig, error) {
+	snmpTrapConfigMu.Lock()
+	defer snmpTrapConfigMu.Unlock()
+	if snmpTrapConfigCache != nil && time.Now().Before(snmpTrapConfigExpires) {
+		return *snmpTrapConfigCache, nil
+	}
+	cfg, err := readSNMPTrapConfig(ctx)
+	if err != nil {
+		return cfg, err
+	}
+	snmpTrapConfigCache, snmpTrapConfigExpires = &cfg, time.Now().Add(snmpTrapConfigTTL)
+	return cfg, nil
+}
+
+// readSNMPTrapConfig returns the stored trap receiver configuration, or an
+// empty one (built-in OIDs only) when none has been saved.
+func readSNMPTrapConfig(ctx context.Context) (models.DbSNMPTrapConfig, error) {
+	var cfg models.DbSNMPTrapConfig
+	err := db.GetCollection("snmptrap_config").FindOne(ctx, bson.M{}).Decode(&cfg)
+	if err == mongo.ErrNoDocuments {
+		return models.DbSNMPTrapConfig{}, nil
+	}
+	return cfg, err
+}
+
+// Handler function to get the SNMP trap OID table and clear rules
+func GetSNMPTrapConfig(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg, err := readSNMPTrapConfig(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if cfg.OIDNames == nil {
+		cfg.OIDNames = []models.SNMPOIDName{}
+	}
+	if cfg.ClearRules == nil {
+		cfg.ClearRules = []models.SNMPClearRule{}
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// Handler function to replace the SNMP trap OID table and clear rules
+func UpdateSNMPTrapConfig(c *gin.Context) {
+	var cfg models.DbSNMPTrapConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := snmptrap.Validate(cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"oid_names": cfg.OIDNames, "clear_rules": cfg.ClearRules}}
+	_, err := db.GetCollection("snmptrap_config").UpdateOne(ctx, bson.M{}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	snmpTrapConfigMu.Lock()
+	snmpTrapConfigCache = nil
+	snmpTrapConfigMu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"message": "SNMP trap configuration saved"})
+}
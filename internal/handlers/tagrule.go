@@ -24,6 +24,9 @@ func NewTag(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+    if rejectInvalidRule(c, &tagRule) {
+        return
+    }
 
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -32,6 +35,7 @@ func NewTag(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordRevision(ctx, "tagrules", result.InsertedID.(primitive.ObjectID), "create", revisionAuthor(c), 0)
 	c.JSON(http.StatusOK, gin.H{"result": result.InsertedID})
 }
 
@@ -61,6 +65,7 @@ func IndexTag(c *gin.Context) {
     if records == nil {
 		records = []bson.M{}
 	} 
+    attachRuleStats(ctx, "tagrules", records)
 
     c.JSON(http.StatusOK, records)
 }
@@ -105,6 +110,9 @@ func UpdateTag(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+    if rejectInvalidRule(c, &tagRule) {
+        return
+    }
 
     id := c.Param("id")
     // Convert string ID to BSON ObjectID
@@ -117,13 +125,19 @@ func UpdateTag(c *gin.Context) {
     collection := db.GetCollection("tagrules")
 	updatefilter := bson.M{"_id": objectID }
     // Prepare the update document using the $set operator
-	update := bson.M{"$set": tagRule}
+	update, err := ruleUpdate("tagrules", tagRule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
+    ensureBaseline(context.TODO(), "tagrules", objectID)
     updateResult , updateerr := collection.UpdateOne(context.TODO(), updatefilter, update)
     if updateerr != nil {
         panic(updateerr)
     }
     if updateResult.ModifiedCount > 0 {
+        recordRevision(context.TODO(), "tagrules", objectID, "update", revisionAuthor(c), 0)
         fmt.Printf("Matched %v documents and updated %v documents.\n", updateResult.MatchedCount, updateResult.ModifiedCount)
     }
 	c.JSON(http.StatusOK, gin.H{"modified": updateResult.ModifiedCount})
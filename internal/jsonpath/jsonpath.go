@@ -0,0 +1,236 @@
+// Package jsonpath evaluates a practical subset of JSONPath against decoded
+// JSON (the map[string]interface{} / []interface{} trees produced by
+// encoding/json). Supported syntax:
+//
+//	$                 the root document
+//	.name  ['name']   object member
+//	[2]  [-1]         array index (negative counts from the end)
+//	[*]  .*           every array element or object member
+//	..name            recursive descent
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	stepMember stepKind = iota
+	stepIndex
+	stepWildcard
+	stepDescend
+)
+
+type step struct {
+	kind  stepKind
+	name  string
+	index int
+}
+
+// Path is a compiled JSONPath expression.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// String returns the expression the path was compiled from.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Compile parses expr. A leading "$" is optional, so "alerts[0].labels" and
+// "$.alerts[0].labels" are equivalent.
+func Compile(expr string) (*Path, error) {
+	s := strings.TrimSpace(expr)
+	p := &Path{expr: expr}
+	if s == "" {
+		return nil, fmt.Errorf("jsonpath: empty expression")
+	}
+	if strings.HasPrefix(s, "$") {
+		s = s[1:]
+	} else if s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			s = s[2:]
+			name, rest := readName(s)
+			if name == "" {
+				return nil, fmt.Errorf("jsonpath: %q: expected member name after '..'", expr)
+			}
+			p.steps = append(p.steps, step{kind: stepDescend, name: name})
+			s = rest
+		case s[0] == '.':
+			s = s[1:]
+			if strings.HasPrefix(s, "*") {
+				p.steps = append(p.steps, step{kind: stepWildcard})
+				s = s[1:]
+				continue
+			}
+			name, rest := readName(s)
+			if name == "" {
+				return nil, fmt.Errorf("jsonpath: %q: expected member name after '.'", expr)
+			}
+			p.steps = append(p.steps, step{kind: stepMember, name: name})
+			s = rest
+		case s[0] == '[':
+			end := closingBracket(s)
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath: %q: unterminated '['", expr)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+			switch {
+			case inner == "*":
+				p.steps = append(p.steps, step{kind: stepWildcard})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				p.steps = append(p.steps, step{kind: stepMember, name: inner[1 : len(inner)-1]})
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("jsonpath: %q: invalid index %q", expr, inner)
+				}
+				p.steps = append(p.steps, step{kind: stepIndex, index: idx})
+			}
+		default:
+			return nil, fmt.Errorf("jsonpath: %q: unexpected %q", expr, s)
+		}
+	}
+	return p, nil
+}
+
+// MustCompile is like Compile but panics on an invalid expression.
+func MustCompile(expr string) *Path {
+	p, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Find returns every value the path selects in doc, in document order
+// (object members are visited in sorted key order).
+func (p *Path) Find(doc interface{}) []interface{} {
+	current := []interface{}{doc}
+	for _, st := range p.steps {
+		next := []interface{}{}
+		for _, node := range current {
+			next = apply(st, node, next)
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// First returns the first value the path selects, if any.
+func (p *Path) First(doc interface{}) (interface{}, bool) {
+	values := p.Find(doc)
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[0], true
+}
+
+// Get compiles expr and returns the first value it selects in doc.
+func Get(doc interface{}, expr string) (interface{}, bool, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := p.First(doc)
+	return v, ok, nil
+}
+
+func apply(st step, node interface{}, out []interface{}) []interface{} {
+	switch st.kind {
+	case stepMember:
+		if m, ok := node.(map[string]interface{}); ok {
+			if v, ok := m[st.name]; ok {
+				out = append(out, v)
+			}
+		}
+	case stepIndex:
+		if arr, ok := node.([]interface{}); ok {
+			i := st.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				out = append(out, arr[i])
+			}
+		}
+	case stepWildcard:
+		switch v := node.(type) {
+		case []interface{}:
+			out = append(out, v...)
+		case map[string]interface{}:
+			for _, k := range sortedKeys(v) {
+				out = append(out, v[k])
+			}
+		}
+	case stepDescend:
+		out = descend(st.name, node, out)
+	}
+	return out
+}
+
+func descend(name string, node interface{}, out []interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if child, ok := v[name]; ok {
+			out = append(out, child)
+		}
+		for _, k := range sortedKeys(v) {
+			out = descend(name, v[k], out)
+		}
+	case []interface{}:
+		for _, child := range v {
+			out = descend(name, child, out)
+		}
+	}
+	return out
+}
+
+func readName(s string) (string, string) {
+	i := 0
+	for i < len(s) && s[i] != '.' && s[i] != '[' {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// closingBracket finds the ']' matching the '[' at s[0], skipping quoted names
+func closingBracket(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '\'' || s[i] == '"':
+			quote = s[i]
+		case s[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
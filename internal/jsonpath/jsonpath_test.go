@@ -0,0 +1,119 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const sample = `{
+	"alerts": [
+		{"labels": {"host": "web-01", "app": "shop"}, "value": 3},
+		{"labels": {"host": "web-02"}, "value": 7}
+	],
+	"meta": {"b": 2, "a": 1, "labels": {"host": "meta-host"}},
+	"dotted.key": "dotted",
+	"with]bracket": "bracket",
+	"": "empty"
+}`
+
+func decode(t *testing.T) interface{} {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal([]byte(sample), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestFind(t *testing.T) {
+	doc := decode(t)
+	tests := []struct {
+		expr string
+		want []interface{}
+	}{
+		{"$", []interface{}{doc}},
+		{"$.alerts[0].labels.host", []interface{}{"web-01"}},
+		{"alerts[0].labels.host", []interface{}{"web-01"}},
+		{".alerts[1].value", []interface{}{7.0}},
+		{"$['alerts'][0]['labels'][\"app\"]", []interface{}{"shop"}},
+		{"$[ 'alerts' ][ 1 ].value", []interface{}{7.0}},
+		{"$.alerts[-1].labels.host", []interface{}{"web-02"}},
+		{"$.alerts[*].value", []interface{}{3.0, 7.0}},
+		{"$.alerts.*.labels.host", []interface{}{"web-01", "web-02"}},
+		{"$.meta.*", []interface{}{1.0, 2.0, map[string]interface{}{"host": "meta-host"}}},
+		{"$..host", []interface{}{"web-01", "web-02", "meta-host"}},
+		{"$['dotted.key']", []interface{}{"dotted"}},
+		{"$['with]bracket']", []interface{}{"bracket"}},
+		{"$['']", []interface{}{"empty"}},
+		{"$.alerts[2]", nil},
+		{"$.alerts[-3]", nil},
+		{"$.alerts[0].labels.host.deeper", nil},
+		{"$.meta[0]", nil},
+		{"$.alerts.value", nil},
+		{"$.missing[*]", nil},
+		{"$..nothing", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile(%q) error: %v", tt.expr, err)
+			}
+			if got := p.Find(doc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileRejects(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"   ",
+		"$.",
+		"$..",
+		"$..[0]",
+		"$.alerts[",
+		"$.alerts[0",
+		"$['unterminated]",
+		"$.alerts[x]",
+		"$.alerts[1.5]",
+		"$.alerts[]",
+		"$x",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			if p, err := Compile(expr); err == nil {
+				t.Errorf("Compile(%q) = %+v, want an error", expr, p.steps)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	doc := decode(t)
+	if v, ok, err := Get(doc, "$..host"); err != nil || !ok || v != "web-01" {
+		t.Errorf("Get($..host) = %v, %v, %v; want web-01, true, nil", v, ok, err)
+	}
+	if v, ok, err := Get(doc, "$.missing"); err != nil || ok || v != nil {
+		t.Errorf("Get($.missing) = %v, %v, %v; want nil, false, nil", v, ok, err)
+	}
+	if _, _, err := Get(doc, "$["); err == nil {
+		t.Error("Get($[) succeeded, want an error")
+	}
+	// Scalars and nil documents select nothing below the root
+	for _, scalar := range []interface{}{nil, "text", 1.0, true} {
+		if _, ok, _ := Get(scalar, "$.a"); ok {
+			t.Errorf("Get(%v, $.a) found a value", scalar)
+		}
+	}
+}
+
+func TestMustCompilePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile did not panic on an invalid expression")
+		}
+	}()
+	MustCompile("$[")
+}
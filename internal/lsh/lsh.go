@@ -0,0 +1,234 @@
+// Package lsh finds near-duplicate texts among many with MinHash and
+// locality-sensitive hashing: each text is reduced to a signature of
+// minimum hashes over its tokens, signatures are cut into bands, and texts
+// sharing a band are candidates. A query touches the few buckets of its
+// bands instead of every text.
+package lsh
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+)
+
+// Hashes is the length of every signature.
+const Hashes = 64
+
+// Signature holds the minimum of each hash function over a text's tokens.
+// The share of equal positions in two signatures estimates the Jaccard
+// similarity of their token sets.
+type Signature []uint32
+
+// Entry is one indexed text.
+type Entry struct {
+	ID   string
+	Time time.Time
+	Sig  Signature
+}
+
+// Match is a candidate found by Query, with its estimated similarity.
+type Match struct {
+	ID       string
+	Estimate float64
+}
+
+// Store keeps the entries and the buckets of their bands. Bands are
+// computed by the Index, so stores only keep what they are given.
+type Store interface {
+	Put(ctx context.Context, e Entry, bands []uint64) error
+	Get(ctx context.Context, id string) (Entry, bool, error)
+	Delete(ctx context.Context, id string, bands []uint64) error
+	// Lookup returns every entry in at least one of the buckets, once
+	Lookup(ctx context.Context, bands []uint64) ([]Entry, error)
+	Entries(ctx context.Context) ([]Entry, error)
+	Reset(ctx context.Context) error
+}
+
+// Index is a MinHash LSH index over a Store. Rows is the number of
+// signature positions per band; Hashes/Rows bands are used.
+type Index struct {
+	rows  int
+	store Store
+}
+
+var seeds = func() [Hashes]uint64 {
+	var s [Hashes]uint64
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range s {
+		x = mix(x + uint64(i) + 1)
+		s[i] = x
+	}
+	return s
+}()
+
+// RowsFor picks the rows per band for a similarity threshold: the most
+// selective banding whose S-curve midpoint, (1/bands)^(1/rows), stays
+// below 80% of the threshold, so matches near it are rarely missed.
+func RowsFor(threshold float64) int {
+	rows := 1
+	for _, r := range []int{2, 4, 8, 16} {
+		bands := float64(Hashes / r)
+		if math.Pow(1/bands, 1/float64(r)) <= 0.8*threshold {
+			rows = r
+		}
+	}
+	return rows
+}
+
+// New returns an index cutting signatures into bands of rows positions;
+// rows must divide Hashes.
+func New(rows int, store Store) *Index {
+	if rows < 1 || Hashes%rows != 0 {
+		rows = 1
+	}
+	return &Index{rows: rows, store: store}
+}
+
+// Rows returns the rows per band.
+func (ix *Index) Rows() int {
+	return ix.rows
+}
+
+// Reset empties the index.
+func (ix *Index) Reset(ctx context.Context) error {
+	return ix.store.Reset(ctx)
+}
+
+// Add indexes tokens under id, replacing what id had before. An empty
+// token set is not indexed, as it is similar to nothing.
+func (ix *Index) Add(ctx context.Context, id string, tokens []string, t time.Time) error {
+	if err := ix.Remove(ctx, id); err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	sig := Sign(tokens)
+	return ix.store.Put(ctx, Entry{ID: id, Time: t, Sig: sig}, ix.bands(sig))
+}
+
+// Remove drops id from the index; an unknown id is not an error.
+func (ix *Index) Remove(ctx context.Context, id string) error {
+	e, ok, err := ix.store.Get(ctx, id)
+	if err != nil || !ok {
+		return err
+	}
+	return ix.store.Delete(ctx, id, ix.bands(e.Sig))
+}
+
+// Retain removes every entry keep returns false for.
+func (ix *Index) Retain(ctx context.Context, keep func(Entry) bool) error {
+	entries, err := ix.store.Entries(ctx)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !keep(e) {
+			if err := ix.store.Delete(ctx, e.ID, ix.bands(e.Sig)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Query returns up to limit entries sharing a band with tokens, most
+// similar first, leaving out exclude and entries older than since.
+func (ix *Index) Query(ctx context.Context, tokens []string, since time.Time, exclude string, limit int) ([]Match, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	sig := Sign(tokens)
+	entries, err := ix.store.Lookup(ctx, ix.bands(sig))
+	if err != nil {
+		return nil, err
+	}
+	type ranked struct {
+		Match
+		time time.Time
+	}
+	found := make([]ranked, 0, len(entries))
+	for _, e := range entries {
+		if e.ID == exclude || e.Time.Before(since) {
+			continue
+		}
+		found = append(found, ranked{Match{e.ID, Estimate(sig, e.Sig)}, e.Time})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Estimate != found[j].Estimate {
+			return found[i].Estimate > found[j].Estimate
+		}
+		return found[i].time.After(found[j].time)
+	})
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	out := make([]Match, len(found))
+	for i, f := range found {
+		out[i] = f.Match
+	}
+	return out, nil
+}
+
+// Sign computes the MinHash signature of a token set.
+func Sign(tokens []string) Signature {
+	sig := make(Signature, Hashes)
+	for i := range sig {
+		sig[i] = math.MaxUint32
+	}
+	for _, t := range tokens {
+		h := fnv.New64a()
+		h.Write([]byte(t))
+		x := h.Sum64()
+		for i, seed := range seeds {
+			if v := uint32(mix(x^seed) >> 32); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// Estimate is the share of positions where a and b agree.
+func Estimate(a, b Signature) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// bands hashes each band of sig, with its position, into a bucket key.
+func (ix *Index) bands(sig Signature) []uint64 {
+	out := make([]uint64, 0, len(sig)/ix.rows)
+	var buf [4]byte
+	for b := 0; b+ix.rows <= len(sig); b += ix.rows {
+		h := fnv.New64a()
+		binary.BigEndian.PutUint32(buf[:], uint32(b))
+		h.Write(buf[:])
+		for _, v := range sig[b : b+ix.rows] {
+			binary.BigEndian.PutUint32(buf[:], v)
+			h.Write(buf[:])
+		}
+		out = append(out, h.Sum64())
+	}
+	return out
+}
+
+// mix is the splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,293 @@
+package lsh
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+const benchAlerts = 100000
+
+// benchCorpus returns benchAlerts synthetic alert texts: 2000 message
+// templates over a 5000 word vocabulary, each instance on its own host.
+func benchCorpus() [][]string {
+	r := rand.New(rand.NewSource(1))
+	templates := make([][]string, 2000)
+	for i := range templates {
+		words := make([]string, 6+r.Intn(6))
+		for j := range words {
+			words[j] = fmt.Sprintf("w%d", r.Intn(5000))
+		}
+		templates[i] = words
+	}
+	docs := make([][]string, benchAlerts)
+	for i := range docs {
+		t := templates[r.Intn(len(templates))]
+		docs[i] = append(append([]string(nil), t...), fmt.Sprintf("host-%d", i))
+	}
+	return docs
+}
+
+var (
+	benchOnce  sync.Once
+	benchDocs  [][]string
+	benchIndex *Index
+)
+
+func benchSetup(b *testing.B) {
+	benchOnce.Do(func() {
+		benchDocs = benchCorpus()
+		benchIndex = New(RowsFor(0.6), NewMemoryStore())
+		now := time.Now()
+		for i, d := range benchDocs {
+			benchIndex.Add(context.Background(), fmt.Sprint(i), d, now)
+		}
+	})
+	b.ResetTimer()
+}
+
+// query returns a copy of doc i as if raised on another host.
+func query(i int) []string {
+	q := append([]string(nil), benchDocs[i][:len(benchDocs[i])-1]...)
+	return append(q, "host-new")
+}
+
+// BenchmarkQuery measures candidate lookups among 100k open alerts, as
+// done for every alert a SIMILARITY rule sees.
+func BenchmarkQuery(b *testing.B) {
+	benchSetup(b)
+	ctx := context.Background()
+	since := time.Now().Add(-time.Hour)
+	found := 0
+	for n := 0; n < b.N; n++ {
+		i := n * 7919 % benchAlerts
+		matches, err := benchIndex.Query(ctx, query(i), since, "", 200)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(matches) > 0 && matches[0].Estimate >= 0.6 {
+			found++
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "queries/s")
+	b.ReportMetric(float64(found)/float64(b.N), "found")
+}
+
+// BenchmarkScan is the exhaustive Jaccard comparison the index replaces.
+func BenchmarkScan(b *testing.B) {
+	benchSetup(b)
+	sets := make([]map[string]bool, len(benchDocs))
+	for i, d := range benchDocs {
+		sets[i] = map[string]bool{}
+		for _, t := range d {
+			sets[i][t] = true
+		}
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		q := query(n * 7919 % benchAlerts)
+		best := 0.0
+		for _, s := range sets {
+			inter := 0
+			for _, t := range q {
+				if s[t] {
+					inter++
+				}
+			}
+			if j := float64(inter) / float64(len(q)+len(s)-inter); j > best {
+				best = j
+			}
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "queries/s")
+}
+
+// BenchmarkAdd measures indexing one more alert next to 100k open ones.
+func BenchmarkAdd(b *testing.B) {
+	benchSetup(b)
+	ctx := context.Background()
+	now := time.Now()
+	for n := 0; n < b.N; n++ {
+		id := fmt.Sprintf("bench-%d", n)
+		if err := benchIndex.Add(ctx, id, query(n%benchAlerts), now); err != nil {
+			b.Fatal(err)
+		}
+		benchIndex.Remove(ctx, id)
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "alerts/s")
+}
+
+func ids(matches []Match) []string {
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.ID
+	}
+	return out
+}
+
+func TestAddRemoveQuery(t *testing.T) {
+	ctx := context.Background()
+	ix := New(RowsFor(0.6), NewMemoryStore())
+	now := time.Now()
+	disk := []string{"disk", "full", "on", "volume", "data"}
+	if err := ix.Add(ctx, "a", disk, now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	ix.Add(ctx, "b", disk, now)
+	ix.Add(ctx, "c", []string{"link", "down", "on", "port", "eth0"}, now)
+	ix.Add(ctx, "empty", nil, now)
+
+	matches, err := ix.Query(ctx, disk, time.Time{}, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Equal estimates are ordered newest first
+	if got := ids(matches); fmt.Sprint(got) != "[b a]" || matches[0].Estimate != 1 {
+		t.Errorf("Query = %+v, want b then a with estimate 1", matches)
+	}
+	if got, _ := ix.Query(ctx, disk, now.Add(-time.Hour), "", 0); fmt.Sprint(ids(got)) != "[b]" {
+		t.Errorf("Query since an hour ago = %v, want [b]", ids(got))
+	}
+	if got, _ := ix.Query(ctx, disk, time.Time{}, "b", 0); fmt.Sprint(ids(got)) != "[a]" {
+		t.Errorf("Query excluding b = %v, want [a]", ids(got))
+	}
+	if got, _ := ix.Query(ctx, disk, time.Time{}, "", 1); len(got) != 1 {
+		t.Errorf("Query with limit 1 = %v", ids(got))
+	}
+	if got, _ := ix.Query(ctx, nil, time.Time{}, "", 0); len(got) != 0 {
+		t.Errorf("Query without tokens = %v", ids(got))
+	}
+
+	if err := ix.Remove(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Remove(ctx, "unknown"); err != nil {
+		t.Errorf("Remove of an unknown id: %v", err)
+	}
+	if got, _ := ix.Query(ctx, disk, time.Time{}, "", 0); fmt.Sprint(ids(got)) != "[a]" {
+		t.Errorf("Query after Remove(b) = %v, want [a]", ids(got))
+	}
+
+	// Adding an id again replaces its tokens
+	ix.Add(ctx, "a", []string{"link", "down", "on", "port", "eth0"}, now)
+	if got, _ := ix.Query(ctx, disk, time.Time{}, "", 0); len(got) != 0 {
+		t.Errorf("Query for the old tokens of a = %v, want nothing", ids(got))
+	}
+	if got, _ := ix.Query(ctx, []string{"link", "down", "on", "port", "eth0"}, time.Time{}, "", 0); len(got) != 2 {
+		t.Errorf("Query for the new tokens of a = %v, want [a c]", ids(got))
+	}
+}
+
+func TestRetain(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	ix := New(4, store)
+	now := time.Now()
+	tokens := []string{"cpu", "load", "high"}
+	for i := 0; i < 10; i++ {
+		ix.Add(ctx, fmt.Sprint(i), tokens, now.Add(-time.Duration(i)*time.Minute))
+	}
+
+	cutoff := now.Add(-5 * time.Minute)
+	if err := ix.Retain(ctx, func(e Entry) bool { return !e.Time.Before(cutoff) }); err != nil {
+		t.Fatal(err)
+	}
+	if store.Len() != 6 {
+		t.Errorf("%d entries left, want 6", store.Len())
+	}
+	matches, _ := ix.Query(ctx, tokens, time.Time{}, "", 0)
+	if got := fmt.Sprint(ids(matches)); got != "[0 1 2 3 4 5]" {
+		t.Errorf("Query after Retain = %s, want [0 1 2 3 4 5]", got)
+	}
+	for _, id := range []string{"6", "9"} {
+		if _, ok, _ := store.Get(ctx, id); ok {
+			t.Errorf("entry %s survived Retain", id)
+		}
+	}
+}
+
+func TestNewRejectsUnevenRows(t *testing.T) {
+	for _, rows := range []int{0, -1, 3, 128} {
+		if got := New(rows, NewMemoryStore()).Rows(); got != 1 {
+			t.Errorf("New(%d).Rows() = %d, want 1", rows, got)
+		}
+	}
+}
+
+// nearDuplicates returns two token sets of n tokens with Jaccard similarity
+// at least j, drawn from r.
+func nearDuplicates(r *rand.Rand, n int, j float64) ([]string, []string, float64) {
+	// shared/(2n-shared) >= j
+	shared := int(math.Ceil(2 * float64(n) * j / (1 + j)))
+	a := make([]string, n)
+	for i := range a {
+		a[i] = fmt.Sprintf("t%d", r.Int63())
+	}
+	b := append([]string(nil), a[:shared]...)
+	for len(b) < n {
+		b = append(b, fmt.Sprintf("u%d", r.Int63()))
+	}
+	return a, b, float64(shared) / float64(2*n-shared)
+}
+
+// TestRecall checks that texts at or above a threshold are found with the
+// banding RowsFor picks for it, and that unrelated texts are not.
+func TestRecall(t *testing.T) {
+	ctx := context.Background()
+	for _, threshold := range []float64{0.5, 0.6, 0.7, 0.8, 0.9} {
+		t.Run(fmt.Sprint(threshold), func(t *testing.T) {
+			r := rand.New(rand.NewSource(int64(threshold * 100)))
+			ix := New(RowsFor(threshold), NewMemoryStore())
+			const pairs = 200
+			queries := make([][]string, pairs)
+			for i := range queries {
+				a, b, j := nearDuplicates(r, 20+r.Intn(20), threshold)
+				if j < threshold {
+					t.Fatalf("pair similarity %.2f below %.2f", j, threshold)
+				}
+				ix.Add(ctx, fmt.Sprint(i), a, time.Time{})
+				queries[i] = b
+			}
+
+			found := 0
+			for i, q := range queries {
+				matches, err := ix.Query(ctx, q, time.Time{}, "", 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, m := range matches {
+					if m.ID == fmt.Sprint(i) {
+						found++
+						break
+					}
+				}
+			}
+			if recall := float64(found) / pairs; recall < 0.98 {
+				t.Errorf("recall %.3f with %d rows per band, want at least 0.98", recall, ix.Rows())
+			}
+
+			// Fresh tokens share nothing with the indexed texts
+			unrelated, _, _ := nearDuplicates(r, 30, 0)
+			if matches, _ := ix.Query(ctx, unrelated, time.Time{}, "", 0); len(matches) > 0 {
+				t.Errorf("unrelated text matched %v", ids(matches))
+			}
+		})
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for _, j := range []float64{0.3, 0.5, 0.8} {
+		a, b, exact := nearDuplicates(r, 200, j)
+		if est := Estimate(Sign(a), Sign(b)); math.Abs(est-exact) > 0.2 {
+			t.Errorf("Estimate = %.2f for Jaccard %.2f", est, exact)
+		}
+	}
+	if Estimate(Sign([]string{"x"}), Signature{1, 2}) != 0 || Estimate(nil, nil) != 0 {
+		t.Error("Estimate of mismatched or empty signatures is not 0")
+	}
+}
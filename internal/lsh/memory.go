@@ -0,0 +1,122 @@
+package lsh
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps the index in process memory. Buckets hold slot
+// numbers rather than IDs to keep them small.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	slots   map[string]int32
+	entries []Entry // by slot; ID is "" for a free slot
+	free    []int32
+	buckets map[uint64][]int32
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{slots: map[string]int32{}, buckets: map[uint64][]int32{}}
+}
+
+func (s *MemoryStore) Put(_ context.Context, e Entry, bands []uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slot, ok := s.slots[e.ID]
+	if !ok {
+		if n := len(s.free); n > 0 {
+			slot, s.free = s.free[n-1], s.free[:n-1]
+		} else {
+			slot = int32(len(s.entries))
+			s.entries = append(s.entries, Entry{})
+		}
+		s.slots[e.ID] = slot
+	}
+	s.entries[slot] = e
+	for _, b := range bands {
+		s.buckets[b] = append(s.buckets[b], slot)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (Entry, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	slot, ok := s.slots[id]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	return s.entries[slot], true, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, id string, bands []uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slot, ok := s.slots[id]
+	if !ok {
+		return nil
+	}
+	for _, b := range bands {
+		bucket := s.buckets[b]
+		for i, x := range bucket {
+			if x == slot {
+				bucket[i] = bucket[len(bucket)-1]
+				bucket = bucket[:len(bucket)-1]
+				break
+			}
+		}
+		if len(bucket) == 0 {
+			delete(s.buckets, b)
+		} else {
+			s.buckets[b] = bucket
+		}
+	}
+	delete(s.slots, id)
+	s.entries[slot] = Entry{}
+	s.free = append(s.free, slot)
+	return nil
+}
+
+func (s *MemoryStore) Lookup(_ context.Context, bands []uint64) ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := map[int32]bool{}
+	var out []Entry
+	for _, b := range bands {
+		for _, slot := range s.buckets[b] {
+			if !seen[slot] {
+				seen[slot] = true
+				out = append(out, s.entries[slot])
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) Entries(_ context.Context) ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Entry, 0, len(s.slots))
+	for _, slot := range s.slots {
+		out = append(out, s.entries[slot])
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slots = map[string]int32{}
+	s.entries = nil
+	s.free = nil
+	s.buckets = map[uint64][]int32{}
+	return nil
+}
+
+// Len returns the number of entries.
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.slots)
+}
@@ -0,0 +1,53 @@
+package lsh
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryStoreReusesSlots(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	s.Put(ctx, Entry{ID: "a"}, []uint64{1, 2})
+	s.Put(ctx, Entry{ID: "b"}, []uint64{2, 3})
+
+	if err := s.Delete(ctx, "a", []uint64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get(ctx, "a"); ok {
+		t.Error("deleted entry still found")
+	}
+	if _, ok := s.buckets[1]; ok {
+		t.Error("bucket emptied by Delete was kept")
+	}
+	if got, _ := s.Lookup(ctx, []uint64{1, 2}); len(got) != 1 || got[0].ID != "b" {
+		t.Errorf("Lookup after Delete = %+v, want only b", got)
+	}
+
+	// The freed slot is taken by the next new entry
+	s.Put(ctx, Entry{ID: "c"}, []uint64{1})
+	if len(s.entries) != 2 || s.slots["c"] != 0 || len(s.free) != 0 {
+		t.Errorf("slots = %v, %d entries, free %v; want c in slot 0 of 2", s.slots, len(s.entries), s.free)
+	}
+	if got, _ := s.Lookup(ctx, []uint64{1}); len(got) != 1 || got[0].ID != "c" {
+		t.Errorf("Lookup(1) = %+v, want only c", got)
+	}
+	if got, _ := s.Lookup(ctx, []uint64{1, 2, 3}); len(got) != 2 {
+		t.Errorf("Lookup of all buckets = %+v, want b and c once each", got)
+	}
+
+	// Deleting an unknown id changes nothing
+	s.Delete(ctx, "a", []uint64{1, 2})
+	if s.Len() != 2 || len(s.free) != 0 {
+		t.Errorf("Len = %d, free %v after deleting an unknown id", s.Len(), s.free)
+	}
+
+	entries, _ := s.Entries(ctx)
+	if len(entries) != 2 {
+		t.Errorf("Entries = %+v, want b and c", entries)
+	}
+	s.Reset(ctx)
+	if s.Len() != 0 || len(s.buckets) != 0 {
+		t.Error("Reset left entries behind")
+	}
+}
@@ -0,0 +1,149 @@
+package lsh
+
+import (
+	"context"
+	"encoding/binary"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStore keeps the index in Redis under a key prefix, so that every
+// server shares it: one set per bucket (prefix + "b:" + band) holding IDs,
+// and one hash (prefix + "e") from ID to the encoded entry.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore returns a store keeping its keys under prefix.
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) bucketKey(b uint64) string {
+	return s.prefix + "b:" + strconv.FormatUint(b, 16)
+}
+
+func (s *RedisStore) entriesKey() string {
+	return s.prefix + "e"
+}
+
+func (s *RedisStore) Put(ctx context.Context, e Entry, bands []uint64) error {
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, s.entriesKey(), e.ID, encodeEntry(e))
+		for _, b := range bands {
+			p.SAdd(ctx, s.bucketKey(b), e.ID)
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (Entry, bool, error) {
+	raw, err := s.client.HGet(ctx, s.entriesKey(), id).Bytes()
+	if err == redis.Nil {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+	e, ok := decodeEntry(id, raw)
+	return e, ok, nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, id string, bands []uint64) error {
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		for _, b := range bands {
+			p.SRem(ctx, s.bucketKey(b), id)
+		}
+		p.HDel(ctx, s.entriesKey(), id)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Lookup(ctx context.Context, bands []uint64) ([]Entry, error) {
+	keys := make([]string, len(bands))
+	for i, b := range bands {
+		keys[i] = s.bucketKey(b)
+	}
+	ids, err := s.client.SUnion(ctx, keys...).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	values, err := s.client.HMGet(ctx, s.entriesKey(), ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Entry, 0, len(ids))
+	for i, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if e, ok := decodeEntry(ids[i], []byte(raw)); ok {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (s *RedisStore) Entries(ctx context.Context) ([]Entry, error) {
+	var out []Entry
+	iter := s.client.HScan(ctx, s.entriesKey(), 0, "", 1000).Iterator()
+	for iter.Next(ctx) {
+		id := iter.Val()
+		if !iter.Next(ctx) {
+			break
+		}
+		if e, ok := decodeEntry(id, []byte(iter.Val())); ok {
+			out = append(out, e)
+		}
+	}
+	return out, iter.Err()
+}
+
+// Reset deletes every key under the prefix.
+func (s *RedisStore) Reset(ctx context.Context) error {
+	iter := s.client.Scan(ctx, 0, s.prefix+"*", 1000).Iterator()
+	var batch []string
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == 1000 {
+			if err := s.client.Del(ctx, batch...).Err(); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return s.client.Del(ctx, batch...).Err()
+	}
+	return nil
+}
+
+// encodeEntry packs the time (Unix seconds) and the signature.
+func encodeEntry(e Entry) []byte {
+	buf := make([]byte, 8+4*len(e.Sig))
+	binary.BigEndian.PutUint64(buf, uint64(e.Time.Unix()))
+	for i, v := range e.Sig {
+		binary.BigEndian.PutUint32(buf[8+4*i:], v)
+	}
+	return buf
+}
+
+func decodeEntry(id string, raw []byte) (Entry, bool) {
+	if len(raw) != 8+4*Hashes {
+		return Entry{}, false
+	}
+	e := Entry{ID: id, Time: time.Unix(int64(binary.BigEndian.Uint64(raw)), 0), Sig: make(Signature, Hashes)}
+	for i := range e.Sig {
+		e.Sig[i] = binary.BigEndian.Uint32(raw[8+4*i:])
+	}
+	return e, true
+}
@@ -44,6 +44,10 @@ type DbAlert struct {
 	AlertType		string				`json:"alerttype"`
 	AlertCount		int					`json:"alertcount"`
 	AlertDropped	string				`json:"alertdropped"`
+	Fingerprint		string				`json:"fingerprint,omitempty" bson:"fingerprint,omitempty"`
+	// Open is set while an ingested alert is not closed; deduplication and
+	// the unique fingerprint index key on it, whatever the status is called
+	Open			bool				`json:"-" bson:"open,omitempty"`
 	AdditionalDetails	map[string]interface{}			`json:"additionaldetails"`
 	WorkLogs		[]WorkLog			`json:"worklogs"`
 	GroupIdentifier	string				`json:"groupidentifier"`
@@ -73,6 +77,10 @@ type GroupingReason struct {
 	Type        string   `json:"type" bson:"type"`
 	Description string   `json:"description,omitempty" bson:"description,omitempty"`
 	Reasons     []string `json:"reasons,omitempty" bson:"reasons,omitempty"`
+	// TOPOLOGY groups: the most upstream alerting entity, and the path
+	// between the last alert to join and the alert it was grouped with
+	ProbableCause string `json:"probable_cause,omitempty" bson:"probable_cause,omitempty"`
+	Path          string `json:"path,omitempty" bson:"path,omitempty"`
 }
 
 type WorkLog struct {
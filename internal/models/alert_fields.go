@@ -0,0 +1,266 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// alertFieldAliases maps the short names used by rules and source mappings
+// onto the canonical (lowercase json/bson) DbAlert field names.
+var alertFieldAliases = map[string]string{
+	"summary":   "alertsummary",
+	"service":   "servicename",
+	"source":    "alertsource",
+	"notes":     "alertnotes",
+	"status":    "alertstatus",
+	"acked":     "alertacked",
+	"priority":  "alertpriority",
+	"type":      "alerttype",
+	"ip":        "ipaddress",
+	"dropped":   "alertdropped",
+	"count":     "alertcount",
+	"firsttime": "alertfirsttime",
+	"lasttime":  "alertlasttime",
+	"cleartime": "alertcleartime",
+}
+
+// alertFields lists the DbAlert fields that can be read and written by name.
+var alertFields = map[string]bool{
+	"entity": true, "alertsource": true, "servicename": true, "alertsummary": true,
+	"alertstatus": true, "alertnotes": true, "alertacked": true, "severity": true,
+	"alertid": true, "alertpriority": true, "ipaddress": true, "alerttype": true,
+	"alertdropped": true, "fingerprint": true, "groupidentifier": true,
+	"alertdestination": true, "alertcount": true,
+	"alertfirsttime": true, "alertlasttime": true, "alertcleartime": true,
+}
+
+// CanonicalAlertField resolves name (case-insensitive, aliases allowed) to the
+// DbAlert field it refers to, or "" when it is not an alert field.
+func CanonicalAlertField(name string) string {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := alertFieldAliases[n]; ok {
+		n = alias
+	}
+	if alertFields[n] {
+		return n
+	}
+	return ""
+}
+
+// AlertFieldNames returns the canonical names of every addressable alert field.
+func AlertFieldNames() []string {
+	names := make([]string, 0, len(alertFields))
+	for n := range alertFields {
+		names = append(names, n)
+	}
+	return names
+}
+
+// FieldValue returns the value of an alert field by name. Names that are not
+// alert fields are looked up in AdditionalDetails, either directly or through
+// an "additionaldetails." prefix with dot-separated nesting.
+func (a *DbAlert) FieldValue(name string) (interface{}, bool) {
+	switch CanonicalAlertField(name) {
+	case "entity":
+		return a.Entity, true
+	case "alertsource":
+		return a.AlertSource, true
+	case "servicename":
+		return a.ServiceName, true
+	case "alertsummary":
+		return a.AlertSummary, true
+	case "alertstatus":
+		return a.AlertStatus, true
+	case "alertnotes":
+		return a.AlertNotes, true
+	case "alertacked":
+		return a.AlertAcked, true
+	case "severity":
+		return a.Severity, true
+	case "alertid":
+		return a.AlertId, true
+	case "alertpriority":
+		return a.AlertPriority, true
+	case "ipaddress":
+		return a.IpAddress, true
+	case "alerttype":
+		return a.AlertType, true
+	case "alertdropped":
+		return a.AlertDropped, true
+	case "fingerprint":
+		return a.Fingerprint, true
+	case "groupidentifier":
+		return a.GroupIdentifier, true
+	case "alertdestination":
+		return a.AlertDestination, true
+	case "alertcount":
+		return a.AlertCount, true
+	case "alertfirsttime":
+		return a.AlertFirstTime.Time, true
+	case "alertlasttime":
+		return a.AlertLastTime.Time, true
+	case "alertcleartime":
+		return a.AlertClearTime.Time, true
+	}
+	return a.DetailValue(name)
+}
+
+// DetailKey makes key safe to store in AdditionalDetails. Mongo paths such
+// as additionaldetails.<key>, used by correlation scope and tag grouping,
+// read dots as nesting, so they become underscores ("service.name" is
+// stored as "service_name").
+func DetailKey(key string) string {
+	return strings.ReplaceAll(key, ".", "_")
+}
+
+// DetailValue looks key up in AdditionalDetails. The key may carry an
+// "additionaldetails." prefix and use dots to reach into nested maps.
+func (a *DbAlert) DetailValue(key string) (interface{}, bool) {
+	if a.AdditionalDetails == nil {
+		return nil, false
+	}
+	if strings.HasPrefix(strings.ToLower(key), "additionaldetails.") {
+		key = key[len("additionaldetails."):]
+	}
+	if v, ok := a.AdditionalDetails[key]; ok {
+		return v, true
+	}
+
+	var current interface{} = a.AdditionalDetails
+	for _, part := range strings.Split(key, ".") {
+		m, ok := asStringMap(current)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// SetField assigns value to the named alert field, converting it for the
+// count and time fields. Names prefixed with "additionaldetails." are written
+// to AdditionalDetails; any other unknown name is an error.
+func (a *DbAlert) SetField(name, value string) error {
+	if strings.HasPrefix(strings.ToLower(name), "additionaldetails.") {
+		a.SetDetail(name[len("additionaldetails."):], value)
+		return nil
+	}
+
+	switch CanonicalAlertField(name) {
+	case "entity":
+		a.Entity = value
+	case "alertsource":
+		a.AlertSource = value
+	case "servicename":
+		a.ServiceName = value
+	case "alertsummary":
+		a.AlertSummary = value
+	case "alertstatus":
+		a.AlertStatus = value
+	case "alertnotes":
+		a.AlertNotes = value
+	case "alertacked":
+		a.AlertAcked = value
+	case "severity":
+		a.Severity = value
+	case "alertid":
+		a.AlertId = value
+	case "alertpriority":
+		a.AlertPriority = value
+	case "ipaddress":
+		a.IpAddress = value
+	case "alerttype":
+		a.AlertType = value
+	case "alertdropped":
+		a.AlertDropped = value
+	case "fingerprint":
+		a.Fingerprint = value
+	case "groupidentifier":
+		a.GroupIdentifier = value
+	case "alertdestination":
+		a.AlertDestination = value
+	case "alertcount":
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("alertcount: %v", err)
+		}
+		a.AlertCount = n
+	case "alertfirsttime", "alertlasttime", "alertcleartime":
+		t, err := ParseAlertTime(value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		switch CanonicalAlertField(name) {
+		case "alertfirsttime":
+			a.AlertFirstTime = CustomTime{Time: t}
+		case "alertlasttime":
+			a.AlertLastTime = CustomTime{Time: t}
+		default:
+			a.AlertClearTime = CustomTime{Time: t}
+		}
+	default:
+		return fmt.Errorf("unknown alert field %q", name)
+	}
+	return nil
+}
+
+// SetDetail stores value in AdditionalDetails under key.
+func (a *DbAlert) SetDetail(key string, value interface{}) {
+	if a.AdditionalDetails == nil {
+		a.AdditionalDetails = map[string]interface{}{}
+	}
+	a.AdditionalDetails[key] = value
+}
+
+// ParseAlertTime accepts the time formats sent by common monitoring tools:
+// RFC 3339, the "2006-01-02 15:04:05" form used by the UI, and unix epoch
+// seconds or milliseconds.
+func ParseAlertTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04:05Z07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		// Anything past year 5138 in seconds is really milliseconds
+		if f > 1e11 {
+			return time.UnixMilli(int64(f)), nil
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised time %q", s)
+}
+
+func asStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case primitive.M:
+		return m, true
+	case primitive.D:
+		return m.Map(), true
+	case map[string]string:
+		out := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			out[k] = val
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// AlertDroppedValue marks an alert dropped by a rule.
+const AlertDroppedValue = "YES"
+
+// Dropped reports whether an alert rule dropped the alert.
+func (a *DbAlert) Dropped() bool {
+	return a.AlertDropped == AlertDroppedValue
+}
@@ -10,8 +10,57 @@ type DbAlertRule struct {
 	RuleDescription 	string 				`bson:"ruledescription" json:"ruledescription"`
 	RuleObject			string  			`bson:"ruleobject" json:"ruleobject"`
 	Order				int  				`bson:"order" json:"order"`
+	// SetField/SetValue is the single action of rules saved before Actions
+	// existed; MigrateActions moves it into Actions
 	SetField			string				`bson:"setfield" json:"setfield"`
 	SetValue			string				`bson:"setvalue" json:"setvalue"`
+	Actions				[]AlertAction		`bson:"actions,omitempty" json:"actions,omitempty"`
+	Enabled				*bool				`bson:"enabled,omitempty" json:"enabled,omitempty"` // nil means enabled
+	Calendar			*CalendarCondition	`bson:"calendar,omitempty" json:"calendar,omitempty"` // nil means always active
 	
 }
 
+// Alert rule action types
+const (
+	ActionSetField    = "set_field"    // Field = Value
+	ActionAddTag      = "add_tag"      // AdditionalDetails[Tag] = Value
+	ActionRemoveTag   = "remove_tag"   // delete AdditionalDetails[Tag]
+	ActionSetSummary  = "set_summary"  // AlertSummary = Template rendered over the alert
+	ActionSetSeverity = "set_severity" // Severity = Value
+	ActionSetPriority = "set_priority" // AlertPriority = Value
+	ActionDrop        = "drop"         // AlertDropped = YES
+	ActionStop        = "stop"         // no alert or tag rule runs after this one
+)
+
+// AlertActionTypes lists the action types alert rules understand.
+var AlertActionTypes = []string{ActionSetField, ActionAddTag, ActionRemoveTag, ActionSetSummary, ActionSetSeverity, ActionSetPriority, ActionDrop, ActionStop}
+
+// AlertAction is one step of an alert rule; which fields apply depends on
+// Type.
+type AlertAction struct {
+	Type     string `bson:"type" json:"type"`
+	Field    string `bson:"field,omitempty" json:"field,omitempty"`
+	Tag      string `bson:"tag,omitempty" json:"tag,omitempty"`
+	Value    string `bson:"value,omitempty" json:"value,omitempty"`
+	Template string `bson:"template,omitempty" json:"template,omitempty"`
+}
+
+// RuleActions returns the actions the rule runs: Actions, or a set_field
+// action for a rule still in the SetField/SetValue form.
+func (r DbAlertRule) RuleActions() []AlertAction {
+	if len(r.Actions) > 0 || r.SetField == "" {
+		return r.Actions
+	}
+	return []AlertAction{{Type: ActionSetField, Field: r.SetField, Value: r.SetValue}}
+}
+
+// MigrateActions moves a SetField/SetValue pair into Actions and reports
+// whether the rule changed.
+func (r *DbAlertRule) MigrateActions() bool {
+	if len(r.Actions) > 0 || r.SetField == "" {
+		return false
+	}
+	r.Actions = r.RuleActions()
+	r.SetField, r.SetValue = "", ""
+	return true
+}
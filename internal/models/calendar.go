@@ -0,0 +1,44 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Calendar condition modes
+const (
+	CalendarActive   = "active"   // the rule applies within business hours
+	CalendarInactive = "inactive" // the rule applies outside business hours
+)
+
+// DbCalendar is a named business-hours calendar: weekly hours in a time
+// zone, and holidays on which it is closed all day.
+type DbCalendar struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"` // referenced by CalendarCondition.Name
+	Description string             `bson:"description" json:"description"`
+	TimeZone    string             `bson:"timezone" json:"timezone"` // IANA name, e.g. Europe/Berlin; default UTC
+	Hours       []CalendarHours    `bson:"hours" json:"hours"`
+	Holidays    []CalendarHoliday  `bson:"holidays" json:"holidays"`
+}
+
+// CalendarHours opens the calendar from Start to End (HH:MM, local time) on
+// each of Days (mon, tue, ... sun). An End before Start runs past midnight
+// into the next day.
+type CalendarHours struct {
+	Days  []string `bson:"days" json:"days"`
+	Start string   `bson:"start" json:"start"`
+	End   string   `bson:"end" json:"end"`
+}
+
+// CalendarHoliday closes the calendar on Date (YYYY-MM-DD, local time).
+type CalendarHoliday struct {
+	Date string `bson:"date" json:"date"`
+	Name string `bson:"name" json:"name"`
+}
+
+// CalendarCondition limits a rule to the business hours of the named
+// calendar (Mode active) or to the time outside them (Mode inactive).
+type CalendarCondition struct {
+	Name string `bson:"name" json:"name"`
+	Mode string `bson:"mode" json:"mode"`
+}
@@ -4,18 +4,70 @@ import (
     "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Correlation modes
+const (
+	CorrelationModeTagBased   = "TAG_BASED"
+	CorrelationModeSimilarity = "SIMILARITY"
+	CorrelationModeTopology   = "TOPOLOGY"
+	CorrelationModeEmbedding  = "EMBEDDING"
+)
+
+// Upstream directions of a TOPOLOGY rule
+const (
+	UpstreamOut = "out" // relationships point from an entity to what it depends on
+	UpstreamIn  = "in"  // relationships point from an entity to what depends on it
+)
+
+// MaxTopologyHops bounds TopologyConfig.MaxHops
+const MaxTopologyHops = 6
+
+// CorrelationModes lists the modes CorrelateAlert understands.
+var CorrelationModes = []string{CorrelationModeTagBased, CorrelationModeSimilarity, CorrelationModeTopology, CorrelationModeEmbedding}
+
 type DbCorrelationRule struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty"`
 	GroupName       string             `bson:"groupname" json:"groupname"`
 	Description     string             `bson:"description" json:"description"`
-	GroupTags       []string           `bson:"grouptags" json:"grouptags"`
+	GroupTags       []string           `bson:"grouptags" json:"grouptags"` // TAG_BASED: fields or tags whose values must all be equal
 	GroupWindow     int                `bson:"groupwindow" json:"time_window_minutes"`
-	CorrelationMode string             `bson:"correlation_mode" json:"correlation_mode"` // 'TAG_BASED' or 'SIMILARITY'
+	CorrelationMode string             `bson:"correlation_mode" json:"correlation_mode"` // TAG_BASED, SIMILARITY, TOPOLOGY or EMBEDDING
 	ScopeTags       []string           `bson:"scope_tags" json:"scope_tags"`
 	Similarity      SimilarityConfig   `bson:"similarity" json:"similarity"`
+	Topology        *TopologyConfig    `bson:"topology,omitempty" json:"topology,omitempty"`
+	Order           int                `bson:"order" json:"order"`
+	Enabled         *bool              `bson:"enabled,omitempty" json:"enabled,omitempty"` // nil means enabled
+	Calendar        *CalendarCondition `bson:"calendar,omitempty" json:"calendar,omitempty"` // nil means always active
 }
 
+// SimilarityConfig is compared with Metric for SIMILARITY rules and by
+// cosine of embeddings for EMBEDDING rules, which fall back to Metric.
+// Fields are normalized by the Normalize steps first; with Weights each
+// field is scored on its own and the scores averaged by weight. Threshold
+// is the minimum Metric score; cosines are on another scale and compared
+// with EmbeddingThreshold.
 type SimilarityConfig struct {
-	Fields    []string `bson:"fields" json:"fields"`
-	Threshold float64  `bson:"threshold" json:"threshold"`
+	Fields             []string           `bson:"fields" json:"fields"`
+	Threshold          float64            `bson:"threshold" json:"threshold"`
+	EmbeddingThreshold float64            `bson:"embedding_threshold,omitempty" json:"embedding_threshold,omitempty"` // EMBEDDING: minimum cosine
+	Metric             string             `bson:"metric,omitempty" json:"metric,omitempty"`                           // jaccard (default), tfidf, ngram or levenshtein
+	Normalize          []string           `bson:"normalize,omitempty" json:"normalize,omitempty"`                     // timestamps, uuids, ips, hostnames, numbers
+	Weights            map[string]float64 `bson:"weights,omitempty" json:"weights,omitempty"`                         // by field; fields not listed weigh 1
+}
+
+// CosineThreshold is the minimum cosine of an EMBEDDING rule. Rules saved
+// before EmbeddingThreshold existed use Threshold.
+func (c SimilarityConfig) CosineThreshold() float64 {
+	if c.EmbeddingThreshold > 0 {
+		return c.EmbeddingThreshold
+	}
+	return c.Threshold
+}
+
+// TopologyConfig groups alerts whose entities are at most MaxHops apart in
+// Neo4j, following only RelationshipTypes when any are listed. Upstream
+// tells which way relationships point, to find the probable cause.
+type TopologyConfig struct {
+	MaxHops           int      `bson:"max_hops" json:"max_hops"`
+	RelationshipTypes []string `bson:"relationship_types" json:"relationship_types"`
+	Upstream          string   `bson:"upstream" json:"upstream"` // out (default) or in
 }
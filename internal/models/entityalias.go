@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Entity alias kinds
+const (
+	AliasKindName       = "name" // the node name or id itself, for case folding
+	AliasKindHostname   = "hostname"
+	AliasKindFQDN       = "fqdn"
+	AliasKindIP         = "ip"
+	AliasKindInstanceID = "instance_id"
+)
+
+// Entity alias sources
+const (
+	AliasSourceManual = "manual"
+	AliasSourceNeo4j  = "neo4j"
+)
+
+// DbEntityAlias maps another name of an entity (short hostname, FQDN, IP,
+// cloud instance ID) to the canonical entity, the name of its Neo4j node.
+type DbEntityAlias struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Alias     string             `bson:"alias" json:"alias"` // stored lower case, unique
+	Entity    string             `bson:"entity" json:"entity"`
+	Kind      string             `bson:"kind" json:"kind"`
+	Source    string             `bson:"source" json:"source"` // manual | neo4j
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -12,6 +12,8 @@ type DbHealRule struct {
 	Order				int  				`bson:"order" json:"order"`
 	Payload				string				`bson:"payload" json:"payload"`
 	SetValue			string				`bson:"setvalue" json:"setvalue"`
+	Enabled				*bool				`bson:"enabled,omitempty" json:"enabled,omitempty"` // nil means enabled
+	Calendar			*CalendarCondition	`bson:"calendar,omitempty" json:"calendar,omitempty"` // nil means always active
 	
 }
 
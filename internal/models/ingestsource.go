@@ -0,0 +1,32 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DbIngestSource describes how to turn a vendor webhook payload into alerts.
+// Every path is a JSONPath expression evaluated against one alert item.
+type DbIngestSource struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"` // used in /ingest/:source
+	Description string             `bson:"description" json:"description"`
+	Token       string             `bson:"token,omitempty" json:"token,omitempty"` // optional shared secret, never returned
+	TokenSet    bool               `bson:"-" json:"token_set"`                     // whether Token is set, in responses
+	ClearToken  bool               `bson:"-" json:"clear_token,omitempty"`         // on update, removes the stored token
+	// UnwrapPath points at a JSON document embedded as a string, e.g. "$.Message" for SNS
+	UnwrapPath string `bson:"unwrap_path,omitempty" json:"unwrap_path,omitempty"`
+	// ItemsPath selects the alerts inside a payload carrying several (e.g. "$.alerts[*]").
+	// When empty the whole payload is a single alert.
+	ItemsPath      string            `bson:"items_path,omitempty" json:"items_path,omitempty"`
+	FieldMappings  map[string]string `bson:"field_mappings" json:"field_mappings"`   // DbAlert field -> JSONPath
+	DetailMappings map[string]string `bson:"detail_mappings" json:"detail_mappings"` // AdditionalDetails key -> JSONPath
+	SeverityMap    map[string]string `bson:"severity_map" json:"severity_map"`       // vendor severity -> severity
+	StatusMap      map[string]string `bson:"status_map" json:"status_map"`           // vendor status -> OPEN | CLOSED
+	DedupFields    []string          `bson:"dedup_fields" json:"dedup_fields"`
+}
+
+// Redact replaces the token with TokenSet before the source is returned.
+func (s *DbIngestSource) Redact() {
+	s.TokenSet = s.Token != ""
+	s.Token = ""
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Lookup table match modes
+const (
+	LookupMatchExact  = "exact"
+	LookupMatchPrefix = "prefix"
+)
+
+// DbLookupTable maps values extracted by tag rules to other values, e.g.
+// hostname prefix to datacenter or app code to owning team.
+type DbLookupTable struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"` // referenced by TagLookup.Table
+	Description string             `bson:"description" json:"description"`
+	// Match is exact (default) or prefix, where the longest matching key wins
+	Match           string        `bson:"match" json:"match"`
+	CaseInsensitive bool          `bson:"case_insensitive" json:"case_insensitive"`
+	Default         string        `bson:"default,omitempty" json:"default,omitempty"` // used when no key matches
+	Entries         []LookupEntry `bson:"entries" json:"entries"`
+}
+
+// LookupEntry is one row of a lookup table.
+type LookupEntry struct {
+	Key   string `bson:"key" json:"key"`
+	Value string `bson:"value" json:"value"`
+}
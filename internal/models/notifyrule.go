@@ -14,6 +14,8 @@ type DbNotifyRule struct {
 	EndPoint			string 				`bson:"endpoint" json:"endpoint"`
 	PagerDutyService		string				`bson:"pagerduty_service,omitempty" json:"pagerduty_service,omitempty"`
 	PagerDutyEscalationPolicy	string			`bson:"pagerduty_escalation_policy,omitempty" json:"pagerduty_escalation_policy,omitempty"`
+	Enabled				*bool				`bson:"enabled,omitempty" json:"enabled,omitempty"` // nil means enabled
+	Calendar			*CalendarCondition	`bson:"calendar,omitempty" json:"calendar,omitempty"` // nil means always active
 	
 }
 
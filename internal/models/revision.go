@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DbRuleRevision is an immutable snapshot of a rule taken after every change.
+// Versions count up from 1 per rule.
+type DbRuleRevision struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Collection string             `bson:"collection" json:"collection"` // alertrules, tagrules, ...
+	RuleID     primitive.ObjectID `bson:"rule_id" json:"rule_id"`
+	Version    int                `bson:"version" json:"version"`
+	Action     string             `bson:"action" json:"action"` // create | update | delete | enable | disable | reorder | rollback | baseline | import | migrate
+	Author     string             `bson:"author" json:"author"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	// RestoredVersion is the version a rollback copied
+	RestoredVersion int    `bson:"restored_version,omitempty" json:"restored_version,omitempty"`
+	Document        bson.M `bson:"document" json:"document"`
+}
+
+// RevisionChange is one top-level field that differs between two revisions.
+type RevisionChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
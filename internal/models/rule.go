@@ -0,0 +1,7 @@
+package models
+
+// RuleEnabled reports whether a rule is active. Rules saved before the
+// enabled flag existed have none and are active.
+func RuleEnabled(enabled *bool) bool {
+	return enabled == nil || *enabled
+}
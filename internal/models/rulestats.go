@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DbRuleStats counts how often a rule matched. Hourly and Daily hold rolling
+// per-bucket counts keyed by UTC "2006010215" and "20060102".
+type DbRuleStats struct {
+	ID               string             `bson:"_id" json:"-"` // <collection>:<rule id>
+	Collection       string             `bson:"collection" json:"collection"`
+	RuleID           primitive.ObjectID `bson:"rule_id" json:"rule_id"`
+	Hits             int64              `bson:"hits" json:"hits"`
+	LastMatchedAt    time.Time          `bson:"last_matched_at" json:"last_matched_at"`
+	LastMatchedAlert string             `bson:"last_matched_alert" json:"last_matched_alert"`
+	Hourly           map[string]int64   `bson:"hourly" json:"hourly"`
+	Daily            map[string]int64   `bson:"daily" json:"daily"`
+	// Computed from the buckets when served
+	Last24h int64 `bson:"-" json:"last_24h"`
+	Last7d  int64 `bson:"-" json:"last_7d"`
+}
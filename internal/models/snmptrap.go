@@ -0,0 +1,30 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DbSNMPTrapConfig is the single document configuring the SNMP trap receiver.
+// Entries extend and override the built-in SNMPv2-MIB / IF-MIB tables.
+type DbSNMPTrapConfig struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OIDNames   []SNMPOIDName      `bson:"oid_names" json:"oid_names"`
+	ClearRules []SNMPClearRule    `bson:"clear_rules" json:"clear_rules"`
+}
+
+// SNMPOIDName names an OID. For trap OIDs Severity sets the alert severity.
+type SNMPOIDName struct {
+	OID      string `bson:"oid" json:"oid"`
+	Name     string `bson:"name" json:"name"`
+	Severity string `bson:"severity,omitempty" json:"severity,omitempty"`
+}
+
+// SNMPClearRule pairs a problem trap with the trap that clears it, e.g.
+// linkDown/linkUp. The open alert is matched on the device and the values of
+// MatchVarbinds (names or OIDs, e.g. "ifIndex").
+type SNMPClearRule struct {
+	Name          string   `bson:"name" json:"name"`
+	TrapOID       string   `bson:"trap_oid" json:"trap_oid"`   // OID or name
+	ClearOID      string   `bson:"clear_oid" json:"clear_oid"` // OID or name
+	MatchVarbinds []string `bson:"match_varbinds" json:"match_varbinds"`
+}
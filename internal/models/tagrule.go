@@ -14,5 +14,29 @@ type DbTagRule struct {
 	TagName				string 				`bson:"tagname" json:"tagname"`
 	FieldExtraction		string				`bson:"fieldextraction" json:"fieldextraction"`
 	TagValue			string 				`bson:"tagvalue" json:"tagvalue"`
+	Enabled				*bool				`bson:"enabled,omitempty" json:"enabled,omitempty"` // nil means enabled
+	Calendar			*CalendarCondition	`bson:"calendar,omitempty" json:"calendar,omitempty"` // nil means always active
+	// Extractions emit further tags, applied after TagName
+	Extractions			[]TagExtraction		`bson:"extractions,omitempty" json:"extractions,omitempty"`
+}
+
+// TagExtraction reads one value from the alert and turns it into one or more
+// tags. The value comes from FieldName or, when set, from JSONPath evaluated
+// against AdditionalDetails. Each named group of Regex becomes a tag; without
+// a regex or named groups the value (or first group) is stored as TagName.
+type TagExtraction struct {
+	FieldName string      `bson:"fieldname,omitempty" json:"fieldname,omitempty"`
+	JSONPath  string      `bson:"jsonpath,omitempty" json:"jsonpath,omitempty"` // e.g. $.kubernetes.labels.app
+	Regex     string      `bson:"regex,omitempty" json:"regex,omitempty"`
+	TagName   string      `bson:"tagname,omitempty" json:"tagname,omitempty"`
+	Lookups   []TagLookup `bson:"lookups,omitempty" json:"lookups,omitempty"`
+}
+
+// TagLookup maps the value of an extracted tag through a lookup table. The
+// result replaces the tag, or is stored as As and the tag is kept.
+type TagLookup struct {
+	Tag   string `bson:"tag" json:"tag"`
+	Table string `bson:"table" json:"table"` // DbLookupTable name
+	As    string `bson:"as,omitempty" json:"as,omitempty"`
 }
 
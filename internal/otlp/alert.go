@@ -0,0 +1,251 @@
+package otlp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// OTLP severity number ranges (logs data model)
+const (
+	SeverityTrace = 1
+	SeverityDebug = 5
+	SeverityInfo  = 9
+	SeverityWarn  = 13
+	SeverityError = 17
+	SeverityFatal = 21
+)
+
+// maxSummaryLength keeps multi-kilobyte stack traces out of the summary
+const maxSummaryLength = 512
+
+// ParseSeverity accepts a severity number or one of TRACE, DEBUG, INFO,
+// WARN, ERROR and FATAL (optionally with a 2-4 suffix, e.g. ERROR2).
+func ParseSeverity(s string) (int, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(s); err == nil && n >= 1 && n <= 24 {
+		return n, nil
+	}
+	if n := severityFromText(s); n > 0 {
+		return n, nil
+	}
+	return 0, fmt.Errorf("otlp: unknown severity %q", s)
+}
+
+// severityFromText maps severity text for records that leave SeverityNumber unset.
+func severityFromText(text string) int {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	offset := 0
+	if n := len(text); n > 1 && text[n-1] >= '2' && text[n-1] <= '4' {
+		offset = int(text[n-1] - '1')
+		text = text[:n-1]
+	}
+	base := 0
+	switch text {
+	case "TRACE":
+		base = SeverityTrace
+	case "DEBUG":
+		base = SeverityDebug
+	case "INFO", "INFORMATION", "NOTICE":
+		base = SeverityInfo
+	case "WARN", "WARNING":
+		base = SeverityWarn
+	case "ERROR", "ERR":
+		base = SeverityError
+	case "FATAL", "CRITICAL", "CRIT", "EMERGENCY", "ALERT":
+		base = SeverityFatal
+	default:
+		return 0
+	}
+	return base + offset
+}
+
+// Severity returns the record severity number, falling back to its text.
+func (r Record) Severity() int {
+	if r.SeverityNumber > 0 {
+		return r.SeverityNumber
+	}
+	return severityFromText(r.SeverityText)
+}
+
+// alertSeverity folds the 24 OTLP severity numbers onto CRITICAL/ERROR/WARN/INFO
+func alertSeverity(n int) string {
+	switch {
+	case n >= SeverityFatal:
+		return "CRITICAL"
+	case n >= SeverityError:
+		return "ERROR"
+	case n >= SeverityWarn:
+		return "WARN"
+	}
+	return "INFO"
+}
+
+// ToAlert maps a log record onto an alert. service.name becomes ServiceName,
+// host.name (or k8s.pod.name) the Entity, and the resource attributes are
+// copied into AdditionalDetails with dots in their keys replaced
+// (service_name).
+func ToAlert(r Record) models.DbAlert {
+	service := stringAttr(r.Resource, "service.name")
+	entity := firstAttr(r.Resource, "host.name", "k8s.pod.name", "service.instance.id")
+	if entity == "" {
+		entity = service
+	}
+
+	body := bodyString(r.Body)
+	summary := body
+	if i := strings.IndexByte(summary, '\n'); i >= 0 {
+		summary = summary[:i]
+	}
+	if len(summary) > maxSummaryLength {
+		// Cut on a rune boundary so the summary stays valid UTF-8
+		cut := maxSummaryLength
+		for cut > 0 && !utf8.RuneStart(summary[cut]) {
+			cut--
+		}
+		summary = summary[:cut] + "..."
+	}
+
+	details := make(map[string]interface{}, len(r.Resource)+8)
+	for k, v := range r.Resource {
+		details[models.DetailKey(k)] = detailValue(v)
+	}
+	details["severity_number"] = r.Severity()
+	if r.SeverityText != "" {
+		details["severity_text"] = r.SeverityText
+	}
+	if r.Scope != "" {
+		details["scope"] = r.Scope
+	}
+	if r.TraceID != "" {
+		details["trace_id"] = r.TraceID
+	}
+	if r.SpanID != "" {
+		details["span_id"] = r.SpanID
+	}
+	if len(r.Attributes) > 0 {
+		details["attributes"] = detailValue(r.Attributes)
+	}
+	if body != summary {
+		details["body"] = body
+	}
+	template := BodyTemplate(body)
+	details["body_template"] = template
+
+	alertType := stringAttr(r.Attributes, "exception.type")
+	if alertType == "" {
+		alertType = r.Scope
+	}
+	if alertType == "" {
+		alertType = "log"
+	}
+
+	alert := models.DbAlert{
+		Entity:            entity,
+		ServiceName:       service,
+		AlertSource:       "otlp",
+		AlertSummary:      summary,
+		AlertType:         alertType,
+		Severity:          alertSeverity(r.Severity()),
+		AdditionalDetails: details,
+		Fingerprint:       recordFingerprint(service, entity, r.TraceID, template),
+	}
+	if !r.Time.IsZero() {
+		alert.AlertFirstTime = models.CustomTime{Time: r.Time}
+	}
+	return alert
+}
+
+// recordFingerprint collapses records of one trace, or records of a service
+// and entity sharing a body template, into a single alert.
+func recordFingerprint(service, entity, traceID, template string) string {
+	parts := []string{service, "template", entity, template}
+	if traceID != "" {
+		parts = []string{service, "trace", traceID}
+	}
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+var templateRules = []struct {
+	re          *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{8,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<num>"},
+}
+
+// BodyTemplate replaces the variable parts of a log line (UUIDs, addresses,
+// hex identifiers, numbers) with placeholders, so "timeout after 31ms" and
+// "timeout after 5ms" share the template "timeout after <num>ms".
+func BodyTemplate(body string) string {
+	if i := strings.IndexByte(body, '\n'); i >= 0 {
+		body = body[:i]
+	}
+	for _, rule := range templateRules {
+		body = rule.re.ReplaceAllString(body, rule.placeholder)
+	}
+	return strings.TrimSpace(body)
+}
+
+func bodyString(body interface{}) string {
+	switch v := body.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}:
+		// Structured bodies usually carry the text under "message" or "msg"
+		if s := firstAttr(v, "message", "msg"); s != "" {
+			return s
+		}
+	}
+	return fmt.Sprint(body)
+}
+
+// detailValue copies v with the dots in map keys replaced, at any depth.
+func detailValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			out[models.DetailKey(k)] = detailValue(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, child := range val {
+			out[i] = detailValue(child)
+		}
+		return out
+	}
+	return v
+}
+
+func stringAttr(attrs map[string]interface{}, key string) string {
+	if v, ok := attrs[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func firstAttr(attrs map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if s := stringAttr(attrs, k); s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package otlp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestToAlertTruncatesSummaryOnRuneBoundary(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"short", "disk full", "disk full"},
+		{"first line only", "disk full\nstack trace", "disk full"},
+		{"ascii", strings.Repeat("a", maxSummaryLength+10), strings.Repeat("a", maxSummaryLength) + "..."},
+		// "é" is two bytes; the limit falls inside the last one
+		{"multi-byte rune", "a" + strings.Repeat("é", maxSummaryLength), "a" + strings.Repeat("é", (maxSummaryLength-1)/2) + "..."},
+		{"four-byte rune", strings.Repeat("🔥", maxSummaryLength), strings.Repeat("🔥", maxSummaryLength/4) + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToAlert(Record{Body: tt.body}).AlertSummary
+			if !utf8.ValidString(got) {
+				t.Fatalf("summary is not valid UTF-8: %q", got)
+			}
+			if got != tt.want {
+				t.Errorf("summary = %q (%d bytes), want %q (%d bytes)", got, len(got), tt.want, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"1", 1},
+		{"24", 24},
+		{" 17 ", 17},
+		{"error", SeverityError},
+		{"ERROR3", SeverityError + 2},
+		{"warning", SeverityWarn},
+		{"Fatal4", SeverityFatal + 3},
+		{"crit", SeverityFatal},
+		{"notice", SeverityInfo},
+	}
+	for _, tt := range tests {
+		if got, err := ParseSeverity(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseSeverity(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "0", "25", "-1", "ERROR5", "ERROR1", "loud"} {
+		if got, err := ParseSeverity(in); err == nil {
+			t.Errorf("ParseSeverity(%q) = %d, want an error", in, got)
+		}
+	}
+}
+
+func TestBodyTemplate(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"timeout after 31ms", "timeout after <num>ms"},
+		{"request 0b6c8f6e-3f1a-4c39-9d3e-5a1b2c3d4e5f failed", "request <uuid> failed"},
+		{"connect 10.0.0.12:5432 refused", "connect <ip> refused"},
+		{"object deadbeef01 at 0x7ffe missing", "object <hex> at <hex> missing"},
+		{"  ratio 0.75\nsecond line 2", "ratio <num>"},
+	}
+	for _, tt := range tests {
+		if got := BodyTemplate(tt.body); got != tt.want {
+			t.Errorf("BodyTemplate(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestToAlert(t *testing.T) {
+	r := Record{
+		Resource:       map[string]interface{}{"service.name": "checkout", "k8s.pod.name": "checkout-7d9", "deployment.environment": "prod"},
+		Scope:          "payments",
+		SeverityNumber: SeverityError + 1,
+		SeverityText:   "ERROR2",
+		Body:           map[string]interface{}{"message": "card declined after 3 tries\nat pay()"},
+		Attributes:     map[string]interface{}{"exception.type": "CardError", "http": map[string]interface{}{"status.code": int64(402)}},
+		TraceID:        "5b8efff798038103d269b633813fc60c",
+	}
+	got := ToAlert(r)
+	if got.Entity != "checkout-7d9" || got.ServiceName != "checkout" || got.AlertSource != "otlp" ||
+		got.AlertType != "CardError" || got.Severity != "ERROR" || got.AlertSummary != "card declined after 3 tries" {
+		t.Errorf("ToAlert = %+v", got)
+	}
+	wantDetails := map[string]interface{}{
+		"service_name":           "checkout",
+		"k8s_pod_name":           "checkout-7d9",
+		"deployment_environment": "prod",
+		"severity_number":        SeverityError + 1,
+		"severity_text":          "ERROR2",
+		"scope":                  "payments",
+		"trace_id":               "5b8efff798038103d269b633813fc60c",
+		"attributes":             map[string]interface{}{"exception_type": "CardError", "http": map[string]interface{}{"status_code": int64(402)}},
+		"body":                   "card declined after 3 tries\nat pay()",
+		"body_template":          "card declined after <num> tries",
+	}
+	if !reflect.DeepEqual(got.AdditionalDetails, wantDetails) {
+		t.Errorf("details =\n%#v\nwant\n%#v", got.AdditionalDetails, wantDetails)
+	}
+
+	// Records of one trace share a fingerprint whatever their body
+	other := r
+	other.Body = "something else"
+	if ToAlert(other).Fingerprint != got.Fingerprint {
+		t.Error("records of one trace got different fingerprints")
+	}
+	// Without a trace, the body template decides
+	r.TraceID, other.TraceID = "", ""
+	r.Body = "timeout after 31ms"
+	same := r
+	same.Body = "timeout after 5ms"
+	if ToAlert(r).Fingerprint != ToAlert(same).Fingerprint {
+		t.Error("records sharing a template got different fingerprints")
+	}
+	if ToAlert(r).Fingerprint == ToAlert(other).Fingerprint {
+		t.Error("records with different templates share a fingerprint")
+	}
+}
+
+func TestToAlertDefaults(t *testing.T) {
+	got := ToAlert(Record{Resource: map[string]interface{}{"service.name": "batch"}, SeverityText: "fatal", Body: int64(42)})
+	if got.Entity != "batch" || got.AlertType != "log" || got.Severity != "CRITICAL" || got.AlertSummary != "42" || !got.AlertFirstTime.Time.IsZero() {
+		t.Errorf("ToAlert = %+v", got)
+	}
+	if n := got.AdditionalDetails["severity_number"]; n != SeverityFatal {
+		t.Errorf("severity_number = %v, want %d", n, SeverityFatal)
+	}
+}
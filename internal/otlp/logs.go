@@ -0,0 +1,257 @@
+// Package otlp decodes OTLP/HTTP log exports (JSON and protobuf) into flat
+// records and turns the severe ones into alerts.
+package otlp
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Record is one log record together with the resource and scope it was
+// exported under.
+type Record struct {
+	Resource       map[string]interface{}
+	Scope          string
+	Time           time.Time
+	SeverityNumber int
+	SeverityText   string
+	Body           interface{}
+	Attributes     map[string]interface{}
+	TraceID        string // lowercase hex, empty when unset
+	SpanID         string
+}
+
+// DecodeProtobuf parses an ExportLogsServiceRequest in binary protobuf form.
+func DecodeProtobuf(b []byte) ([]Record, error) {
+	var req collogs.ExportLogsServiceRequest
+	if err := proto.Unmarshal(b, &req); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, rl := range req.GetResourceLogs() {
+		resource := keyValues(rl.GetResource().GetAttributes())
+		for _, sl := range rl.GetScopeLogs() {
+			scope := sl.GetScope().GetName()
+			for _, lr := range sl.GetLogRecords() {
+				records = append(records, Record{
+					Resource:       resource,
+					Scope:          scope,
+					Time:           recordTime(lr.GetTimeUnixNano(), lr.GetObservedTimeUnixNano()),
+					SeverityNumber: int(lr.GetSeverityNumber()),
+					SeverityText:   lr.GetSeverityText(),
+					Body:           anyValue(lr.GetBody()),
+					Attributes:     keyValues(lr.GetAttributes()),
+					TraceID:        hexID(lr.GetTraceId()),
+					SpanID:         hexID(lr.GetSpanId()),
+				})
+			}
+		}
+	}
+	return records, nil
+}
+
+func keyValues(kvs []*commonpb.KeyValue) map[string]interface{} {
+	m := make(map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		m[kv.GetKey()] = anyValue(kv.GetValue())
+	}
+	return m
+}
+
+func anyValue(v *commonpb.AnyValue) interface{} {
+	switch x := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return x.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return x.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return x.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return x.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return base64.StdEncoding.EncodeToString(x.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		values := make([]interface{}, 0, len(x.ArrayValue.GetValues()))
+		for _, item := range x.ArrayValue.GetValues() {
+			values = append(values, anyValue(item))
+		}
+		return values
+	case *commonpb.AnyValue_KvlistValue:
+		return keyValues(x.KvlistValue.GetValues())
+	}
+	return nil
+}
+
+func hexID(b []byte) string {
+	for _, c := range b {
+		if c != 0 {
+			return hex.EncodeToString(b)
+		}
+	}
+	return "" // all-zero IDs mean "not set"
+}
+
+func recordTime(unixNano, observedNano uint64) time.Time {
+	if unixNano == 0 {
+		unixNano = observedNano
+	}
+	if unixNano == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(unixNano))
+}
+
+// The OTLP/JSON encoding differs from canonical protobuf JSON: trace and span
+// IDs are hex, enums are integers and 64-bit integers may be strings or
+// numbers, so it is decoded with its own structs.
+type jsonRequest struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []jsonKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			LogRecords []struct {
+				TimeUnixNano         jsonUint64     `json:"timeUnixNano"`
+				ObservedTimeUnixNano jsonUint64     `json:"observedTimeUnixNano"`
+				SeverityNumber       int            `json:"severityNumber"`
+				SeverityText         string         `json:"severityText"`
+				Body                 *jsonAnyValue  `json:"body"`
+				Attributes           []jsonKeyValue `json:"attributes"`
+				TraceID              string         `json:"traceId"`
+				SpanID               string         `json:"spanId"`
+			} `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+type jsonKeyValue struct {
+	Key   string        `json:"key"`
+	Value *jsonAnyValue `json:"value"`
+}
+
+type jsonAnyValue struct {
+	StringValue *string     `json:"stringValue"`
+	BoolValue   *bool       `json:"boolValue"`
+	IntValue    *jsonInt64  `json:"intValue"`
+	DoubleValue *float64    `json:"doubleValue"`
+	BytesValue  *string     `json:"bytesValue"`
+	ArrayValue  *struct {
+		Values []*jsonAnyValue `json:"values"`
+	} `json:"arrayValue"`
+	KvlistValue *struct {
+		Values []jsonKeyValue `json:"values"`
+	} `json:"kvlistValue"`
+}
+
+// jsonUint64 and jsonInt64 accept both "123" and 123
+type jsonUint64 uint64
+type jsonInt64 int64
+
+func (n *jsonUint64) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseUint(unquote(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("otlp: invalid uint64 %s", b)
+	}
+	*n = jsonUint64(v)
+	return nil
+}
+
+func (n *jsonInt64) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseInt(unquote(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("otlp: invalid int64 %s", b)
+	}
+	*n = jsonInt64(v)
+	return nil
+}
+
+func unquote(b []byte) string {
+	s := string(b)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// DecodeJSON parses an ExportLogsServiceRequest in OTLP/JSON form.
+func DecodeJSON(b []byte) ([]Record, error) {
+	var req jsonRequest
+	if err := json.Unmarshal(b, &req); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, rl := range req.ResourceLogs {
+		resource := jsonKeyValues(rl.Resource.Attributes)
+		for _, sl := range rl.ScopeLogs {
+			for _, lr := range sl.LogRecords {
+				records = append(records, Record{
+					Resource:       resource,
+					Scope:          sl.Scope.Name,
+					Time:           recordTime(uint64(lr.TimeUnixNano), uint64(lr.ObservedTimeUnixNano)),
+					SeverityNumber: lr.SeverityNumber,
+					SeverityText:   lr.SeverityText,
+					Body:           lr.Body.value(),
+					Attributes:     jsonKeyValues(lr.Attributes),
+					TraceID:        jsonHexID(lr.TraceID),
+					SpanID:         jsonHexID(lr.SpanID),
+				})
+			}
+		}
+	}
+	return records, nil
+}
+
+func jsonKeyValues(kvs []jsonKeyValue) map[string]interface{} {
+	m := make(map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value.value()
+	}
+	return m
+}
+
+func (v *jsonAnyValue) value() interface{} {
+	switch {
+	case v == nil:
+		return nil
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return int64(*v.IntValue)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.BytesValue != nil:
+		return *v.BytesValue
+	case v.ArrayValue != nil:
+		values := make([]interface{}, 0, len(v.ArrayValue.Values))
+		for _, item := range v.ArrayValue.Values {
+			values = append(values, item.value())
+		}
+		return values
+	case v.KvlistValue != nil:
+		return jsonKeyValues(v.KvlistValue.Values)
+	}
+	return nil
+}
+
+func jsonHexID(s string) string {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return ""
+	}
+	return hexID(b)
+}
@@ -0,0 +1,163 @@
+package otlp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// exportJSON and exportProto carry the same two records.
+const exportJSON = `{
+	"resourceLogs": [{
+		"resource": {"attributes": [
+			{"key": "service.name", "value": {"stringValue": "checkout"}},
+			{"key": "host.name", "value": {"stringValue": "web-01"}}
+		]},
+		"scopeLogs": [{
+			"scope": {"name": "payments"},
+			"logRecords": [
+				{
+					"timeUnixNano": "1714557600000000000",
+					"severityNumber": 17,
+					"severityText": "ERROR",
+					"body": {"stringValue": "card declined"},
+					"attributes": [
+						{"key": "retries", "value": {"intValue": "3"}},
+						{"key": "ratio", "value": {"doubleValue": 0.5}},
+						{"key": "ok", "value": {"boolValue": false}},
+						{"key": "raw", "value": {"bytesValue": "AQI="}},
+						{"key": "tags", "value": {"arrayValue": {"values": [{"stringValue": "a"}, {"intValue": 2}]}}},
+						{"key": "http", "value": {"kvlistValue": {"values": [{"key": "status", "value": {"intValue": 502}}]}}}
+					],
+					"traceId": "5b8efff798038103d269b633813fc60c",
+					"spanId": "eee19b7ec3c1b174"
+				},
+				{
+					"observedTimeUnixNano": 1714557601000000000,
+					"severityText": "WARN",
+					"traceId": "00000000000000000000000000000000",
+					"spanId": "not-hex"
+				}
+			]
+		}]
+	}]
+}`
+
+func exportProto(t *testing.T) []byte {
+	str := func(s string) *commonpb.AnyValue {
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+	}
+	integer := func(n int64) *commonpb.AnyValue {
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: n}}
+	}
+	req := &collogs.ExportLogsServiceRequest{ResourceLogs: []*logspb.ResourceLogs{{
+		Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+			{Key: "service.name", Value: str("checkout")},
+			{Key: "host.name", Value: str("web-01")},
+		}},
+		ScopeLogs: []*logspb.ScopeLogs{{
+			Scope: &commonpb.InstrumentationScope{Name: "payments"},
+			LogRecords: []*logspb.LogRecord{
+				{
+					TimeUnixNano:   1714557600000000000,
+					SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+					SeverityText:   "ERROR",
+					Body:           str("card declined"),
+					Attributes: []*commonpb.KeyValue{
+						{Key: "retries", Value: integer(3)},
+						{Key: "ratio", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: 0.5}}},
+						{Key: "ok", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: false}}},
+						{Key: "raw", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: []byte{1, 2}}}},
+						{Key: "tags", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: []*commonpb.AnyValue{str("a"), integer(2)}}}}},
+						{Key: "http", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: []*commonpb.KeyValue{{Key: "status", Value: integer(502)}}}}}},
+					},
+					TraceId: []byte{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c},
+					SpanId:  []byte{0xee, 0xe1, 0x9b, 0x7e, 0xc3, 0xc1, 0xb1, 0x74},
+				},
+				{
+					ObservedTimeUnixNano: 1714557601000000000,
+					SeverityText:         "WARN",
+					TraceId:              make([]byte, 16),
+				},
+			},
+		}},
+	}}}
+	b, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func wantRecords() []Record {
+	resource := map[string]interface{}{"service.name": "checkout", "host.name": "web-01"}
+	return []Record{
+		{
+			Resource:       resource,
+			Scope:          "payments",
+			Time:           time.Unix(0, 1714557600000000000),
+			SeverityNumber: SeverityError,
+			SeverityText:   "ERROR",
+			Body:           "card declined",
+			Attributes: map[string]interface{}{
+				"retries": int64(3),
+				"ratio":   0.5,
+				"ok":      false,
+				"raw":     "AQI=",
+				"tags":    []interface{}{"a", int64(2)},
+				"http":    map[string]interface{}{"status": int64(502)},
+			},
+			TraceID: "5b8efff798038103d269b633813fc60c",
+			SpanID:  "eee19b7ec3c1b174",
+		},
+		{
+			Resource:     resource,
+			Scope:        "payments",
+			Time:         time.Unix(0, 1714557601000000000),
+			SeverityText: "WARN",
+			Attributes:   map[string]interface{}{},
+		},
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func() ([]Record, error)
+	}{
+		{"json", func() ([]Record, error) { return DecodeJSON([]byte(exportJSON)) }},
+		{"protobuf", func() ([]Record, error) { return DecodeProtobuf(exportProto(t)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.decode()
+			if err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if want := wantRecords(); !reflect.DeepEqual(got, want) {
+				t.Errorf("records =\n%#v\nwant\n%#v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	for _, body := range []string{
+		`{"resourceLogs": [`,
+		`{"resourceLogs": [{"scopeLogs": [{"logRecords": [{"timeUnixNano": "soon"}]}]}]}`,
+		`{"resourceLogs": [{"scopeLogs": [{"logRecords": [{"body": {"intValue": "1.5"}}]}]}]}`,
+	} {
+		if records, err := DecodeJSON([]byte(body)); err == nil {
+			t.Errorf("DecodeJSON(%s) = %+v, want an error", body, records)
+		}
+	}
+	if records, err := DecodeProtobuf([]byte{0x0a, 0x05, 0x01}); err == nil {
+		t.Errorf("DecodeProtobuf of a truncated message = %+v, want an error", records)
+	}
+}
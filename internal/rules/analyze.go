@@ -0,0 +1,418 @@
+package rules
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// Static reasoning about predicates, for the conflict analysis. A predicate
+// is expanded into disjunctive normal form: a list of clauses, each a
+// conjunction of comparisons on normalized field names. The checks below
+// are conservative in the direction that matters: Covers only answers true
+// when it can prove it, MayOverlap only answers false when it can prove the
+// predicates disjoint. Ordering comparisons are reasoned about as numbers
+// (times on time fields), so fields compared with < and > are assumed to
+// hold numbers.
+
+// maxClauses bounds the normal form; larger predicates are left unanalysed
+const maxClauses = 256
+
+var timeFields = map[string]bool{"alertfirsttime": true, "alertlasttime": true, "alertcleartime": true}
+
+type clause []comparison
+
+// FieldKey normalizes a rule field name: the canonical alert field, or
+// "additionaldetails.<key>" for anything else.
+func FieldKey(name string) string {
+	if f := models.CanonicalAlertField(name); f != "" {
+		return f
+	}
+	n := strings.TrimSpace(name)
+	if strings.HasPrefix(strings.ToLower(n), "additionaldetails.") {
+		n = n[len("additionaldetails."):]
+	}
+	return "additionaldetails." + n
+}
+
+// clauses returns the satisfiable clauses of the predicate's normal form;
+// ok is false when the form grew past maxClauses.
+func (p *Predicate) clauses() ([]clause, bool) {
+	all, ok := normalize(p.root, false)
+	if !ok {
+		return nil, false
+	}
+	out := all[:0]
+	for _, cl := range all {
+		if !contradictory(cl) {
+			out = append(out, cl)
+		}
+	}
+	return out, true
+}
+
+// normalize expands cond, negated when negate is set, into clauses.
+func normalize(cond condition, negate bool) ([]clause, bool) {
+	switch c := cond.(type) {
+	case comparison:
+		c.field = FieldKey(c.field)
+		if negate {
+			c.negated = !c.negated
+		}
+		return []clause{{c}}, true
+	case group:
+		neg := c.not != negate
+		if len(c.children) == 0 {
+			// An empty group matches everything
+			if neg {
+				return nil, true
+			}
+			return []clause{{}}, true
+		}
+		// De Morgan: a negated AND is an OR of negated children
+		and := c.and != neg
+		var out []clause
+		for i, child := range c.children {
+			sub, ok := normalize(child, neg)
+			if !ok {
+				return nil, false
+			}
+			switch {
+			case !and:
+				out = append(out, sub...)
+			case i == 0:
+				out = sub
+			default:
+				product := make([]clause, 0, len(out)*len(sub))
+				for _, x := range out {
+					for _, y := range sub {
+						product = append(product, append(append(clause{}, x...), y...))
+					}
+				}
+				out = product
+			}
+			if len(out) > maxClauses {
+				return nil, false
+			}
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// Satisfiable reports whether some alert can match p; false only when every
+// clause contradicts itself (e.g. severity = 1 and severity = 2).
+func (p *Predicate) Satisfiable() bool {
+	cls, ok := p.clauses()
+	return !ok || len(cls) > 0
+}
+
+// Covers reports whether every alert matching q provably matches p too.
+func (p *Predicate) Covers(q *Predicate) bool {
+	pc, ok := p.clauses()
+	if !ok {
+		return false
+	}
+	qc, ok := q.clauses()
+	if !ok {
+		return false
+	}
+	for _, x := range qc {
+		covered := false
+		for _, y := range pc {
+			if clauseImplies(x, y) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// MayOverlap reports whether an alert could match both p and q; false only
+// when they are provably disjoint.
+func (p *Predicate) MayOverlap(q *Predicate) bool {
+	pc, ok := p.clauses()
+	if !ok {
+		return true
+	}
+	qc, ok := q.clauses()
+	if !ok {
+		return true
+	}
+	for _, x := range pc {
+		for _, y := range qc {
+			if !clausesDisjoint(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clauseImplies reports whether x implies y: each comparison of y follows
+// from one of x.
+func clauseImplies(x, y clause) bool {
+	for _, b := range y {
+		found := false
+		for _, a := range x {
+			if implies(a, b) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func clausesDisjoint(x, y clause) bool {
+	for _, a := range x {
+		for _, b := range y {
+			if disjoint(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contradictory(cl clause) bool {
+	return clausesDisjoint(cl, cl)
+}
+
+// implies reports whether a provably implies b.
+func implies(a, b comparison) bool {
+	if a.field != b.field {
+		return false
+	}
+	switch {
+	case !a.negated && !b.negated:
+		return impliesPositive(a, b)
+	case !a.negated && b.negated:
+		return disjointPositive(a, positive(b))
+	case a.negated && b.negated:
+		// contrapositive: not a => not b when b => a
+		return impliesPositive(positive(b), positive(a))
+	}
+	return false
+}
+
+// disjoint reports whether no value satisfies both a and b.
+func disjoint(a, b comparison) bool {
+	b.negated = !b.negated
+	return implies(a, b)
+}
+
+func positive(c comparison) comparison {
+	c.negated = false
+	return c
+}
+
+// exactValues returns the only lower-case values an = or in comparison
+// accepts.
+func exactValues(c comparison) ([]string, bool) {
+	switch c.kind {
+	case opEquals, opIn:
+		return c.lower, true
+	}
+	return nil, false
+}
+
+// holds evaluates the positive comparison c on a field whose lower-cased
+// value is v; known is false when the case or type of the real value could
+// change the outcome.
+func holds(c comparison, v string) (result, known bool) {
+	switch c.kind {
+	case opRegex:
+		return false, false
+	case opGreater, opGreaterEqual, opLess, opLessEqual, opBetween:
+		if timeFields[c.field] || v == "" {
+			return false, false
+		}
+	}
+	return positive(c).testValue(v, v != ""), true
+}
+
+func impliesPositive(a, b comparison) bool {
+	if a.kind == b.kind && sameOperands(a, b) {
+		return true
+	}
+	if values, ok := exactValues(a); ok {
+		for _, v := range values {
+			if r, known := holds(b, v); !known || !r {
+				return false
+			}
+		}
+		return true
+	}
+	switch b.kind {
+	case opExists:
+		switch a.kind {
+		case opContains, opBeginsWith, opEndsWith:
+			return a.lower[0] != ""
+		case opRegex:
+			return !a.re.MatchString("")
+		}
+	case opContains:
+		switch a.kind {
+		case opContains, opBeginsWith, opEndsWith:
+			return strings.Contains(a.lower[0], b.lower[0])
+		}
+	case opBeginsWith:
+		return a.kind == opBeginsWith && strings.HasPrefix(a.lower[0], b.lower[0])
+	case opEndsWith:
+		return a.kind == opEndsWith && strings.HasSuffix(a.lower[0], b.lower[0])
+	}
+	return rangeWithin(a, b)
+}
+
+func disjointPositive(a, b comparison) bool {
+	if values, ok := exactValues(a); ok {
+		return noneHold(b, values)
+	}
+	if values, ok := exactValues(b); ok {
+		return noneHold(a, values)
+	}
+	switch {
+	case a.kind == opBeginsWith && b.kind == opBeginsWith:
+		return !strings.HasPrefix(a.lower[0], b.lower[0]) && !strings.HasPrefix(b.lower[0], a.lower[0])
+	case a.kind == opEndsWith && b.kind == opEndsWith:
+		return !strings.HasSuffix(a.lower[0], b.lower[0]) && !strings.HasSuffix(b.lower[0], a.lower[0])
+	}
+	return rangesDisjoint(a, b)
+}
+
+// noneHold reports whether c provably rejects every value.
+func noneHold(c comparison, values []string) bool {
+	for _, v := range values {
+		if r, known := holds(c, v); !known || r {
+			return false
+		}
+	}
+	return true
+}
+
+func sameOperands(a, b comparison) bool {
+	if a.kind == opRegex {
+		return a.re.String() == b.re.String()
+	}
+	if len(a.lower) != len(b.lower) {
+		return false
+	}
+	for i := range a.lower {
+		if a.lower[i] != b.lower[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// bound is one end of the range an ordering comparison accepts.
+type bound struct {
+	value     string
+	inclusive bool
+	set       bool
+}
+
+func rangeOf(c comparison) (lo, hi bound, ok bool) {
+	switch c.kind {
+	case opGreater:
+		lo = bound{c.values[0], false, true}
+	case opGreaterEqual:
+		lo = bound{c.values[0], true, true}
+	case opLess:
+		hi = bound{c.values[0], false, true}
+	case opLessEqual:
+		hi = bound{c.values[0], true, true}
+	case opBetween:
+		lo = bound{c.values[0], true, true}
+		hi = bound{c.values[1], true, true}
+	default:
+		return lo, hi, false
+	}
+	return lo, hi, true
+}
+
+// rangeWithin reports whether the range of a lies inside the range of b.
+func rangeWithin(a, b comparison) bool {
+	alo, ahi, ok := rangeOf(a)
+	if !ok {
+		return false
+	}
+	blo, bhi, ok := rangeOf(b)
+	if !ok {
+		return false
+	}
+	if blo.set {
+		if !alo.set {
+			return false
+		}
+		c, ok := orderOperands(a.field, alo.value, blo.value)
+		if !ok || c < 0 || (c == 0 && alo.inclusive && !blo.inclusive) {
+			return false
+		}
+	}
+	if bhi.set {
+		if !ahi.set {
+			return false
+		}
+		c, ok := orderOperands(a.field, ahi.value, bhi.value)
+		if !ok || c > 0 || (c == 0 && ahi.inclusive && !bhi.inclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+func rangesDisjoint(a, b comparison) bool {
+	alo, ahi, ok := rangeOf(a)
+	if !ok {
+		return false
+	}
+	blo, bhi, ok := rangeOf(b)
+	if !ok {
+		return false
+	}
+	return below(a.field, ahi, blo) || below(a.field, bhi, alo)
+}
+
+// below reports whether everything up to hi lies under lo.
+func below(field string, hi, lo bound) bool {
+	if !hi.set || !lo.set {
+		return false
+	}
+	c, ok := orderOperands(field, hi.value, lo.value)
+	return ok && (c < 0 || (c == 0 && !(hi.inclusive && lo.inclusive)))
+}
+
+// orderOperands compares two operands: as times on time fields, otherwise
+// as numbers. ok is false when they are neither.
+func orderOperands(field, x, y string) (int, bool) {
+	if timeFields[field] {
+		tx, errX := models.ParseAlertTime(x)
+		ty, errY := models.ParseAlertTime(y)
+		if errX != nil || errY != nil {
+			return 0, false
+		}
+		return tx.Compare(ty), true
+	}
+	a, errA := strconv.ParseFloat(strings.TrimSpace(x), 64)
+	b, errB := strconv.ParseFloat(strings.TrimSpace(y), 64)
+	if errA != nil || errB != nil {
+		return 0, false
+	}
+	switch {
+	case a < b:
+		return -1, true
+	case a > b:
+		return 1, true
+	}
+	return 0, true
+}
@@ -0,0 +1,141 @@
+package rules
+
+import "testing"
+
+func mustCompile(t *testing.T, ruleObject string) *Predicate {
+	t.Helper()
+	p, err := Compile(ruleObject)
+	if err != nil {
+		t.Fatalf("Compile(%s): %v", ruleObject, err)
+	}
+	return p
+}
+
+func TestFieldKey(t *testing.T) {
+	tests := map[string]string{
+		"Entity":                   "entity",
+		"summary":                  "alertsummary",
+		" priority ":               "alertpriority",
+		"region":                   "additionaldetails.region",
+		"additionaldetails.region": "additionaldetails.region",
+		"AdditionalDetails.k8s.ns": "additionaldetails.k8s.ns",
+	}
+	for in, want := range tests {
+		if got := FieldKey(in); got != want {
+			t.Errorf("FieldKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSatisfiable(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		want bool
+	}{
+		{"empty", ``, true},
+		{"single", `{"rules":[{"field":"severity","operator":"=","value":"critical"}]}`, true},
+		{"two values", `{"rules":[
+			{"field":"severity","operator":"=","value":"critical"},
+			{"field":"severity","operator":"=","value":"warn"}]}`, false},
+		{"alias and detail name the same field", `{"rules":[
+			{"field":"priority","operator":"=","value":"P1"},
+			{"field":"alertpriority","operator":"!=","value":"p1"}]}`, false},
+		{"equals outside in", `{"rules":[
+			{"field":"region","operator":"=","value":"eu"},
+			{"field":"additionaldetails.region","operator":"in","value":"us,ap"}]}`, false},
+		{"empty range", `{"rules":[
+			{"field":"count","operator":">","value":"10"},
+			{"field":"count","operator":"<=","value":"10"}]}`, false},
+		{"touching range", `{"rules":[
+			{"field":"count","operator":">=","value":"10"},
+			{"field":"count","operator":"<=","value":"10"}]}`, true},
+		{"disjoint prefixes", `{"rules":[
+			{"field":"entity","operator":"beginsWith","value":"db"},
+			{"field":"entity","operator":"beginsWith","value":"web"}]}`, false},
+		{"one satisfiable branch", `{"combinator":"or","rules":[
+			{"combinator":"and","rules":[
+				{"field":"severity","operator":"=","value":"critical"},
+				{"field":"severity","operator":"=","value":"warn"}]},
+			{"field":"entity","operator":"=","value":"db01"}]}`, true},
+		{"negated empty group", `{"combinator":"and","not":true,"rules":[{"combinator":"and","rules":[]}]}`, false},
+		{"de morgan", `{"combinator":"and","rules":[
+			{"field":"severity","operator":"=","value":"critical"},
+			{"combinator":"or","not":true,"rules":[
+				{"field":"severity","operator":"=","value":"critical"},
+				{"field":"entity","operator":"=","value":"db01"}]}]}`, false},
+		{"regex is not reasoned about", `{"rules":[
+			{"field":"entity","operator":"regex","value":"^db"},
+			{"field":"entity","operator":"=","value":"web01"}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustCompile(t, tt.rule).Satisfiable(); got != tt.want {
+				t.Errorf("Satisfiable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoversAndMayOverlap(t *testing.T) {
+	const (
+		everything   = ``
+		critical     = `{"rules":[{"field":"severity","operator":"=","value":"critical"}]}`
+		criticalOrDB = `{"combinator":"or","rules":[{"field":"severity","operator":"=","value":"CRITICAL"},{"field":"entity","operator":"beginsWith","value":"db"}]}`
+		criticalDB   = `{"rules":[{"field":"severity","operator":"=","value":"critical"},{"field":"entity","operator":"beginsWith","value":"db0"}]}`
+		warn         = `{"rules":[{"field":"severity","operator":"in","value":"warn,info"}]}`
+		notCritical  = `{"rules":[{"field":"severity","operator":"!=","value":"critical"}]}`
+		db           = `{"rules":[{"field":"entity","operator":"beginsWith","value":"db"}]}`
+		web          = `{"rules":[{"field":"entity","operator":"beginsWith","value":"web"}]}`
+		hasDB        = `{"rules":[{"field":"entity","operator":"contains","value":"db"}]}`
+		count5to10   = `{"rules":[{"field":"count","operator":"between","value":"5,10"}]}`
+		countOver3   = `{"rules":[{"field":"count","operator":">","value":"3"}]}`
+		countUnder5  = `{"rules":[{"field":"count","operator":"<","value":"5"}]}`
+		hasRegion    = `{"rules":[{"field":"region","operator":"exists"}]}`
+		regionEU     = `{"rules":[{"field":"additionaldetails.region","operator":"beginsWith","value":"eu"}]}`
+		huge         = `{"combinator":"and","rules":[
+			{"combinator":"or","rules":[{"field":"a","operator":"=","value":"1"},{"field":"a","operator":"=","value":"2"},{"field":"a","operator":"=","value":"3"},{"field":"a","operator":"=","value":"4"}]},
+			{"combinator":"or","rules":[{"field":"b","operator":"=","value":"1"},{"field":"b","operator":"=","value":"2"},{"field":"b","operator":"=","value":"3"},{"field":"b","operator":"=","value":"4"}]},
+			{"combinator":"or","rules":[{"field":"c","operator":"=","value":"1"},{"field":"c","operator":"=","value":"2"},{"field":"c","operator":"=","value":"3"},{"field":"c","operator":"=","value":"4"}]},
+			{"combinator":"or","rules":[{"field":"d","operator":"=","value":"1"},{"field":"d","operator":"=","value":"2"},{"field":"d","operator":"=","value":"3"},{"field":"d","operator":"=","value":"4"}]},
+			{"combinator":"or","rules":[{"field":"e","operator":"=","value":"1"},{"field":"e","operator":"=","value":"2"}]}]}`
+	)
+	tests := []struct {
+		name    string
+		p, q    string
+		covers  bool // p covers q
+		overlap bool
+	}{
+		{"anything covers", everything, critical, true, true},
+		{"same condition", critical, critical, true, true},
+		{"narrower not covering", critical, everything, false, true},
+		{"or covers its branch", criticalOrDB, critical, true, true},
+		{"or covers conjunction", criticalOrDB, criticalDB, true, true},
+		{"conjunction covers nothing wider", criticalDB, critical, false, true},
+		{"disjoint values", critical, warn, false, false},
+		{"negation covers other values", notCritical, warn, true, true},
+		{"negation is disjoint", notCritical, critical, false, false},
+		{"prefix covers longer prefix", db, criticalDB, true, true},
+		{"disjoint prefixes", db, web, false, false},
+		{"contains covers prefix", hasDB, db, true, true},
+		{"range within range", countOver3, count5to10, true, true},
+		{"ranges disjoint", count5to10, countUnder5, false, false},
+		{"ranges overlap", countOver3, countUnder5, false, true},
+		{"exists covers prefix", hasRegion, regionEU, true, true},
+		{"too large to analyse", critical, huge, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, q := mustCompile(t, tt.p), mustCompile(t, tt.q)
+			if got := p.Covers(q); got != tt.covers {
+				t.Errorf("Covers = %v, want %v", got, tt.covers)
+			}
+			if got := p.MayOverlap(q); got != tt.overlap {
+				t.Errorf("MayOverlap = %v, want %v", got, tt.overlap)
+			}
+			if got := q.MayOverlap(p); got != tt.overlap {
+				t.Errorf("MayOverlap reversed = %v, want %v", got, tt.overlap)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// span is one block of business hours in minutes since local midnight. An
+// end past 24*60 runs into the next day.
+type span struct {
+	start, end int
+}
+
+// Calendar is a compiled DbCalendar.
+type Calendar struct {
+	loc      *time.Location
+	hours    [7][]span // by time.Weekday
+	holidays map[string]string
+}
+
+// NewCalendar checks and compiles a calendar.
+func NewCalendar(c models.DbCalendar) (*Calendar, error) {
+	cal := &Calendar{loc: time.UTC, holidays: map[string]string{}}
+	if c.TimeZone != "" {
+		loc, err := time.LoadLocation(c.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("timezone: %v", err)
+		}
+		cal.loc = loc
+	}
+	for i, h := range c.Hours {
+		start, err := clockMinutes(h.Start)
+		if err != nil {
+			return nil, fmt.Errorf("hours[%d].start: %v", i, err)
+		}
+		end, err := clockMinutes(h.End)
+		if err != nil {
+			return nil, fmt.Errorf("hours[%d].end: %v", i, err)
+		}
+		if end == start {
+			return nil, fmt.Errorf("hours[%d]: start and end are equal", i)
+		}
+		if end < start {
+			end += 24 * 60
+		}
+		if len(h.Days) == 0 {
+			return nil, fmt.Errorf("hours[%d].days: at least one day is required", i)
+		}
+		for _, d := range h.Days {
+			day, ok := parseWeekday(d)
+			if !ok {
+				return nil, fmt.Errorf("hours[%d].days: unknown day %q", i, d)
+			}
+			cal.hours[day] = append(cal.hours[day], span{start, end})
+		}
+	}
+	for i, h := range c.Holidays {
+		if _, err := time.Parse("2006-01-02", h.Date); err != nil {
+			return nil, fmt.Errorf("holidays[%d].date: must be YYYY-MM-DD", i)
+		}
+		cal.holidays[h.Date] = h.Name
+	}
+	return cal, nil
+}
+
+// parseWeekday accepts "mon" or "monday", in any case.
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// clockMinutes parses HH:MM; 24:00 is accepted as the end of the day.
+func clockMinutes(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Window is the state of a rule's calendar condition at one instant, as
+// shown by dry runs.
+type Window struct {
+	Calendar  string `json:"calendar"`
+	Mode      string `json:"mode"`
+	LocalTime string `json:"local_time"`
+	Open      bool   `json:"open"`   // within business hours
+	Window    string `json:"window"` // the business hours, holiday or "closed" that applied
+	Active    bool   `json:"active"` // whether the rule applies
+}
+
+// At reports whether the calendar is open at t and which hours or holiday
+// decided it.
+func (c *Calendar) At(t time.Time) (bool, string) {
+	local := t.In(c.loc)
+	minute := local.Hour()*60 + local.Minute()
+	today := local.Format("2006-01-02")
+	yesterday := local.AddDate(0, 0, -1)
+
+	if name, ok := c.holidays[today]; ok {
+		// Hours begun the day before still run into a holiday
+		if s, ok := c.spill(yesterday, minute); ok {
+			return true, s
+		}
+		return false, holidayWindow(today, name)
+	}
+	for _, s := range c.hours[local.Weekday()] {
+		if minute >= s.start && minute < s.end {
+			return true, spanWindow(local.Weekday(), s)
+		}
+	}
+	if s, ok := c.spill(yesterday, minute); ok {
+		return true, s
+	}
+	return false, "closed"
+}
+
+// spill finds hours of day that run past midnight into minute of the day
+// after; hours of a holiday do not.
+func (c *Calendar) spill(day time.Time, minute int) (string, bool) {
+	if _, ok := c.holidays[day.Format("2006-01-02")]; ok {
+		return "", false
+	}
+	for _, s := range c.hours[day.Weekday()] {
+		if s.end > 24*60 && minute < s.end-24*60 {
+			return spanWindow(day.Weekday(), s), true
+		}
+	}
+	return "", false
+}
+
+func spanWindow(day time.Weekday, s span) string {
+	return fmt.Sprintf("%s %02d:%02d-%02d:%02d", strings.ToLower(day.String()[:3]), s.start/60, s.start%60, (s.end/60)%24, s.end%60)
+}
+
+func holidayWindow(date, name string) string {
+	if name == "" {
+		return "holiday " + date
+	}
+	return "holiday " + date + " (" + name + ")"
+}
+
+// Calendars holds compiled calendars by name.
+type Calendars map[string]*Calendar
+
+// NewCalendars compiles every calendar; broken ones are reported and left
+// out, so rules using them fail with an unknown calendar.
+func NewCalendars(list []models.DbCalendar) (Calendars, []error) {
+	out := make(Calendars, len(list))
+	var errs []error
+	for _, c := range list {
+		cal, err := NewCalendar(c)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("calendar %q: %v", c.Name, err))
+			continue
+		}
+		out[c.Name] = cal
+	}
+	return out, errs
+}
+
+// Known returns an error when cond names a calendar that is not loaded.
+func (cs Calendars) Known(cond *models.CalendarCondition) error {
+	if cond == nil || cond.Name == "" {
+		return nil
+	}
+	if _, ok := cs[cond.Name]; !ok {
+		return fmt.Errorf("unknown calendar %q", cond.Name)
+	}
+	return nil
+}
+
+// Check evaluates a rule's calendar condition at t. Rules without one are
+// always active and get a nil Window; an unknown calendar is an error and
+// the rule does not apply.
+func (cs Calendars) Check(cond *models.CalendarCondition, t time.Time) (*Window, error) {
+	if cond == nil || cond.Name == "" {
+		return nil, nil
+	}
+	if err := cs.Known(cond); err != nil {
+		return nil, err
+	}
+	cal := cs[cond.Name]
+	open, window := cal.At(t)
+	w := &Window{
+		Calendar:  cond.Name,
+		Mode:      cond.Mode,
+		LocalTime: t.In(cal.loc).Format("2006-01-02 15:04 MST Mon"),
+		Open:      open,
+		Window:    window,
+		Active:    open,
+	}
+	if cond.Mode == models.CalendarInactive {
+		w.Active = !open
+	}
+	return w, nil
+}
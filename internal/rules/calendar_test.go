@@ -0,0 +1,133 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+func testCalendars(t *testing.T) Calendars {
+	t.Helper()
+	cals, errs := NewCalendars([]models.DbCalendar{
+		{
+			Name:     "office",
+			TimeZone: "Europe/Berlin",
+			Hours:    []models.CalendarHours{{Days: []string{"mon", "Tue", "wednesday", "thu", "fri"}, Start: "09:00", End: "17:00"}},
+			Holidays: []models.CalendarHoliday{{Date: "2024-05-09", Name: "Ascension"}},
+		},
+		{
+			Name:     "night",
+			Hours:    []models.CalendarHours{{Days: []string{"fri"}, Start: "22:00", End: "06:00"}},
+			Holidays: []models.CalendarHoliday{{Date: "2024-05-11"}, {Date: "2024-05-17"}},
+		},
+		{
+			Name:  "allday",
+			Hours: []models.CalendarHours{{Days: []string{"mon"}, Start: "00:00", End: "24:00"}},
+		},
+	})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	return cals
+}
+
+func TestCalendarAt(t *testing.T) {
+	cals := testCalendars(t)
+	tests := []struct {
+		name     string
+		calendar string
+		at       string // RFC 3339
+		open     bool
+		window   string
+	}{
+		{"before opening", "office", "2024-05-06T08:59:00+02:00", false, "closed"},
+		{"opening", "office", "2024-05-06T09:00:00+02:00", true, "mon 09:00-17:00"},
+		{"in another zone", "office", "2024-05-06T07:30:00Z", true, "mon 09:00-17:00"},
+		{"closing", "office", "2024-05-06T17:00:00+02:00", false, "closed"},
+		{"long day name", "office", "2024-05-08T12:00:00+02:00", true, "wed 09:00-17:00"},
+		{"holiday", "office", "2024-05-09T12:00:00+02:00", false, "holiday 2024-05-09 (Ascension)"},
+		{"weekend", "office", "2024-05-11T12:00:00+02:00", false, "closed"},
+		{"before midnight", "night", "2024-05-10T23:00:00Z", true, "fri 22:00-06:00"},
+		{"after midnight into a holiday", "night", "2024-05-11T05:59:00Z", true, "fri 22:00-06:00"},
+		{"end after midnight", "night", "2024-05-11T06:00:00Z", false, "holiday 2024-05-11"},
+		{"after midnight", "night", "2024-05-04T03:00:00Z", true, "fri 22:00-06:00"},
+		{"holiday before midnight", "night", "2024-05-17T23:00:00Z", false, "holiday 2024-05-17"},
+		{"no spill from a holiday", "night", "2024-05-18T01:00:00Z", false, "closed"},
+		{"midnight start", "allday", "2024-05-06T00:00:00Z", true, "mon 00:00-00:00"},
+		{"last minute", "allday", "2024-05-06T23:59:59Z", true, "mon 00:00-00:00"},
+		{"midnight end", "allday", "2024-05-07T00:00:00Z", false, "closed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, err := time.Parse(time.RFC3339, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			open, window := cals[tt.calendar].At(at)
+			if open != tt.open || window != tt.window {
+				t.Errorf("At(%s) = %v %q, want %v %q", tt.at, open, window, tt.open, tt.window)
+			}
+		})
+	}
+}
+
+func TestNewCalendarErrors(t *testing.T) {
+	hours := func(start, end string, days ...string) []models.CalendarHours {
+		return []models.CalendarHours{{Days: days, Start: start, End: end}}
+	}
+	tests := []struct {
+		name string
+		cal  models.DbCalendar
+	}{
+		{"unknown timezone", models.DbCalendar{TimeZone: "Mars/Olympus"}},
+		{"bad start", models.DbCalendar{Hours: hours("25:00", "17:00", "mon")}},
+		{"bad end", models.DbCalendar{Hours: hours("09:00", "5pm", "mon")}},
+		{"empty span", models.DbCalendar{Hours: hours("09:00", "09:00", "mon")}},
+		{"no days", models.DbCalendar{Hours: hours("09:00", "17:00")}},
+		{"unknown day", models.DbCalendar{Hours: hours("09:00", "17:00", "funday")}},
+		{"bad holiday", models.DbCalendar{Holidays: []models.CalendarHoliday{{Date: "09/05/2024"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCalendar(tt.cal); err == nil {
+				t.Error("NewCalendar succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestCalendarsCheck(t *testing.T) {
+	cals := testCalendars(t)
+	open := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC)
+	closed := time.Date(2024, 5, 6, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		cond   *models.CalendarCondition
+		at     time.Time
+		active bool
+	}{
+		{"active in hours", &models.CalendarCondition{Name: "office", Mode: models.CalendarActive}, open, true},
+		{"active out of hours", &models.CalendarCondition{Name: "office", Mode: models.CalendarActive}, closed, false},
+		{"inactive in hours", &models.CalendarCondition{Name: "office", Mode: models.CalendarInactive}, open, false},
+		{"inactive out of hours", &models.CalendarCondition{Name: "office", Mode: models.CalendarInactive}, closed, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := cals.Check(tt.cond, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.Active != tt.active {
+				t.Errorf("Active = %v, want %v (%+v)", w.Active, tt.active, w)
+			}
+		})
+	}
+
+	if w, err := cals.Check(nil, open); w != nil || err != nil {
+		t.Errorf("Check(nil) = %v, %v; want no window", w, err)
+	}
+	if _, err := cals.Check(&models.CalendarCondition{Name: "missing", Mode: models.CalendarActive}, open); err == nil {
+		t.Error("Check of an unknown calendar succeeded")
+	}
+}
@@ -0,0 +1,450 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/similarity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Finding kinds reported by Analyze
+const (
+	FindingInvalid       = "invalid"       // the condition does not compile
+	FindingUnsatisfiable = "unsatisfiable" // the condition matches no alert
+	FindingShadowed      = "shadowed"      // the rule never has an effect
+	FindingRedundant     = "redundant"     // a later rule writes the same value whenever this one does
+	FindingConflict      = "conflict"      // a later rule may write a different value
+	FindingScopeOverlap  = "scope_overlap" // two correlation rules can group the same alerts
+)
+
+// RuleRef identifies the rule a finding is about.
+type RuleRef struct {
+	Type  string             `json:"type"` // alert | tag | notify | heal | correlation
+	ID    primitive.ObjectID `json:"id"`
+	Name  string             `json:"name"`
+	Order int                `json:"order"`
+}
+
+func (r RuleRef) String() string {
+	return fmt.Sprintf("%s rule %q (order %d)", r.Type, r.Name, r.Order)
+}
+
+// Finding is one problem found by Analyze. Other is the rule that causes it.
+type Finding struct {
+	Kind    string   `json:"kind"`
+	Rule    RuleRef  `json:"rule"`
+	Other   *RuleRef `json:"other,omitempty"`
+	Field   string   `json:"field,omitempty"`
+	Message string   `json:"message"`
+	// OverlapMinutes is the window in which two correlation rules compete
+	OverlapMinutes int `json:"overlap_minutes,omitempty"`
+}
+
+// write is a field an enrichment rule writes when it matches. Always is
+// false when writing depends on more than the condition (a regex that must
+// match, a value that must be present); Value is only set for constants.
+// Removed marks a tag deleted by a remove_tag action.
+type write struct {
+	field    string
+	value    string
+	constant bool
+	always   bool
+	removed  bool
+}
+
+// writer is an enabled alert or tag rule with a satisfiable condition.
+// Stops is set for alert rules with a stop action.
+type writer struct {
+	ref      RuleRef
+	pred     *Predicate
+	calendar *models.CalendarCondition
+	reads    map[string]bool
+	writes   []write
+	stops    bool
+}
+
+// Analyze statically checks enabled rules for conditions that are invalid
+// or match nothing, enrichment rules whose writes are overwritten or
+// contested by later rules, and correlation rules competing for the same
+// alerts. Rules are expected in evaluation order; disabled rules are
+// skipped.
+func Analyze(alertRules []models.DbAlertRule, tagRules []models.DbTagRule, notifyRules []models.DbNotifyRule, healRules []models.DbHealRule, correlationRules []models.DbCorrelationRule) []Finding {
+	findings := []Finding{}
+	var writers []writer
+
+	compile := func(ref RuleRef, ruleObject string) *Predicate {
+		pred, err := Compile(ruleObject)
+		if err != nil {
+			findings = append(findings, Finding{Kind: FindingInvalid, Rule: ref, Message: err.Error()})
+			return nil
+		}
+		if !pred.Satisfiable() {
+			findings = append(findings, Finding{Kind: FindingUnsatisfiable, Rule: ref, Message: "the condition contradicts itself and matches no alert"})
+			return nil
+		}
+		return pred
+	}
+
+	for _, r := range sortedByOrder(alertRules, func(r models.DbAlertRule) int { return r.Order }) {
+		if !models.RuleEnabled(r.Enabled) {
+			continue
+		}
+		ref := RuleRef{Type: "alert", ID: r.ID, Name: r.RuleName, Order: r.Order}
+		if pred := compile(ref, r.RuleObject); pred != nil {
+			writers = append(writers, writer{ref: ref, pred: pred, calendar: r.Calendar, reads: readFields(r.RuleObject), writes: alertRuleWrites(r), stops: alertRuleStops(r)})
+		}
+	}
+	for _, r := range sortedByOrder(tagRules, func(r models.DbTagRule) int { return r.Order }) {
+		if !models.RuleEnabled(r.Enabled) {
+			continue
+		}
+		ref := RuleRef{Type: "tag", ID: r.ID, Name: r.RuleName, Order: r.Order}
+		if pred := compile(ref, r.RuleObject); pred != nil {
+			writers = append(writers, writer{ref: ref, pred: pred, calendar: r.Calendar, reads: readFields(r.RuleObject), writes: tagRuleWrites(r)})
+		}
+	}
+	for _, r := range notifyRules {
+		if models.RuleEnabled(r.Enabled) {
+			compile(RuleRef{Type: "notify", ID: r.ID, Name: r.RuleName, Order: r.Order}, r.RuleObject)
+		}
+	}
+	for _, r := range healRules {
+		if models.RuleEnabled(r.Enabled) {
+			compile(RuleRef{Type: "heal", ID: r.ID, Name: r.RuleName, Order: r.Order}, r.RuleObject)
+		}
+	}
+
+	findings = append(findings, writeFindings(writers)...)
+	findings = append(findings, correlationFindings(correlationRules)...)
+	return findings
+}
+
+// writeFindings compares each write with those of the rules after it; the
+// last write of a field wins. A rule with a stop action ends processing for
+// the alerts it matches, so rules after it are shadowed where it covers
+// them and cannot be proven to overwrite anything where it may overlap.
+func writeFindings(writers []writer) []Finding {
+	var findings []Finding
+	for j, late := range writers {
+		for _, early := range writers[:j] {
+			if early.stops && calendarCovers(early.calendar, late.calendar) && early.pred.Covers(late.pred) {
+				other := early.ref
+				findings = append(findings, Finding{Kind: FindingShadowed, Rule: late.ref, Other: &other,
+					Message: fmt.Sprintf("%s stops rule processing for every alert this rule matches", other)})
+				break
+			}
+		}
+	}
+	for i, early := range writers {
+		if early.stops {
+			// Nothing runs after it for the alerts it matches
+			continue
+		}
+		for _, w := range early.writes {
+			interrupted := false
+			for _, late := range writers[i+1:] {
+				lw, ok := writeOf(late, w.field)
+				// A later rule reading the field reacts to this write
+				// rather than simply replacing it
+				if late.reads[w.field] {
+					ok = false
+				}
+				if !ok {
+					if late.stops && stopsAfter(late, early, &interrupted) {
+						break
+					}
+					continue
+				}
+				same := w.constant && lw.constant && w.value == lw.value && w.removed == lw.removed
+				covers := !interrupted && lw.always && calendarCovers(late.calendar, early.calendar) && late.pred.Covers(early.pred)
+				other := late.ref
+				switch {
+				case covers && same:
+					findings = append(findings, Finding{Kind: FindingRedundant, Rule: early.ref, Other: &other, Field: w.field,
+						Message: fmt.Sprintf("%s matches every alert this rule matches and sets %s to the same value afterwards", other, w.field)})
+				case covers:
+					findings = append(findings, Finding{Kind: FindingShadowed, Rule: early.ref, Other: &other, Field: w.field,
+						Message: fmt.Sprintf("%s matches every alert this rule matches and overwrites %s afterwards", other, w.field)})
+				case !same && early.pred.MayOverlap(late.pred):
+					findings = append(findings, Finding{Kind: FindingConflict, Rule: early.ref, Other: &other, Field: w.field,
+						Message: fmt.Sprintf("for alerts matching both, %s runs later and its value of %s wins", other, w.field)})
+				}
+				// Later rules are compared with the one that took over
+				if covers || late.stops && stopsAfter(late, early, &interrupted) {
+					break
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// stopsAfter reports whether the stopping rule s ends processing for every
+// alert early matches; when it only may, interrupted is set.
+func stopsAfter(s, early writer, interrupted *bool) bool {
+	if calendarCovers(s.calendar, early.calendar) && s.pred.Covers(early.pred) {
+		return true
+	}
+	if s.pred.MayOverlap(early.pred) {
+		*interrupted = true
+	}
+	return false
+}
+
+func writeOf(w writer, field string) (write, bool) {
+	for _, x := range w.writes {
+		if x.field == field {
+			return x, true
+		}
+	}
+	return write{}, false
+}
+
+// alertRuleWrites lists the fields a rule's actions write; within the rule
+// the last action on a field wins. A summary is a constant only when its
+// template has no placeholders.
+func alertRuleWrites(r models.DbAlertRule) []write {
+	var out []write
+	put := func(w write) {
+		for i, x := range out {
+			if x.field == w.field {
+				out[i] = w
+				return
+			}
+		}
+		out = append(out, w)
+	}
+	for _, a := range r.RuleActions() {
+		switch a.Type {
+		case models.ActionSetField:
+			if models.CanonicalAlertField(a.Field) == "" && !strings.HasPrefix(strings.ToLower(a.Field), "additionaldetails.") {
+				continue
+			}
+			put(write{field: FieldKey(a.Field), value: a.Value, constant: true, always: true})
+		case models.ActionSetSeverity:
+			put(write{field: "severity", value: a.Value, constant: true, always: true})
+		case models.ActionSetPriority:
+			put(write{field: "alertpriority", value: a.Value, constant: true, always: true})
+		case models.ActionDrop:
+			put(write{field: "alertdropped", value: models.AlertDroppedValue, constant: true, always: true})
+		case models.ActionSetSummary:
+			constant := !strings.Contains(a.Template, "{{")
+			put(write{field: "alertsummary", value: a.Template, constant: constant, always: true})
+		case models.ActionAddTag:
+			put(write{field: FieldKey("additionaldetails." + a.Tag), value: a.Value, constant: true, always: true})
+		case models.ActionRemoveTag:
+			put(write{field: FieldKey("additionaldetails." + a.Tag), constant: true, always: true, removed: true})
+		}
+	}
+	return out
+}
+
+func alertRuleStops(r models.DbAlertRule) bool {
+	for _, a := range r.RuleActions() {
+		if a.Type == models.ActionStop {
+			return true
+		}
+	}
+	return false
+}
+
+// tagRuleWrites lists the tags a rule can set. Only a TagValue without a
+// FieldExtraction is written on every match; extractions depend on the
+// alert's values.
+func tagRuleWrites(r models.DbTagRule) []write {
+	var out []write
+	add := func(name string, w write) {
+		if name == "" {
+			return
+		}
+		w.field = FieldKey("additionaldetails." + name)
+		for _, x := range out {
+			if x.field == w.field {
+				return
+			}
+		}
+		out = append(out, w)
+	}
+	if r.TagName != "" && (r.FieldExtraction != "" || r.TagValue != "") {
+		constant := r.FieldExtraction == ""
+		add(r.TagName, write{value: r.TagValue, constant: constant, always: constant})
+	}
+	for _, x := range r.Extractions {
+		named := false
+		if re, err := regexp.Compile(x.Regex); err == nil && x.Regex != "" {
+			for _, name := range re.SubexpNames() {
+				if name != "" {
+					named = true
+					add(name, write{})
+				}
+			}
+		}
+		if !named {
+			add(x.TagName, write{})
+		}
+		for _, l := range x.Lookups {
+			add(l.As, write{})
+		}
+	}
+	return out
+}
+
+func readFields(ruleObject string) map[string]bool {
+	fields, _ := Fields(ruleObject)
+	out := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		out[FieldKey(f)] = true
+	}
+	return out
+}
+
+// correlationFindings reports correlation rules whose scopes nest, so that
+// alerts grouped by the narrower one are candidates for the other as well.
+// Only the first matching rule groups an alert, so a later rule is shadowed
+// when an earlier one of the same mode has a scope no stricter, a window no
+// shorter and grouping criteria no stricter.
+func correlationFindings(rules []models.DbCorrelationRule) []Finding {
+	type scoped struct {
+		rule  models.DbCorrelationRule
+		ref   RuleRef
+		scope map[string]bool
+	}
+	var active []scoped
+	for _, r := range sortedByOrder(rules, func(r models.DbCorrelationRule) int { return r.Order }) {
+		if !models.RuleEnabled(r.Enabled) || r.GroupWindow <= 0 {
+			continue
+		}
+		active = append(active, scoped{
+			rule:  r,
+			ref:   RuleRef{Type: "correlation", ID: r.ID, Name: r.GroupName, Order: r.Order},
+			scope: fieldSet(r.ScopeTags),
+		})
+	}
+
+	var findings []Finding
+	for j, late := range active {
+		for _, early := range active[:j] {
+			earlyWider := subset(early.scope, late.scope)
+			if !earlyWider && !subset(late.scope, early.scope) {
+				continue
+			}
+			other := early.ref
+			overlap := early.rule.GroupWindow
+			if late.rule.GroupWindow < overlap {
+				overlap = late.rule.GroupWindow
+			}
+			f := Finding{Kind: FindingScopeOverlap, Rule: late.ref, Other: &other, OverlapMinutes: overlap,
+				Message: fmt.Sprintf("%s (%s, %d min, scope %s) is tried first and can group the same alerts within %d minutes",
+					other, early.rule.CorrelationMode, early.rule.GroupWindow, scopeString(early.rule.ScopeTags), overlap)}
+			if earlyWider && early.rule.GroupWindow >= late.rule.GroupWindow && calendarCovers(early.rule.Calendar, late.rule.Calendar) && criteriaCover(early.rule, late.rule) {
+				f.Kind = FindingShadowed
+				f.Message = fmt.Sprintf("%s is tried first with a scope, window and criteria at least as broad, so this rule never groups an alert", other)
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// criteriaCover reports whether every pair of alerts late would group is
+// grouped by early too, scope and window aside.
+func criteriaCover(early, late models.DbCorrelationRule) bool {
+	if early.CorrelationMode != late.CorrelationMode {
+		return false
+	}
+	switch early.CorrelationMode {
+	case models.CorrelationModeSimilarity, models.CorrelationModeEmbedding:
+		ef, lf := fieldSet(early.Similarity.Fields), fieldSet(late.Similarity.Fields)
+		return subset(ef, lf) && subset(lf, ef) && early.Similarity.Threshold <= late.Similarity.Threshold &&
+			(early.CorrelationMode != models.CorrelationModeEmbedding || early.Similarity.CosineThreshold() <= late.Similarity.CosineThreshold()) &&
+			sameTextComparison(early.Similarity, late.Similarity)
+	case models.CorrelationModeTagBased:
+		return subset(fieldSet(early.GroupTags), fieldSet(late.GroupTags))
+	case models.CorrelationModeTopology:
+		if early.Topology == nil || late.Topology == nil || early.Topology.MaxHops < late.Topology.MaxHops {
+			return false
+		}
+		// Following any relationship covers following some
+		return len(early.Topology.RelationshipTypes) == 0 ||
+			len(late.Topology.RelationshipTypes) > 0 && subset(typeSet(late.Topology.RelationshipTypes), typeSet(early.Topology.RelationshipTypes))
+	}
+	return false
+}
+
+// sameTextComparison reports whether two similarity settings score alerts
+// alike: the same metric, normalization and weights.
+func sameTextComparison(a, b models.SimilarityConfig) bool {
+	metric := func(m string) string {
+		if m == "" {
+			return similarity.Jaccard
+		}
+		return m
+	}
+	if metric(a.Metric) != metric(b.Metric) || len(a.Weights) != len(b.Weights) {
+		return false
+	}
+	as, bs := typeSet(a.Normalize), typeSet(b.Normalize)
+	if !subset(as, bs) || !subset(bs, as) {
+		return false
+	}
+	for f, w := range a.Weights {
+		if x, ok := b.Weights[f]; !ok || x != w {
+			return false
+		}
+	}
+	return true
+}
+
+// calendarCovers reports whether a rule limited by outer is active whenever
+// one limited by inner is: outer has no calendar condition or the same one.
+func calendarCovers(outer, inner *models.CalendarCondition) bool {
+	if outer == nil || outer.Name == "" {
+		return true
+	}
+	return inner != nil && *outer == *inner
+}
+
+func fieldSet(names []string) map[string]bool {
+	out := make(map[string]bool, len(names))
+	for _, n := range names {
+		if strings.TrimSpace(n) != "" {
+			out[FieldKey(n)] = true
+		}
+	}
+	return out
+}
+
+// typeSet holds names compared exactly, such as Neo4j relationship types.
+func typeSet(names []string) map[string]bool {
+	out := make(map[string]bool, len(names))
+	for _, n := range names {
+		out[strings.TrimSpace(n)] = true
+	}
+	return out
+}
+
+func subset(a, b map[string]bool) bool {
+	for k := range a {
+		if !b[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func scopeString(tags []string) string {
+	if len(tags) == 0 {
+		return "global"
+	}
+	return "[" + strings.Join(tags, ", ") + "]"
+}
+
+func sortedByOrder[T any](rules []T, order func(T) int) []T {
+	sorted := append([]T(nil), rules...)
+	sort.SliceStable(sorted, func(i, j int) bool { return order(sorted[i]) < order(sorted[j]) })
+	return sorted
+}
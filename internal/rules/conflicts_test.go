@@ -0,0 +1,222 @@
+package rules
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// summarizeFindings renders findings as "kind rule<-other field", sorted.
+func summarizeFindings(findings []Finding) []string {
+	out := []string{}
+	for _, f := range findings {
+		s := f.Kind + " " + f.Rule.Name
+		if f.Other != nil {
+			s += "<-" + f.Other.Name
+		}
+		if f.Field != "" {
+			s += " " + f.Field
+		}
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func setPriority(name string, order int, ruleObject, value string) models.DbAlertRule {
+	return models.DbAlertRule{RuleName: name, Order: order, RuleObject: ruleObject,
+		Actions: []models.AlertAction{{Type: models.ActionSetPriority, Value: value}}}
+}
+
+func TestAnalyzeEnrichmentRules(t *testing.T) {
+	const (
+		critical = `{"rules":[{"field":"severity","operator":"=","value":"critical"}]}`
+		warn     = `{"rules":[{"field":"severity","operator":"=","value":"warn"}]}`
+		db       = `{"rules":[{"field":"entity","operator":"beginsWith","value":"db"}]}`
+	)
+	disabled := false
+	tests := []struct {
+		name  string
+		alert []models.DbAlertRule
+		tag   []models.DbTagRule
+		want  []string
+	}{
+		{
+			name:  "independent rules",
+			alert: []models.DbAlertRule{setPriority("a", 1, critical, "P1"), setPriority("b", 2, warn, "P3")},
+			want:  []string{},
+		},
+		{
+			name:  "overwritten by a broader rule",
+			alert: []models.DbAlertRule{setPriority("a", 1, critical, "P1"), setPriority("b", 2, ``, "P3")},
+			want:  []string{"shadowed a<-b alertpriority"},
+		},
+		{
+			name:  "same value written again",
+			alert: []models.DbAlertRule{setPriority("a", 1, critical, "P1"), setPriority("b", 2, ``, "P1")},
+			want:  []string{"redundant a<-b alertpriority"},
+		},
+		{
+			name:  "overlapping rules disagree",
+			alert: []models.DbAlertRule{setPriority("a", 1, critical, "P1"), setPriority("b", 2, db, "P2")},
+			want:  []string{"conflict a<-b alertpriority"},
+		},
+		{
+			name:  "order decides which rule is first",
+			alert: []models.DbAlertRule{setPriority("b", 2, ``, "P3"), setPriority("a", 1, critical, "P1")},
+			want:  []string{"shadowed a<-b alertpriority"},
+		},
+		{
+			name: "a later rule reading the field reacts to it",
+			alert: []models.DbAlertRule{
+				setPriority("a", 1, critical, "P1"),
+				setPriority("b", 2, `{"rules":[{"field":"priority","operator":"=","value":"P1"}]}`, "P0"),
+			},
+			want: []string{},
+		},
+		{
+			name: "a stop shadows what it covers",
+			alert: []models.DbAlertRule{
+				{RuleName: "stop", Order: 1, RuleObject: critical, Actions: []models.AlertAction{{Type: models.ActionStop}}},
+				setPriority("after", 2, `{"rules":[{"field":"severity","operator":"=","value":"critical"},{"field":"entity","operator":"=","value":"db01"}]}`, "P1"),
+			},
+			want: []string{"shadowed after<-stop"},
+		},
+		{
+			name: "a stop in between hides the overwrite",
+			alert: []models.DbAlertRule{
+				setPriority("a", 1, critical, "P1"),
+				{RuleName: "stop", Order: 2, RuleObject: db, Actions: []models.AlertAction{{Type: models.ActionStop}}},
+				setPriority("b", 3, ``, "P3"),
+			},
+			want: []string{"conflict a<-b alertpriority"},
+		},
+		{
+			name: "disabled rules are skipped",
+			alert: []models.DbAlertRule{
+				setPriority("a", 1, critical, "P1"),
+				{RuleName: "b", Order: 2, Enabled: &disabled, Actions: []models.AlertAction{{Type: models.ActionSetPriority, Value: "P3"}}},
+			},
+			want: []string{},
+		},
+		{
+			name: "invalid and unsatisfiable conditions",
+			alert: []models.DbAlertRule{
+				setPriority("invalid", 1, `{"rules":[{"field":"entity","operator":"like","value":"x"}]}`, "P1"),
+				setPriority("never", 2, `{"rules":[{"field":"severity","operator":"=","value":"critical"},{"field":"severity","operator":"=","value":"warn"}]}`, "P1"),
+			},
+			want: []string{"invalid invalid", "unsatisfiable never"},
+		},
+		{
+			name:  "tag rule overwrites an alert rule tag",
+			alert: []models.DbAlertRule{{RuleName: "a", Order: 1, RuleObject: critical, Actions: []models.AlertAction{{Type: models.ActionAddTag, Tag: "team", Value: "dba"}}}},
+			tag:   []models.DbTagRule{{RuleName: "t", TagName: "team", TagValue: "ops"}},
+			want:  []string{"shadowed a<-t additionaldetails.team"},
+		},
+		{
+			name:  "extracted tags may not be written",
+			alert: []models.DbAlertRule{{RuleName: "a", Order: 1, Actions: []models.AlertAction{{Type: models.ActionAddTag, Tag: "host", Value: "x"}}}},
+			tag:   []models.DbTagRule{{RuleName: "t", TagName: "host", FieldName: "entity", FieldExtraction: `^(\w+)`}},
+			want:  []string{"conflict a<-t additionaldetails.host"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizeFindings(Analyze(tt.alert, tt.tag, nil, nil, nil))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeCorrelationRules(t *testing.T) {
+	tagBased := func(name string, order, window int, scope, group []string) models.DbCorrelationRule {
+		return models.DbCorrelationRule{GroupName: name, Order: order, GroupWindow: window,
+			CorrelationMode: models.CorrelationModeTagBased, ScopeTags: scope, GroupTags: group}
+	}
+	tests := []struct {
+		name  string
+		rules []models.DbCorrelationRule
+		want  []string
+	}{
+		{
+			name: "disjoint scopes",
+			rules: []models.DbCorrelationRule{
+				tagBased("a", 1, 10, []string{"region"}, []string{"entity"}),
+				tagBased("b", 2, 10, []string{"service"}, []string{"entity"}),
+			},
+			want: []string{},
+		},
+		{
+			name: "broader rule first",
+			rules: []models.DbCorrelationRule{
+				tagBased("a", 1, 30, nil, []string{"entity"}),
+				tagBased("b", 2, 10, []string{"region"}, []string{"entity", "alerttype"}),
+			},
+			want: []string{"shadowed b<-a"},
+		},
+		{
+			name: "shorter window first",
+			rules: []models.DbCorrelationRule{
+				tagBased("a", 1, 5, nil, []string{"entity"}),
+				tagBased("b", 2, 10, []string{"region"}, []string{"entity"}),
+			},
+			want: []string{"scope_overlap b<-a"},
+		},
+		{
+			name: "different modes",
+			rules: []models.DbCorrelationRule{
+				tagBased("a", 1, 30, nil, []string{"entity"}),
+				{GroupName: "b", Order: 2, GroupWindow: 10, CorrelationMode: models.CorrelationModeSimilarity,
+					Similarity: models.SimilarityConfig{Fields: []string{"summary"}, Threshold: 0.8}},
+			},
+			want: []string{"scope_overlap b<-a"},
+		},
+		{
+			name: "similarity with a lower threshold first",
+			rules: []models.DbCorrelationRule{
+				{GroupName: "a", Order: 1, GroupWindow: 30, CorrelationMode: models.CorrelationModeSimilarity,
+					Similarity: models.SimilarityConfig{Fields: []string{"summary"}, Threshold: 0.6}},
+				{GroupName: "b", Order: 2, GroupWindow: 10, CorrelationMode: models.CorrelationModeSimilarity,
+					Similarity: models.SimilarityConfig{Fields: []string{"alertsummary"}, Threshold: 0.8, Metric: "jaccard"}},
+			},
+			want: []string{"shadowed b<-a"},
+		},
+		{
+			name: "embedding with a higher cosine threshold first",
+			rules: []models.DbCorrelationRule{
+				{GroupName: "a", Order: 1, GroupWindow: 30, CorrelationMode: models.CorrelationModeEmbedding,
+					Similarity: models.SimilarityConfig{Fields: []string{"summary"}, Threshold: 0.5, EmbeddingThreshold: 0.9}},
+				{GroupName: "b", Order: 2, GroupWindow: 10, CorrelationMode: models.CorrelationModeEmbedding,
+					Similarity: models.SimilarityConfig{Fields: []string{"summary"}, Threshold: 0.5, EmbeddingThreshold: 0.8}},
+			},
+			want: []string{"scope_overlap b<-a"},
+		},
+		{
+			name: "no window means the rule is off",
+			rules: []models.DbCorrelationRule{
+				tagBased("a", 1, 0, nil, []string{"entity"}),
+				tagBased("b", 2, 10, nil, []string{"entity"}),
+			},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := Analyze(nil, nil, nil, nil, tt.rules)
+			got := summarizeFindings(findings)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings %v, want %v", got, tt.want)
+			}
+			for _, f := range findings {
+				if f.Kind == FindingScopeOverlap && (f.OverlapMinutes == 0 || !strings.Contains(f.Message, "minutes")) {
+					t.Errorf("scope overlap without its window: %+v", f)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Change records one field written by a rule.
+type Change struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// Result describes how one rule treated an alert.
+type Result struct {
+	RuleType string             `json:"rule_type"` // alert | tag
+	RuleID   primitive.ObjectID `json:"rule_id"`
+	RuleName string             `json:"rule_name"`
+	Order    int                `json:"order"`
+	Matched  bool               `json:"matched"`
+	Changes  []Change           `json:"changes,omitempty"`
+	Calendar *Window            `json:"calendar,omitempty"` // set for rules with a calendar condition
+	Stopped  bool               `json:"stopped,omitempty"`  // a stop action ended rule processing
+	Error    string             `json:"error,omitempty"`
+}
+
+// err is set when the rule failed to compile; such rules never match
+type alertRule struct {
+	rule models.DbAlertRule
+	pred *Predicate
+	err  error
+}
+
+type tagRule struct {
+	rule        models.DbTagRule
+	pred        *Predicate
+	re          *regexp.Regexp
+	extractions []extraction
+	err         error
+}
+
+// Enricher applies alert rules and then tag rules to incoming alerts, each
+// set in Order sequence.
+type Enricher struct {
+	alertRules []alertRule
+	tagRules   []tagRule
+	calendars  Calendars
+}
+
+// NewEnricher compiles the enabled rules against the lookup tables tag rules
+// may refer to and the calendars rules may be limited to. Rules that fail to
+// compile are reported in the returned errors and never match; the rest
+// still apply.
+func NewEnricher(alertRules []models.DbAlertRule, tagRules []models.DbTagRule, tables []models.DbLookupTable, calendars Calendars) (*Enricher, []error) {
+	e := &Enricher{calendars: calendars}
+	var errs []error
+
+	lookups := make(map[string]*Lookup, len(tables))
+	for _, t := range tables {
+		lookups[t.Name] = NewLookup(t)
+	}
+
+	sorted := append([]models.DbAlertRule(nil), alertRules...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Order < sorted[j].Order })
+	for _, r := range sorted {
+		if !models.RuleEnabled(r.Enabled) {
+			continue
+		}
+		pred, err := Compile(r.RuleObject)
+		if err == nil {
+			err = calendars.Known(r.Calendar)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("alert rule %q: %v", r.RuleName, err))
+		}
+		e.alertRules = append(e.alertRules, alertRule{rule: r, pred: pred, err: err})
+	}
+
+	sortedTags := append([]models.DbTagRule(nil), tagRules...)
+	sort.SliceStable(sortedTags, func(i, j int) bool { return sortedTags[i].Order < sortedTags[j].Order })
+	for _, r := range sortedTags {
+		if (r.TagName == "" && len(r.Extractions) == 0) || !models.RuleEnabled(r.Enabled) {
+			continue
+		}
+		t := tagRule{rule: r}
+		t.pred, t.err = Compile(r.RuleObject)
+		if t.err == nil {
+			t.err = calendars.Known(r.Calendar)
+		}
+		if t.err == nil && r.FieldExtraction != "" {
+			if t.re, t.err = regexp.Compile(r.FieldExtraction); t.err != nil {
+				t.err = fmt.Errorf("invalid extraction regex: %v", t.err)
+			}
+		}
+		for i, spec := range r.Extractions {
+			if t.err != nil {
+				break
+			}
+			x, err := compileExtraction(spec, lookups)
+			if err != nil {
+				t.err = fmt.Errorf("extraction %d: %v", i+1, err)
+			}
+			t.extractions = append(t.extractions, x)
+		}
+		if t.err != nil {
+			errs = append(errs, fmt.Errorf("tag rule %q: %v", r.RuleName, t.err))
+		}
+		e.tagRules = append(e.tagRules, t)
+	}
+	return e, errs
+}
+
+// Apply runs the rules against alert, modifying it in place, and returns
+// what each rule did. A rule with a stop action is the last one to run.
+func (e *Enricher) Apply(alert *models.DbAlert) []Result {
+	return e.ApplyAt(alert, time.Now())
+}
+
+// ApplyAt is Apply with calendar conditions evaluated at the given time.
+func (e *Enricher) ApplyAt(alert *models.DbAlert, at time.Time) []Result {
+	results := make([]Result, 0, len(e.alertRules)+len(e.tagRules))
+	for _, r := range e.alertRules {
+		res := e.applyAlertRule(r, alert, at)
+		results = append(results, res)
+		if res.Stopped {
+			return results
+		}
+	}
+	for _, t := range e.tagRules {
+		results = append(results, e.applyTagRule(t, alert, at))
+	}
+	return results
+}
+
+// active evaluates a calendar condition at at and records the window in
+// res; rules outside their window are skipped.
+func (e *Enricher) active(cond *models.CalendarCondition, at time.Time, res *Result) bool {
+	w, err := e.calendars.Check(cond, at)
+	if err != nil {
+		res.Error = err.Error()
+		return false
+	}
+	res.Calendar = w
+	return w == nil || w.Active
+}
+
+// applyAlertRule runs the rule's actions in order when the condition
+// matches. A failing action is reported and the others still run; a stop
+// action takes effect once the rule is done.
+func (e *Enricher) applyAlertRule(r alertRule, alert *models.DbAlert, at time.Time) Result {
+	res := Result{RuleType: "alert", RuleID: r.rule.ID, RuleName: r.rule.RuleName, Order: r.rule.Order}
+	if r.err != nil {
+		res.Error = r.err.Error()
+		return res
+	}
+	if !e.active(r.rule.Calendar, at, &res) || !r.pred.Match(alert) {
+		return res
+	}
+	res.Matched = true
+
+	for i, a := range r.rule.RuleActions() {
+		if a.Type == models.ActionStop {
+			res.Stopped = true
+			continue
+		}
+		change, err := applyAction(a, alert)
+		if err != nil {
+			if res.Error == "" {
+				res.Error = fmt.Sprintf("action %d (%s): %v", i+1, a.Type, err)
+			}
+			continue
+		}
+		if change != nil {
+			res.Changes = append(res.Changes, *change)
+		}
+	}
+	return res
+}
+
+// applyAction runs one action other than stop and returns what it changed.
+func applyAction(a models.AlertAction, alert *models.DbAlert) (*Change, error) {
+	switch a.Type {
+	case models.ActionSetField:
+		return setAlertField(alert, a.Field, a.Value)
+	case models.ActionSetSeverity:
+		return setAlertField(alert, "severity", a.Value)
+	case models.ActionSetPriority:
+		return setAlertField(alert, "alertpriority", a.Value)
+	case models.ActionDrop:
+		return setAlertField(alert, "alertdropped", models.AlertDroppedValue)
+	case models.ActionSetSummary:
+		summary, err := RenderTemplate(a.Template, alert)
+		if err != nil {
+			return nil, err
+		}
+		return setAlertField(alert, "alertsummary", summary)
+	case models.ActionAddTag:
+		old, _ := alert.DetailValue(a.Tag)
+		alert.SetDetail(a.Tag, a.Value)
+		return &Change{Field: "additionaldetails." + a.Tag, Old: old, New: a.Value}, nil
+	case models.ActionRemoveTag:
+		old, ok := alert.AdditionalDetails[a.Tag]
+		if !ok {
+			return nil, nil
+		}
+		delete(alert.AdditionalDetails, a.Tag)
+		return &Change{Field: "additionaldetails." + a.Tag, Old: old}, nil
+	}
+	return nil, fmt.Errorf("unknown action type %q", a.Type)
+}
+
+func setAlertField(alert *models.DbAlert, field, value string) (*Change, error) {
+	old, _ := alert.FieldValue(field)
+	if err := alert.SetField(field, value); err != nil {
+		return nil, err
+	}
+	updated, _ := alert.FieldValue(field)
+	return &Change{Field: field, Old: old, New: updated}, nil
+}
+
+// applyTagRule stores the extracted tags in AdditionalDetails: TagName first,
+// then the tags of every extraction. The rule matched when it set any tag.
+func (e *Enricher) applyTagRule(t tagRule, alert *models.DbAlert, at time.Time) Result {
+	res := Result{RuleType: "tag", RuleID: t.rule.ID, RuleName: t.rule.RuleName, Order: t.rule.Order}
+	if t.err != nil {
+		res.Error = t.err.Error()
+		return res
+	}
+	if !e.active(t.rule.Calendar, at, &res) || !t.pred.Match(alert) {
+		return res
+	}
+
+	// Tags are set as they are produced, so an extraction can read the
+	// tags of the ones before it
+	set := func(tags []tag) {
+		for _, tg := range tags {
+			old, _ := alert.DetailValue(tg.name)
+			alert.SetDetail(tg.name, tg.value)
+			res.Changes = append(res.Changes, Change{Field: "additionaldetails." + tg.name, Old: old, New: tg.value})
+		}
+	}
+	if t.rule.TagName != "" {
+		if value, ok := extractTag(t, alert); ok {
+			set([]tag{{t.rule.TagName, value}})
+		}
+	}
+	for _, x := range t.extractions {
+		set(x.tags(alert))
+	}
+	res.Matched = len(res.Changes) > 0
+	return res
+}
+
+// extractTag computes the tag value. With a FieldExtraction regex the tag is
+// only set when it matches FieldName; TagValue may then use $1 / ${name} to
+// refer to capture groups, and defaults to the first group.
+func extractTag(t tagRule, alert *models.DbAlert) (string, bool) {
+	if t.re == nil {
+		return t.rule.TagValue, t.rule.TagValue != ""
+	}
+
+	raw, _ := alert.FieldValue(t.rule.FieldName)
+	source := valueString(raw)
+	match := t.re.FindStringSubmatchIndex(source)
+	if match == nil {
+		return "", false
+	}
+	if t.rule.TagValue != "" {
+		return string(t.re.ExpandString(nil, t.rule.TagValue, source, match)), true
+	}
+	if len(match) >= 4 && match[2] >= 0 {
+		return source[match[2]:match[3]], true
+	}
+	return source[match[0]:match[1]], true
+}
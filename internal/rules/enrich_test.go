@@ -0,0 +1,222 @@
+package rules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+const matchCritical = `{"rules":[{"field":"severity","operator":"=","value":"critical"}]}`
+
+func TestEnricherAlertRules(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name    string
+		rules   []models.DbAlertRule
+		check   func(*models.DbAlert) string
+		matched []string
+		errs    int
+	}{
+		{
+			name: "actions in order",
+			rules: []models.DbAlertRule{{RuleName: "r", RuleObject: matchCritical, Actions: []models.AlertAction{
+				{Type: models.ActionSetPriority, Value: "P1"},
+				{Type: models.ActionAddTag, Tag: "team", Value: "dba"},
+				{Type: models.ActionSetSummary, Template: "{{.Entity}}: {{.AlertSummary}} ({{.AdditionalDetails.team}})"},
+				{Type: models.ActionRemoveTag, Tag: "region"},
+			}}},
+			check: func(a *models.DbAlert) string {
+				if a.AlertPriority != "P1" || a.AlertSummary != "db01.prod: Disk /var at 91% (dba)" {
+					return a.AlertPriority + " " + a.AlertSummary
+				}
+				if _, ok := a.AdditionalDetails["region"]; ok {
+					return "region not removed"
+				}
+				return ""
+			},
+			matched: []string{"r"},
+		},
+		{
+			name: "rules run by order",
+			rules: []models.DbAlertRule{
+				{RuleName: "second", Order: 2, Actions: []models.AlertAction{{Type: models.ActionSetSeverity, Value: "WARN"}}},
+				{RuleName: "first", Order: 1, RuleObject: matchCritical, Actions: []models.AlertAction{{Type: models.ActionAddTag, Tag: "seen", Value: "critical"}}},
+			},
+			check: func(a *models.DbAlert) string {
+				if a.Severity != "WARN" || a.AdditionalDetails["seen"] != "critical" {
+					return a.Severity
+				}
+				return ""
+			},
+			matched: []string{"first", "second"},
+		},
+		{
+			name: "later rules see earlier changes",
+			rules: []models.DbAlertRule{
+				{RuleName: "downgrade", Order: 1, Actions: []models.AlertAction{{Type: models.ActionSetSeverity, Value: "INFO"}}},
+				{RuleName: "critical only", Order: 2, RuleObject: matchCritical, Actions: []models.AlertAction{{Type: models.ActionDrop}}},
+			},
+			check: func(a *models.DbAlert) string {
+				if a.Dropped() {
+					return "dropped"
+				}
+				return ""
+			},
+			matched: []string{"downgrade"},
+		},
+		{
+			name: "stop ends processing after the rule",
+			rules: []models.DbAlertRule{
+				{RuleName: "stop", Order: 1, Actions: []models.AlertAction{{Type: models.ActionStop}, {Type: models.ActionDrop}}},
+				{RuleName: "never", Order: 2, Actions: []models.AlertAction{{Type: models.ActionSetPriority, Value: "P5"}}},
+			},
+			check: func(a *models.DbAlert) string {
+				if !a.Dropped() || a.AlertPriority == "P5" {
+					return "stop did not apply"
+				}
+				return ""
+			},
+			matched: []string{"stop"},
+		},
+		{
+			name:  "legacy setfield",
+			rules: []models.DbAlertRule{{RuleName: "legacy", SetField: "additionaldetails.owner", SetValue: "ops"}},
+			check: func(a *models.DbAlert) string {
+				if a.AdditionalDetails["owner"] != "ops" {
+					return "owner not set"
+				}
+				return ""
+			},
+			matched: []string{"legacy"},
+		},
+		{
+			name: "disabled and broken rules are skipped",
+			rules: []models.DbAlertRule{
+				{RuleName: "disabled", Enabled: &disabled, Actions: []models.AlertAction{{Type: models.ActionDrop}}},
+				{RuleName: "broken", RuleObject: `{"rules":[{"field":"entity","operator":"like"}]}`, Actions: []models.AlertAction{{Type: models.ActionDrop}}},
+				{RuleName: "ok", Actions: []models.AlertAction{{Type: models.ActionSetPriority, Value: "P2"}}},
+			},
+			check: func(a *models.DbAlert) string {
+				if a.Dropped() || a.AlertPriority != "P2" {
+					return "wrong rules applied"
+				}
+				return ""
+			},
+			matched: []string{"ok"},
+			errs:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, errs := NewEnricher(tt.rules, nil, nil, nil)
+			if len(errs) != tt.errs {
+				t.Fatalf("NewEnricher errors = %v, want %d", errs, tt.errs)
+			}
+			alert := testAlert()
+			var matched []string
+			for _, res := range e.Apply(alert) {
+				if res.Matched {
+					matched = append(matched, res.RuleName)
+				}
+			}
+			if !reflect.DeepEqual(matched, tt.matched) {
+				t.Errorf("matched %v, want %v", matched, tt.matched)
+			}
+			if msg := tt.check(alert); msg != "" {
+				t.Error(msg)
+			}
+		})
+	}
+}
+
+func TestEnricherTagRules(t *testing.T) {
+	tables := []models.DbLookupTable{{
+		Name:            "datacenters",
+		Match:           models.LookupMatchPrefix,
+		CaseInsensitive: true,
+		Entries:         []models.LookupEntry{{Key: "eu", Value: "Europe"}, {Key: "eu-west", Value: "Dublin"}},
+	}}
+	tests := []struct {
+		name string
+		rule models.DbTagRule
+		want map[string]interface{}
+	}{
+		{
+			name: "fixed value",
+			rule: models.DbTagRule{TagName: "tier", TagValue: "db", RuleObject: matchCritical},
+			want: map[string]interface{}{"tier": "db"},
+		},
+		{
+			name: "first group",
+			rule: models.DbTagRule{TagName: "host", FieldName: "entity", FieldExtraction: `^(\w+)\.`},
+			want: map[string]interface{}{"host": "db01"},
+		},
+		{
+			name: "expanded value",
+			rule: models.DbTagRule{TagName: "mount", FieldName: "summary", FieldExtraction: `Disk (?P<path>\S+) at (\d+)`, TagValue: "${path}=$2"},
+			want: map[string]interface{}{"mount": "/var=91"},
+		},
+		{
+			name: "no match sets nothing",
+			rule: models.DbTagRule{TagName: "host", FieldName: "entity", FieldExtraction: `^web`},
+			want: map[string]interface{}{},
+		},
+		{
+			name: "named groups and lookup",
+			rule: models.DbTagRule{Extractions: []models.TagExtraction{
+				{FieldName: "region", Regex: `^(?P<zone>[a-z]+-[a-z]+)`, Lookups: []models.TagLookup{{Tag: "zone", Table: "datacenters", As: "site"}}},
+			}},
+			want: map[string]interface{}{"zone": "eu-west", "site": "Dublin"},
+		},
+		{
+			name: "jsonpath sees earlier extractions",
+			rule: models.DbTagRule{Extractions: []models.TagExtraction{
+				{JSONPath: "$.k8s.namespace", TagName: "ns"},
+				{FieldName: "ns", Regex: `^(pay)`, TagName: "domain"},
+			}},
+			want: map[string]interface{}{"ns": "payments", "domain": "pay"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, errs := NewEnricher(nil, []models.DbTagRule{tt.rule}, tables, nil)
+			if len(errs) > 0 {
+				t.Fatalf("NewEnricher: %v", errs)
+			}
+			alert := testAlert()
+			before := map[string]bool{}
+			for k := range alert.AdditionalDetails {
+				before[k] = true
+			}
+			results := e.Apply(alert)
+			got := map[string]interface{}{}
+			for k, v := range alert.AdditionalDetails {
+				if !before[k] {
+					got[k] = v
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tags %v, want %v", got, tt.want)
+			}
+			if matched := results[0].Matched; matched != (len(tt.want) > 0) {
+				t.Errorf("Matched = %v with tags %v", matched, got)
+			}
+		})
+	}
+}
+
+func TestEnricherUnknownLookupTable(t *testing.T) {
+	rule := models.DbTagRule{RuleName: "sites", Extractions: []models.TagExtraction{
+		{FieldName: "region", TagName: "zone", Lookups: []models.TagLookup{{Tag: "zone", Table: "missing"}}},
+	}}
+	e, errs := NewEnricher(nil, []models.DbTagRule{rule}, nil, nil)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), `unknown lookup table "missing"`) {
+		t.Fatalf("NewEnricher errors = %v", errs)
+	}
+	res := e.Apply(testAlert())
+	if res[0].Matched || res[0].Error == "" {
+		t.Errorf("result = %+v, want an unmatched rule with an error", res[0])
+	}
+}
@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/jsonpath"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// extraction is a compiled TagExtraction.
+type extraction struct {
+	spec    models.TagExtraction
+	path    *jsonpath.Path
+	re      *regexp.Regexp
+	lookups []*Lookup // parallel to spec.Lookups
+}
+
+// tag is one extracted tag; extractions keep them in the order produced.
+type tag struct {
+	name  string
+	value string
+}
+
+func compileExtraction(spec models.TagExtraction, tables map[string]*Lookup) (extraction, error) {
+	x := extraction{spec: spec}
+	var err error
+	if spec.JSONPath != "" {
+		if x.path, err = jsonpath.Compile(spec.JSONPath); err != nil {
+			return x, err
+		}
+	}
+	if spec.Regex != "" {
+		if x.re, err = regexp.Compile(spec.Regex); err != nil {
+			return x, fmt.Errorf("invalid extraction regex: %v", err)
+		}
+	}
+	for _, l := range spec.Lookups {
+		table, ok := tables[l.Table]
+		if !ok {
+			return x, fmt.Errorf("unknown lookup table %q", l.Table)
+		}
+		x.lookups = append(x.lookups, table)
+	}
+	return x, nil
+}
+
+// tags returns what the extraction yields for alert, nothing when the source
+// is missing or the regex does not match.
+func (x extraction) tags(alert *models.DbAlert) []tag {
+	var raw interface{}
+	var ok bool
+	if x.path != nil {
+		raw, ok = x.path.First(plainJSON(alert.AdditionalDetails))
+	} else {
+		raw, ok = alert.FieldValue(x.spec.FieldName)
+	}
+	if !ok {
+		return nil
+	}
+	source := valueString(raw)
+
+	var out []tag
+	if x.re == nil {
+		if source != "" {
+			out = append(out, tag{x.spec.TagName, source})
+		}
+	} else {
+		match := x.re.FindStringSubmatchIndex(source)
+		if match == nil {
+			return nil
+		}
+		for i, name := range x.re.SubexpNames() {
+			if name != "" && match[2*i] >= 0 {
+				out = append(out, tag{name, source[match[2*i]:match[2*i+1]]})
+			}
+		}
+		if len(out) == 0 && x.spec.TagName != "" {
+			value := source[match[0]:match[1]]
+			if len(match) >= 4 && match[2] >= 0 {
+				value = source[match[2]:match[3]]
+			}
+			out = append(out, tag{x.spec.TagName, value})
+		}
+	}
+
+	for i, l := range x.spec.Lookups {
+		for _, t := range out {
+			if t.name != l.Tag {
+				continue
+			}
+			if mapped, ok := x.lookups[i].Find(t.value); ok {
+				target := l.As
+				if target == "" {
+					target = l.Tag
+				}
+				out = setTag(out, target, mapped)
+			}
+			break
+		}
+	}
+	return out
+}
+
+func setTag(tags []tag, name, value string) []tag {
+	for i := range tags {
+		if tags[i].name == name {
+			tags[i].value = value
+			return tags
+		}
+	}
+	return append(tags, tag{name, value})
+}
+
+// plainJSON converts the BSON documents and arrays of alerts read back from
+// MongoDB into the map and slice types jsonpath walks.
+func plainJSON(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, child := range x {
+			out[k] = plainJSON(child)
+		}
+		return out
+	case primitive.M:
+		return plainJSON(map[string]interface{}(x))
+	case primitive.D:
+		return plainJSON(map[string]interface{}(x.Map()))
+	case primitive.A:
+		return plainJSON([]interface{}(x))
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, child := range x {
+			out[i] = plainJSON(child)
+		}
+		return out
+	}
+	return v
+}
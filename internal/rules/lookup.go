@@ -0,0 +1,58 @@
+package rules
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// Lookup is a lookup table prepared for matching.
+type Lookup struct {
+	table models.DbLookupTable
+	exact map[string]string
+	keys  []models.LookupEntry // prefix mode, longest key first
+}
+
+// NewLookup indexes the entries of t. Later entries win over earlier ones
+// with the same key.
+func NewLookup(t models.DbLookupTable) *Lookup {
+	l := &Lookup{table: t, exact: make(map[string]string, len(t.Entries))}
+	for _, e := range t.Entries {
+		l.exact[l.fold(e.Key)] = e.Value
+	}
+	if t.Match == models.LookupMatchPrefix {
+		for k, v := range l.exact {
+			l.keys = append(l.keys, models.LookupEntry{Key: k, Value: v})
+		}
+		sort.Slice(l.keys, func(i, j int) bool {
+			if len(l.keys[i].Key) != len(l.keys[j].Key) {
+				return len(l.keys[i].Key) > len(l.keys[j].Key)
+			}
+			return l.keys[i].Key < l.keys[j].Key
+		})
+	}
+	return l
+}
+
+func (l *Lookup) fold(s string) string {
+	if l.table.CaseInsensitive {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+// Find returns the value mapped to key, falling back to the table default.
+func (l *Lookup) Find(key string) (string, bool) {
+	key = l.fold(key)
+	if l.table.Match == models.LookupMatchPrefix {
+		for _, e := range l.keys {
+			if strings.HasPrefix(key, e.Key) {
+				return e.Value, true
+			}
+		}
+	} else if v, ok := l.exact[key]; ok {
+		return v, true
+	}
+	return l.table.Default, l.table.Default != ""
+}
@@ -0,0 +1,356 @@
+// Package rules evaluates the RuleObject conditions stored on alert, tag,
+// heal and notify rules and applies the enrichment actions of the rules
+// that match.
+//
+// A RuleObject is the JSON produced by react-querybuilder:
+//
+//	{"combinator":"and","not":false,"rules":[
+//	  {"field":"Entity","operator":"=","value":"db01"},
+//	  {"combinator":"or","rules":[...]}]}
+//
+// Fields name DbAlert fields (case-insensitive, short aliases such as
+// "summary" allowed) or AdditionalDetails keys.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// node is one element of a RuleObject: a group when Rules/Combinator is set,
+// otherwise a single comparison.
+type node struct {
+	ID         string      `json:"id,omitempty"`
+	Combinator string      `json:"combinator,omitempty"`
+	Not        bool        `json:"not,omitempty"`
+	Rules      []node      `json:"rules,omitempty"`
+	Field      string      `json:"field,omitempty"`
+	Operator   string      `json:"operator,omitempty"`
+	Value      interface{} `json:"value,omitempty"`
+}
+
+func (n node) isGroup() bool {
+	return n.Combinator != "" || n.Rules != nil
+}
+
+// Predicate is a compiled RuleObject.
+type Predicate struct {
+	root condition
+}
+
+// condition is a compiled group or comparison.
+type condition interface {
+	match(alert *models.DbAlert) bool
+}
+
+// Compile parses a RuleObject. An empty string, "{}" or an empty group
+// compile to a predicate matching every alert.
+func Compile(ruleObject string) (*Predicate, error) {
+	s := strings.TrimSpace(ruleObject)
+	if s == "" {
+		return &Predicate{root: group{and: true}}, nil
+	}
+	var root node
+	if err := json.Unmarshal([]byte(s), &root); err != nil {
+		return nil, fmt.Errorf("invalid rule object: %v", err)
+	}
+	if !root.isGroup() && root.Field == "" {
+		return &Predicate{root: group{and: true}}, nil
+	}
+	cond, err := compileNode(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Predicate{root: cond}, nil
+}
+
+// Match reports whether alert satisfies the predicate.
+func (p *Predicate) Match(alert *models.DbAlert) bool {
+	return p.root.match(alert)
+}
+
+// Fields lists the fields the predicate refers to, in order of appearance.
+func Fields(ruleObject string) ([]string, error) {
+	var root node
+	if s := strings.TrimSpace(ruleObject); s != "" {
+		if err := json.Unmarshal([]byte(s), &root); err != nil {
+			return nil, fmt.Errorf("invalid rule object: %v", err)
+		}
+	}
+	var fields []string
+	var walk func(n node)
+	walk = func(n node) {
+		if n.Field != "" {
+			fields = append(fields, n.Field)
+		}
+		for _, child := range n.Rules {
+			walk(child)
+		}
+	}
+	walk(root)
+	return fields, nil
+}
+
+func compileNode(n node) (condition, error) {
+	if !n.isGroup() {
+		return compileComparison(n)
+	}
+
+	g := group{not: n.Not}
+	switch strings.ToLower(n.Combinator) {
+	case "", "and":
+		g.and = true
+	case "or":
+	default:
+		return nil, fmt.Errorf("unknown combinator %q", n.Combinator)
+	}
+	for _, child := range n.Rules {
+		cond, err := compileNode(child)
+		if err != nil {
+			return nil, err
+		}
+		g.children = append(g.children, cond)
+	}
+	return g, nil
+}
+
+type group struct {
+	and      bool
+	not      bool
+	children []condition
+}
+
+func (g group) match(alert *models.DbAlert) bool {
+	// Empty groups match, as in react-querybuilder's own query formatting
+	result := g.and || len(g.children) == 0
+	for _, child := range g.children {
+		m := child.match(alert)
+		if g.and && !m {
+			result = false
+			break
+		}
+		if !g.and && m {
+			result = true
+			break
+		}
+	}
+	if g.not {
+		return !result
+	}
+	return result
+}
+
+// operator kinds; the many spellings accepted for each are listed in operators
+type opKind int
+
+const (
+	opEquals opKind = iota
+	opContains
+	opBeginsWith
+	opEndsWith
+	opRegex
+	opIn
+	opGreater
+	opGreaterEqual
+	opLess
+	opLessEqual
+	opBetween
+	opExists
+)
+
+// operators maps the react-querybuilder operator names and their common
+// aliases onto an operator kind and whether it is negated.
+var operators = map[string]struct {
+	kind    opKind
+	negated bool
+}{
+	"=": {opEquals, false}, "==": {opEquals, false}, "equals": {opEquals, false}, "eq": {opEquals, false},
+	"!=": {opEquals, true}, "notequals": {opEquals, true}, "ne": {opEquals, true},
+	"contains": {opContains, false}, "doesnotcontain": {opContains, true}, "notcontains": {opContains, true},
+	"beginswith": {opBeginsWith, false}, "doesnotbeginwith": {opBeginsWith, true},
+	"endswith": {opEndsWith, false}, "doesnotendwith": {opEndsWith, true},
+	"regex": {opRegex, false}, "matches": {opRegex, false}, "=~": {opRegex, false},
+	"notregex": {opRegex, true}, "doesnotmatch": {opRegex, true}, "!~": {opRegex, true},
+	"in": {opIn, false}, "notin": {opIn, true},
+	">": {opGreater, false}, "gt": {opGreater, false},
+	">=": {opGreaterEqual, false}, "gte": {opGreaterEqual, false},
+	"<": {opLess, false}, "lt": {opLess, false},
+	"<=": {opLessEqual, false}, "lte": {opLessEqual, false},
+	"between": {opBetween, false}, "notbetween": {opBetween, true},
+	"exists": {opExists, false}, "notnull": {opExists, false}, "isnotempty": {opExists, false},
+	"notexists": {opExists, true}, "null": {opExists, true}, "isempty": {opExists, true},
+}
+
+// comparison tests one field. String comparisons are case-insensitive;
+// regular expressions are used as written.
+type comparison struct {
+	field   string
+	kind    opKind
+	negated bool
+	values  []string // operands as written, for ordering comparisons
+	lower   []string // lowercased operands, for string comparisons
+	re      *regexp.Regexp
+}
+
+func compileComparison(n node) (condition, error) {
+	if strings.TrimSpace(n.Field) == "" {
+		return nil, fmt.Errorf("rule without a field")
+	}
+	op, ok := operators[strings.ToLower(strings.TrimSpace(n.Operator))]
+	if !ok {
+		return nil, fmt.Errorf("field %s: unknown operator %q", n.Field, n.Operator)
+	}
+
+	c := comparison{field: n.Field, kind: op.kind, negated: op.negated}
+	switch op.kind {
+	case opExists:
+	case opRegex:
+		re, err := regexp.Compile(operandString(n.Value))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: invalid regex: %v", n.Field, err)
+		}
+		c.re = re
+	case opIn, opBetween:
+		c.values = operandList(n.Value)
+		if op.kind == opBetween && len(c.values) != 2 {
+			return nil, fmt.Errorf("field %s: between needs two values", n.Field)
+		}
+	default:
+		c.values = []string{operandString(n.Value)}
+	}
+	for _, v := range c.values {
+		c.lower = append(c.lower, strings.ToLower(v))
+	}
+	return c, nil
+}
+
+func (c comparison) match(alert *models.DbAlert) bool {
+	m := c.test(alert)
+	if c.negated {
+		return !m
+	}
+	return m
+}
+
+func (c comparison) test(alert *models.DbAlert) bool {
+	raw, found := alert.FieldValue(c.field)
+	return c.testValue(raw, found)
+}
+
+// testValue applies the comparison to a field value; found is false for a
+// missing field.
+func (c comparison) testValue(raw interface{}, found bool) bool {
+	actual := valueString(raw)
+
+	switch c.kind {
+	case opExists:
+		return found && actual != ""
+	case opRegex:
+		return c.re.MatchString(actual)
+	}
+
+	lower := strings.ToLower(actual)
+	switch c.kind {
+	case opEquals:
+		return lower == c.lower[0]
+	case opContains:
+		return strings.Contains(lower, c.lower[0])
+	case opBeginsWith:
+		return strings.HasPrefix(lower, c.lower[0])
+	case opEndsWith:
+		return strings.HasSuffix(lower, c.lower[0])
+	case opIn:
+		for _, v := range c.lower {
+			if lower == v {
+				return true
+			}
+		}
+		return false
+	case opGreater:
+		return found && compare(raw, actual, c.values[0]) > 0
+	case opGreaterEqual:
+		return found && compare(raw, actual, c.values[0]) >= 0
+	case opLess:
+		return found && compare(raw, actual, c.values[0]) < 0
+	case opLessEqual:
+		return found && compare(raw, actual, c.values[0]) <= 0
+	case opBetween:
+		return found && compare(raw, actual, c.values[0]) >= 0 && compare(raw, actual, c.values[1]) <= 0
+	}
+	return false
+}
+
+// compare orders a field value against an operand: as times for time
+// fields, as numbers when both sides are numeric, otherwise as strings.
+func compare(raw interface{}, actual, operand string) int {
+	if t, ok := raw.(time.Time); ok {
+		if o, err := models.ParseAlertTime(operand); err == nil {
+			return t.Compare(o)
+		}
+	}
+	a, errA := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+	b, errB := strconv.ParseFloat(strings.TrimSpace(operand), 64)
+	if errA == nil && errB == nil {
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToLower(actual), strings.ToLower(operand))
+}
+
+func valueString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case time.Time:
+		if x.IsZero() {
+			return ""
+		}
+		return x.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+func operandString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// operandList accepts a JSON array or react-querybuilder's comma separated string.
+func operandList(v interface{}) []string {
+	var items []string
+	switch x := v.(type) {
+	case []interface{}:
+		for _, item := range x {
+			items = append(items, operandString(item))
+		}
+	default:
+		items = strings.Split(operandString(v), ",")
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
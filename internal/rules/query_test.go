@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+func testAlert() *models.DbAlert {
+	return &models.DbAlert{
+		Entity:         "db01.prod",
+		AlertSummary:   "Disk /var at 91%",
+		Severity:       "CRITICAL",
+		AlertCount:     7,
+		AlertFirstTime: models.CustomTime{Time: time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)},
+		AdditionalDetails: map[string]interface{}{
+			"region": "eu-west",
+			"k8s":    map[string]interface{}{"namespace": "payments"},
+		},
+	}
+}
+
+func TestCompileMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		want bool
+	}{
+		{"empty", ``, true},
+		{"empty object", `{}`, true},
+		{"empty group", `{"combinator":"and","rules":[]}`, true},
+		{"equals ignores case", `{"rules":[{"field":"Entity","operator":"=","value":"DB01.PROD"}]}`, true},
+		{"field alias", `{"rules":[{"field":"summary","operator":"contains","value":"disk"}]}`, true},
+		{"not equals", `{"rules":[{"field":"severity","operator":"!=","value":"critical"}]}`, false},
+		{"begins with", `{"rules":[{"field":"entity","operator":"beginsWith","value":"db"}]}`, true},
+		{"ends with", `{"rules":[{"field":"entity","operator":"endsWith","value":".dev"}]}`, false},
+		{"regex is case sensitive", `{"rules":[{"field":"summary","operator":"regex","value":"^disk"}]}`, false},
+		{"not regex", `{"rules":[{"field":"summary","operator":"!~","value":"^disk"}]}`, true},
+		{"in list", `{"rules":[{"field":"severity","operator":"in","value":"warn, critical"}]}`, true},
+		{"in array", `{"rules":[{"field":"severity","operator":"notIn","value":["warn","critical"]}]}`, false},
+		{"numeric greater", `{"rules":[{"field":"count","operator":">","value":"10"}]}`, false},
+		{"numeric operand", `{"rules":[{"field":"count","operator":">=","value":7}]}`, true},
+		{"between", `{"rules":[{"field":"count","operator":"between","value":"5,10"}]}`, true},
+		{"not between", `{"rules":[{"field":"count","operator":"notBetween","value":[1,3]}]}`, true},
+		{"time", `{"rules":[{"field":"firsttime","operator":"<","value":"2024-05-02T10:00:00Z"}]}`, true},
+		{"detail", `{"rules":[{"field":"region","operator":"=","value":"eu-west"}]}`, true},
+		{"nested detail", `{"rules":[{"field":"additionaldetails.k8s.namespace","operator":"=","value":"payments"}]}`, true},
+		{"exists", `{"rules":[{"field":"region","operator":"exists"}]}`, true},
+		{"missing does not exist", `{"rules":[{"field":"owner","operator":"notExists"}]}`, true},
+		{"missing is not greater", `{"rules":[{"field":"owner","operator":">","value":"0"}]}`, false},
+		{"or", `{"combinator":"or","rules":[
+			{"field":"entity","operator":"=","value":"web01"},
+			{"field":"severity","operator":"=","value":"critical"}]}`, true},
+		{"and", `{"combinator":"and","rules":[
+			{"field":"entity","operator":"=","value":"web01"},
+			{"field":"severity","operator":"=","value":"critical"}]}`, false},
+		{"not group", `{"combinator":"and","not":true,"rules":[{"field":"severity","operator":"=","value":"critical"}]}`, false},
+		{"nested group", `{"combinator":"and","rules":[
+			{"field":"severity","operator":"=","value":"critical"},
+			{"combinator":"or","rules":[
+				{"field":"region","operator":"=","value":"us-east"},
+				{"field":"entity","operator":"contains","value":"prod"}]}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Compile(tt.rule)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if got := p.Match(testAlert()); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"invalid json", `{"rules":[`},
+		{"unknown combinator", `{"combinator":"xor","rules":[]}`},
+		{"unknown operator", `{"rules":[{"field":"entity","operator":"like","value":"x"}]}`},
+		{"missing field", `{"rules":[{"combinator":"and","rules":[{"operator":"=","value":"x"}]}]}`},
+		{"invalid regex", `{"rules":[{"field":"entity","operator":"regex","value":"("}]}`},
+		{"between one value", `{"rules":[{"field":"count","operator":"between","value":"5"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.rule); err == nil {
+				t.Errorf("Compile(%s) succeeded, want an error", tt.rule)
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	got, err := Fields(`{"rules":[{"field":"entity","operator":"=","value":"x"},
+		{"combinator":"or","rules":[{"field":"severity","operator":"=","value":"y"},{"field":"region","operator":"exists"}]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"entity", "severity", "region"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields = %v, want %v", got, want)
+	}
+}
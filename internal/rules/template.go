@@ -0,0 +1,26 @@
+package rules
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// RenderTemplate renders a notify or heal payload as a Go text/template over
+// the alert, e.g. "{{.Entity}} is {{.Severity}} in {{.AdditionalDetails.region}}".
+// Text without template actions is returned unchanged.
+func RenderTemplate(text string, alert *models.DbAlert) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("payload").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, alert); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
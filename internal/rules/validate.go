@@ -0,0 +1,344 @@
+package rules
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/jsonpath"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/similarity"
+)
+
+// FieldErrors maps the JSON name of a rule field to what is wrong with it.
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for f := range e {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		parts = append(parts, f+": "+e[f])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// orNil keeps the nil-when-valid convention of the Validate functions.
+func (e FieldErrors) orNil() FieldErrors {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// sampleAlert is what payload templates are test-rendered against.
+func sampleAlert() *models.DbAlert {
+	return &models.DbAlert{
+		Entity:            "host01",
+		AlertSummary:      "sample alert",
+		Severity:          "CRITICAL",
+		AlertStatus:       "OPEN",
+		AlertCount:        1,
+		AlertFirstTime:    models.CustomTime{Time: time.Unix(0, 0)},
+		AdditionalDetails: map[string]interface{}{},
+	}
+}
+
+// Validate checks a rule of any type before it is stored. It returns nil
+// when the rule is valid.
+func Validate(rule interface{}) FieldErrors {
+	switch r := rule.(type) {
+	case *models.DbAlertRule:
+		return ValidateAlertRule(*r)
+	case *models.DbTagRule:
+		return ValidateTagRule(*r)
+	case *models.DbNotifyRule:
+		return ValidateNotifyRule(*r)
+	case *models.DbHealRule:
+		return ValidateHealRule(*r)
+	case *models.DbCorrelationRule:
+		return ValidateCorrelationRule(*r)
+	}
+	return nil
+}
+
+func checkRuleObject(errs FieldErrors, ruleObject string) {
+	if _, err := Compile(ruleObject); err != nil {
+		errs["ruleobject"] = err.Error()
+	}
+}
+
+// checkCalendar checks the form of a calendar condition; whether the
+// calendar exists is checked when the rule is evaluated.
+func checkCalendar(errs FieldErrors, cond *models.CalendarCondition) {
+	if cond == nil {
+		return
+	}
+	if cond.Name == "" {
+		errs["calendar.name"] = "is required"
+	}
+	if cond.Mode != models.CalendarActive && cond.Mode != models.CalendarInactive {
+		errs["calendar.mode"] = fmt.Sprintf("must be %q or %q", models.CalendarActive, models.CalendarInactive)
+	}
+}
+
+func checkTemplate(errs FieldErrors, field, text string) {
+	if _, err := RenderTemplate(text, sampleAlert()); err != nil {
+		errs[field] = "template does not render: " + err.Error()
+	}
+}
+
+// ValidateAlertRule checks the condition and every action, or, for a rule
+// still in the single-action form, that SetField is an alert field (or an
+// additionaldetails.<key>) that accepts SetValue.
+func ValidateAlertRule(r models.DbAlertRule) FieldErrors {
+	errs := FieldErrors{}
+	checkRuleObject(errs, r.RuleObject)
+	checkCalendar(errs, r.Calendar)
+
+	switch {
+	case len(r.Actions) > 0:
+		for i, a := range r.Actions {
+			checkAction(errs, fmt.Sprintf("actions[%d].", i), a)
+		}
+	case strings.TrimSpace(r.SetField) == "":
+		errs["actions"] = "at least one action is required"
+	default:
+		checkSetField(errs, "setfield", "setvalue", r.SetField, r.SetValue)
+	}
+	return errs.orNil()
+}
+
+// checkSetField checks that field is writable and accepts value, reporting
+// under the given keys.
+func checkSetField(errs FieldErrors, fieldKey, valueKey, field, value string) {
+	field = strings.TrimSpace(field)
+	switch {
+	case field == "":
+		errs[fieldKey] = "is required"
+	case models.CanonicalAlertField(field) == "" && !strings.HasPrefix(strings.ToLower(field), "additionaldetails."):
+		errs[fieldKey] = fmt.Sprintf("%q is not an alert field; use additionaldetails.<key> for custom fields", field)
+	default:
+		if err := sampleAlert().SetField(field, value); err != nil {
+			errs[valueKey] = err.Error()
+		}
+	}
+}
+
+func checkAction(errs FieldErrors, prefix string, a models.AlertAction) {
+	switch a.Type {
+	case models.ActionSetField:
+		checkSetField(errs, prefix+"field", prefix+"value", a.Field, a.Value)
+	case models.ActionAddTag, models.ActionRemoveTag:
+		if strings.TrimSpace(a.Tag) == "" {
+			errs[prefix+"tag"] = "is required"
+		}
+	case models.ActionSetSummary:
+		if a.Template == "" {
+			errs[prefix+"template"] = "is required"
+		} else {
+			checkTemplate(errs, prefix+"template", a.Template)
+		}
+	case models.ActionSetSeverity, models.ActionSetPriority:
+		if strings.TrimSpace(a.Value) == "" {
+			errs[prefix+"value"] = "is required"
+		}
+	case models.ActionDrop, models.ActionStop:
+	default:
+		errs[prefix+"type"] = fmt.Sprintf("must be one of %s", strings.Join(models.AlertActionTypes, ", "))
+	}
+}
+
+// ValidateTagRule checks the condition, the extraction regex and every entry
+// of Extractions. That the lookup tables exist is checked by the handlers
+// against the lookuptables collection.
+func ValidateTagRule(r models.DbTagRule) FieldErrors {
+	errs := FieldErrors{}
+	checkRuleObject(errs, r.RuleObject)
+	checkCalendar(errs, r.Calendar)
+	if r.FieldExtraction != "" {
+		if _, err := regexp.Compile(r.FieldExtraction); err != nil {
+			errs["fieldextraction"] = "invalid regex: " + err.Error()
+		}
+	}
+	for i, x := range r.Extractions {
+		checkExtraction(errs, fmt.Sprintf("extractions[%d].", i), x)
+	}
+	return errs.orNil()
+}
+
+func checkExtraction(errs FieldErrors, prefix string, x models.TagExtraction) {
+	switch {
+	case x.FieldName == "" && x.JSONPath == "":
+		errs[prefix+"fieldname"] = "fieldname or jsonpath is required"
+	case x.FieldName != "" && x.JSONPath != "":
+		errs[prefix+"jsonpath"] = "use either fieldname or jsonpath"
+	case x.JSONPath != "":
+		if _, err := jsonpath.Compile(x.JSONPath); err != nil {
+			errs[prefix+"jsonpath"] = err.Error()
+		}
+	}
+
+	// The tags the extraction can emit, for checking the lookups
+	produced := map[string]bool{}
+	if x.Regex != "" {
+		re, err := regexp.Compile(x.Regex)
+		if err != nil {
+			errs[prefix+"regex"] = "invalid regex: " + err.Error()
+			return
+		}
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				produced[name] = true
+			}
+		}
+	}
+	if len(produced) == 0 {
+		if x.TagName == "" {
+			errs[prefix+"tagname"] = "required unless the regex has named groups"
+		}
+		produced[x.TagName] = true
+	}
+
+	for j, l := range x.Lookups {
+		field := fmt.Sprintf("%slookups[%d].", prefix, j)
+		if l.Table == "" {
+			errs[field+"table"] = "required"
+		}
+		if !produced[l.Tag] {
+			errs[field+"tag"] = fmt.Sprintf("%q is not produced by this extraction", l.Tag)
+		}
+		if l.As != "" {
+			produced[l.As] = true
+		}
+	}
+}
+
+// ValidateNotifyRule checks the condition, that EndPoint is an http(s) URL
+// (it may be empty for PagerDuty rules) and that the payload renders.
+func ValidateNotifyRule(r models.DbNotifyRule) FieldErrors {
+	errs := FieldErrors{}
+	checkRuleObject(errs, r.RuleObject)
+	checkCalendar(errs, r.Calendar)
+
+	endpoint := strings.TrimSpace(r.EndPoint)
+	if endpoint == "" {
+		if r.PagerDutyService == "" {
+			errs["endpoint"] = "is required unless a PagerDuty service is set"
+		}
+	} else if u, err := url.ParseRequestURI(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs["endpoint"] = "must be an http or https URL"
+	}
+	checkTemplate(errs, "payload", r.PayLoad)
+	return errs.orNil()
+}
+
+// ValidateHealRule checks the condition and that the payload renders.
+func ValidateHealRule(r models.DbHealRule) FieldErrors {
+	errs := FieldErrors{}
+	checkRuleObject(errs, r.RuleObject)
+	checkCalendar(errs, r.Calendar)
+	checkTemplate(errs, "payload", r.Payload)
+	return errs.orNil()
+}
+
+// ValidateCorrelationRule checks the window, the similarity settings, the
+// mode, the group tags of TAG_BASED rules and the settings of TOPOLOGY
+// rules.
+func ValidateCorrelationRule(r models.DbCorrelationRule) FieldErrors {
+	errs := FieldErrors{}
+	if r.GroupWindow <= 0 {
+		errs["time_window_minutes"] = "must be greater than 0"
+	}
+	if r.Similarity.Threshold < 0 || r.Similarity.Threshold > 1 {
+		errs["similarity.threshold"] = "must be between 0 and 1"
+	}
+	switch t := r.Similarity.EmbeddingThreshold; {
+	case t < 0 || t > 1:
+		errs["similarity.embedding_threshold"] = "must be between 0 and 1"
+	case t == 0 && r.CorrelationMode == models.CorrelationModeEmbedding:
+		errs["similarity.embedding_threshold"] = "is required for " + models.CorrelationModeEmbedding + "; similarity.threshold applies to the text fallback"
+	}
+	checkSimilarity(errs, r.Similarity)
+	known := false
+	for _, m := range models.CorrelationModes {
+		if r.CorrelationMode == m {
+			known = true
+		}
+	}
+	if !known {
+		errs["correlation_mode"] = fmt.Sprintf("must be one of %s", strings.Join(models.CorrelationModes, ", "))
+	}
+	if r.CorrelationMode == models.CorrelationModeTagBased && len(fieldSet(r.GroupTags)) == 0 {
+		errs["grouptags"] = "at least one tag is required for " + models.CorrelationModeTagBased
+	}
+	if r.CorrelationMode == models.CorrelationModeTopology {
+		checkTopology(errs, r.Topology)
+	}
+	checkCalendar(errs, r.Calendar)
+	return errs.orNil()
+}
+
+func checkTopology(errs FieldErrors, t *models.TopologyConfig) {
+	if t == nil {
+		errs["topology"] = "is required for " + models.CorrelationModeTopology
+		return
+	}
+	if t.MaxHops < 1 || t.MaxHops > models.MaxTopologyHops {
+		errs["topology.max_hops"] = fmt.Sprintf("must be between 1 and %d", models.MaxTopologyHops)
+	}
+	for _, rt := range t.RelationshipTypes {
+		if strings.TrimSpace(rt) == "" {
+			errs["topology.relationship_types"] = "must not contain empty names"
+		}
+	}
+	if t.Upstream != "" && t.Upstream != models.UpstreamOut && t.Upstream != models.UpstreamIn {
+		errs["topology.upstream"] = fmt.Sprintf("must be %q or %q", models.UpstreamOut, models.UpstreamIn)
+	}
+}
+
+func checkSimilarity(errs FieldErrors, cfg models.SimilarityConfig) {
+	if !similarity.KnownMetric(cfg.Metric) {
+		errs["similarity.metric"] = fmt.Sprintf("must be one of %s", strings.Join(similarity.Metrics, ", "))
+	}
+	for _, step := range cfg.Normalize {
+		if !similarity.KnownStep(step) {
+			errs["similarity.normalize"] = fmt.Sprintf("unknown step %q; use %s", step, strings.Join(similarity.Steps, ", "))
+			break
+		}
+	}
+	if len(cfg.Weights) == 0 {
+		return
+	}
+	fields := map[string]bool{}
+	for _, f := range cfg.Fields {
+		fields[f] = true
+	}
+	names := make([]string, 0, len(cfg.Weights))
+	for f := range cfg.Weights {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	positive := false
+	for _, f := range names {
+		w := cfg.Weights[f]
+		switch {
+		case !fields[f]:
+			errs["similarity.weights"] = fmt.Sprintf("%q is not one of the fields", f)
+		case w < 0:
+			errs["similarity.weights"] = fmt.Sprintf("weight of %q must not be negative", f)
+		}
+		if w > 0 {
+			positive = true
+		}
+	}
+	if _, ok := errs["similarity.weights"]; !ok && !positive && len(cfg.Weights) >= len(fields) {
+		errs["similarity.weights"] = "at least one field needs a weight above 0"
+	}
+}
@@ -0,0 +1,50 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+func TestValidateCorrelationThresholds(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		threshold float64
+		cosine    float64
+		wantField string
+	}{
+		{"similarity", models.CorrelationModeSimilarity, 0.6, 0, ""},
+		{"embedding with both thresholds", models.CorrelationModeEmbedding, 0.4, 0.85, ""},
+		{"embedding without a cosine threshold", models.CorrelationModeEmbedding, 0.85, 0, "similarity.embedding_threshold"},
+		{"cosine threshold above 1", models.CorrelationModeEmbedding, 0.4, 1.2, "similarity.embedding_threshold"},
+		{"negative cosine threshold", models.CorrelationModeSimilarity, 0.6, -0.1, "similarity.embedding_threshold"},
+		{"text threshold above 1", models.CorrelationModeEmbedding, 1.5, 0.85, "similarity.threshold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := models.DbCorrelationRule{GroupName: "r", GroupWindow: 10, CorrelationMode: tt.mode,
+				Similarity: models.SimilarityConfig{Fields: []string{"summary"}, Threshold: tt.threshold, EmbeddingThreshold: tt.cosine}}
+			errs := ValidateCorrelationRule(r)
+			if tt.wantField == "" {
+				if len(errs) != 0 {
+					t.Errorf("errors %v, want none", errs)
+				}
+				return
+			}
+			if _, ok := errs[tt.wantField]; !ok || len(errs) != 1 {
+				t.Errorf("errors %v, want one on %s", errs, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestCosineThreshold(t *testing.T) {
+	if got := (models.SimilarityConfig{Threshold: 0.6, EmbeddingThreshold: 0.85}).CosineThreshold(); got != 0.85 {
+		t.Errorf("CosineThreshold = %v, want 0.85", got)
+	}
+	// Rules saved before embedding_threshold keep their single threshold
+	if got := (models.SimilarityConfig{Threshold: 0.8}).CosineThreshold(); got != 0.8 {
+		t.Errorf("CosineThreshold = %v, want 0.8", got)
+	}
+}
@@ -0,0 +1,350 @@
+// Package rulestats counts rule matches. Hits are buffered in Redis and
+// flushed to the "rulestats" Mongo collection periodically, so the ingestion
+// path costs one Redis round trip per matching rule.
+package rulestats
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	collectionName = "rulestats"
+
+	// dirtyKey is the set of "<collection>:<rule id>" with pending hits
+	dirtyKey      = "rulestats:dirty"
+	pendingPrefix = "rulestats:pending:"
+
+	hourFormat = "2006010215"
+	dayFormat  = "20060102"
+
+	// Rolling windows kept in Mongo
+	keepHours = 48
+	keepDays  = 30
+)
+
+// delta is the hits of one rule not yet written to Mongo.
+type delta struct {
+	hits      int64
+	hourly    map[string]int64
+	daily     map[string]int64
+	lastAt    time.Time
+	lastAlert string
+}
+
+func statsID(collection string, ruleID primitive.ObjectID) string {
+	return collection + ":" + ruleID.Hex()
+}
+
+// Hit records that a rule of collection matched the alert. When Redis is
+// unavailable the hit is written to Mongo directly.
+func Hit(ctx context.Context, collection string, ruleID primitive.ObjectID, alertID string) {
+	now := time.Now().UTC()
+	id := statsID(collection, ruleID)
+	key := pendingPrefix + id
+
+	// Keep ingestion moving when Redis is unreachable
+	rctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	_, err := db.RedisClient.TxPipelined(rctx, func(p redis.Pipeliner) error {
+		p.HIncrBy(rctx, key, "hits", 1)
+		p.HIncrBy(rctx, key, "h:"+now.Format(hourFormat), 1)
+		p.HIncrBy(rctx, key, "d:"+now.Format(dayFormat), 1)
+		p.HSet(rctx, key, "last_at", now.UnixNano(), "last_alert", alertID)
+		p.SAdd(rctx, dirtyKey, id)
+		return nil
+	})
+	if err == nil {
+		return
+	}
+
+	d := delta{
+		hits:      1,
+		hourly:    map[string]int64{now.Format(hourFormat): 1},
+		daily:     map[string]int64{now.Format(dayFormat): 1},
+		lastAt:    now,
+		lastAlert: alertID,
+	}
+	if err := write(ctx, collection, ruleID, d); err != nil {
+		log.Printf("Recording hit of %s failed: %v", id, err)
+	}
+}
+
+// Start flushes the buffered hits every interval, read from
+// RULE_STATS_FLUSH_INTERVAL (default 1m).
+func Start() {
+	interval := time.Minute
+	if s := os.Getenv("RULE_STATS_FLUSH_INTERVAL"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			interval = d
+		} else {
+			log.Printf("Ignoring RULE_STATS_FLUSH_INTERVAL %q", s)
+		}
+	}
+	go func() {
+		for range time.Tick(interval) {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if err := Flush(ctx); err != nil {
+				log.Printf("Flushing rule stats failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// Flush moves the hits buffered in Redis to Mongo.
+func Flush(ctx context.Context) error {
+	for {
+		ids, err := db.RedisClient.SPopN(ctx, dirtyKey, 100).Result()
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		for i, id := range ids {
+			if err := flushOne(ctx, id); err != nil {
+				// Leave the rules not reached yet for the next flush
+				if rest := ids[i+1:]; len(rest) > 0 {
+					db.RedisClient.SAdd(ctx, dirtyKey, rest)
+				}
+				return err
+			}
+		}
+	}
+}
+
+func flushOne(ctx context.Context, id string) error {
+	collection, hex, ok := strings.Cut(id, ":")
+	ruleID, err := primitive.ObjectIDFromHex(hex)
+	if !ok || err != nil {
+		return nil
+	}
+
+	// Read and clear atomically; hits arriving later start a new hash
+	key := pendingPrefix + id
+	var fields *redis.StringStringMapCmd
+	if _, err := db.RedisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		fields = p.HGetAll(ctx, key)
+		p.Del(ctx, key)
+		return nil
+	}); err != nil {
+		db.RedisClient.SAdd(ctx, dirtyKey, id)
+		return err
+	}
+	d := parseDelta(fields.Val())
+	if d.hits == 0 {
+		return nil
+	}
+
+	if err := write(ctx, collection, ruleID, d); err != nil {
+		restore(ctx, id, fields.Val())
+		return err
+	}
+	return nil
+}
+
+func parseDelta(fields map[string]string) delta {
+	d := delta{hourly: map[string]int64{}, daily: map[string]int64{}}
+	for k, v := range fields {
+		switch {
+		case k == "hits":
+			d.hits, _ = strconv.ParseInt(v, 10, 64)
+		case k == "last_at":
+			if ns, err := strconv.ParseInt(v, 10, 64); err == nil {
+				d.lastAt = time.Unix(0, ns).UTC()
+			}
+		case k == "last_alert":
+			d.lastAlert = v
+		case strings.HasPrefix(k, "h:"):
+			d.hourly[k[2:]], _ = strconv.ParseInt(v, 10, 64)
+		case strings.HasPrefix(k, "d:"):
+			d.daily[k[2:]], _ = strconv.ParseInt(v, 10, 64)
+		}
+	}
+	return d
+}
+
+// restore puts back hits whose Mongo write failed, for the next flush.
+func restore(ctx context.Context, id string, fields map[string]string) {
+	key := pendingPrefix + id
+	_, err := db.RedisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		for k, v := range fields {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil && k != "last_at" {
+				p.HIncrBy(ctx, key, k, n)
+			} else {
+				p.HSetNX(ctx, key, k, v)
+			}
+		}
+		p.SAdd(ctx, dirtyKey, id)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Restoring rule stats of %s failed: %v", id, err)
+	}
+}
+
+// write adds a delta to the Mongo document of the rule and drops buckets
+// that fell out of the rolling windows.
+func write(ctx context.Context, collection string, ruleID primitive.ObjectID, d delta) error {
+	inc := bson.M{"hits": d.hits}
+	for b, n := range d.hourly {
+		inc["hourly."+b] = n
+	}
+	for b, n := range d.daily {
+		inc["daily."+b] = n
+	}
+	update := bson.M{
+		"$inc": inc,
+		"$set": bson.M{"collection": collection, "rule_id": ruleID},
+		"$max": bson.M{"last_matched_at": d.lastAt},
+	}
+	col := db.GetCollection(collectionName)
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stats models.DbRuleStats
+	if err := col.FindOneAndUpdate(ctx, bson.M{"_id": statsID(collection, ruleID)}, update, opts).Decode(&stats); err != nil {
+		return err
+	}
+
+	set := bson.M{}
+	if !stats.LastMatchedAt.After(d.lastAt) && d.lastAlert != "" {
+		set["last_matched_alert"] = d.lastAlert
+	}
+	unset := bson.M{}
+	now := time.Now().UTC()
+	for b := range stats.Hourly {
+		if t, err := time.Parse(hourFormat, b); err != nil || now.Sub(t) >= keepHours*time.Hour {
+			unset["hourly."+b] = ""
+		}
+	}
+	for b := range stats.Daily {
+		if t, err := time.Parse(dayFormat, b); err != nil || now.Sub(t) >= keepDays*24*time.Hour {
+			unset["daily."+b] = ""
+		}
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		return nil
+	}
+	cleanup := bson.M{}
+	if len(set) > 0 {
+		cleanup["$set"] = set
+	}
+	if len(unset) > 0 {
+		cleanup["$unset"] = unset
+	}
+	_, err := col.UpdateOne(ctx, bson.M{"_id": stats.ID}, cleanup)
+	return err
+}
+
+// Load returns the flushed stats of every rule in collection by rule ID,
+// with Last24h and Last7d filled in.
+func Load(ctx context.Context, collection string) (map[primitive.ObjectID]*models.DbRuleStats, error) {
+	cursor, err := db.GetCollection(collectionName).Find(ctx, bson.M{"collection": collection})
+	if err != nil {
+		return nil, err
+	}
+	var records []*models.DbRuleStats
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	out := make(map[primitive.ObjectID]*models.DbRuleStats, len(records))
+	for _, s := range records {
+		summarize(s, now)
+		out[s.RuleID] = s
+	}
+	return out, nil
+}
+
+// summarize fills in the rolling totals from the buckets.
+func summarize(s *models.DbRuleStats, now time.Time) {
+	hour := now.Truncate(time.Hour)
+	for b, n := range s.Hourly {
+		if t, err := time.Parse(hourFormat, b); err == nil && hour.Sub(t) < 24*time.Hour {
+			s.Last24h += n
+		}
+	}
+	day := now.Truncate(24 * time.Hour)
+	for b, n := range s.Daily {
+		if t, err := time.Parse(dayFormat, b); err == nil && day.Sub(t) < 7*24*time.Hour {
+			s.Last7d += n
+		}
+	}
+}
+
+// Stale is a rule that did not match within the report window.
+type Stale struct {
+	Collection    string             `json:"collection"`
+	RuleID        primitive.ObjectID `json:"rule_id"`
+	RuleName      string             `json:"rule_name"`
+	Enabled       bool               `json:"enabled"`
+	CreatedAt     time.Time          `json:"created_at"`
+	Hits          int64              `json:"hits"`
+	LastMatchedAt *time.Time         `json:"last_matched_at"`
+}
+
+// StaleRules lists the rules of collection (named by nameField) that have
+// not matched in the last days days: never matched first, then by oldest match.
+func StaleRules(ctx context.Context, collection, nameField string, days int) ([]Stale, error) {
+	stats, err := Load(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := db.GetCollection(collection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var rules []bson.M
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	out := []Stale{}
+	for _, r := range rules {
+		id, ok := r["_id"].(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		entry := Stale{
+			Collection: collection,
+			RuleID:     id,
+			RuleName:   fmt.Sprint(r[nameField]),
+			Enabled:    r["enabled"] != false,
+			CreatedAt:  id.Timestamp(),
+		}
+		if s, ok := stats[id]; ok {
+			if s.LastMatchedAt.After(cutoff) {
+				continue
+			}
+			entry.Hits = s.Hits
+			if !s.LastMatchedAt.IsZero() {
+				t := s.LastMatchedAt
+				entry.LastMatchedAt = &t
+			}
+		}
+		out = append(out, entry)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].LastMatchedAt, out[j].LastMatchedAt
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+	return out, nil
+}
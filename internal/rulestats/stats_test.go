@@ -0,0 +1,73 @@
+package rulestats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+func TestParseDelta(t *testing.T) {
+	at := time.Date(2024, 5, 2, 9, 14, 3, 0, time.UTC)
+	got := parseDelta(map[string]string{
+		"hits":         "3",
+		"last_at":      "1714641243000000000",
+		"last_alert":   "ALR-7",
+		"h:2024050209": "2",
+		"h:2024050208": "1",
+		"d:20240502":   "3",
+		"unknown":      "9",
+	})
+	want := delta{
+		hits:      3,
+		hourly:    map[string]int64{"2024050209": 2, "2024050208": 1},
+		daily:     map[string]int64{"20240502": 3},
+		lastAt:    at,
+		lastAlert: "ALR-7",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDelta =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Unparsable values count as zero rather than failing the flush
+	got = parseDelta(map[string]string{"hits": "x", "last_at": "soon", "h:2024050209": "?"})
+	if got.hits != 0 || !got.lastAt.IsZero() || got.hourly["2024050209"] != 0 {
+		t.Errorf("parseDelta of bad values = %+v", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name              string
+		hourly, daily     map[string]int64
+		want24h, wantWeek int64
+	}{
+		{"empty", nil, nil, 0, 0},
+		{
+			name:    "hours within the last 24",
+			hourly:  map[string]int64{"2024051012": 1, "2024050913": 2, "2024050912": 4},
+			want24h: 3,
+		},
+		{
+			name:     "days within the last 7",
+			daily:    map[string]int64{"20240510": 1, "20240504": 2, "20240503": 4},
+			wantWeek: 3,
+		},
+		{
+			name:   "malformed buckets are skipped",
+			hourly: map[string]int64{"today": 5},
+			daily:  map[string]int64{"2024-05-10": 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &models.DbRuleStats{Hourly: tt.hourly, Daily: tt.daily}
+			summarize(s, now)
+			if s.Last24h != tt.want24h || s.Last7d != tt.wantWeek {
+				t.Errorf("summarize = last 24h %d, last 7d %d; want %d, %d", s.Last24h, s.Last7d, tt.want24h, tt.wantWeek)
+			}
+		})
+	}
+}
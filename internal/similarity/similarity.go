@@ -0,0 +1,233 @@
+// Package similarity scores how alike two alert texts are, after
+// optionally replacing the parts that vary between otherwise identical
+// alerts (numbers, addresses, IDs, host names, times) with placeholders.
+package similarity
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Metrics
+const (
+	Jaccard     = "jaccard"     // shared words over all words
+	TFIDF       = "tfidf"       // cosine of TF-IDF word vectors
+	NGram       = "ngram"       // Jaccard over character 3-grams
+	Levenshtein = "levenshtein" // 1 - edit distance / length of the longer text
+)
+
+// Metrics lists the metrics Scores understands; "" means Jaccard.
+var Metrics = []string{Jaccard, TFIDF, NGram, Levenshtein}
+
+// Normalization steps
+const (
+	Timestamps = "timestamps"
+	UUIDs      = "uuids"
+	IPs        = "ips"
+	Hostnames  = "hostnames"
+	Numbers    = "numbers"
+)
+
+// Steps lists the normalization steps in the order they are applied,
+// whatever order a rule lists them in.
+var Steps = []string{Timestamps, UUIDs, IPs, Hostnames, Numbers}
+
+// ngramSize is the length of the character shingles of NGram
+const ngramSize = 3
+
+var templates = map[string]struct {
+	re          *regexp.Regexp
+	placeholder string
+}{
+	Timestamps: {regexp.MustCompile(`\d{4}-\d{2}-\d{2}(?:[t ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?(?:z|[+-]\d{2}:?\d{2})?)?|\b\d{1,2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?\b`), "<ts>"},
+	UUIDs:      {regexp.MustCompile(`\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	IPs:        {regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b|\b(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{1,4}\b`), "<ip>"},
+	Hostnames:  {regexp.MustCompile(`\b[a-z][a-z0-9-]*\d[a-z0-9-]*(?:\.[a-z0-9-]+)*\b|\b[a-z0-9-]+(?:\.[a-z0-9-]+)+\.[a-z]{2,}\b`), "<host>"},
+	Numbers:    {regexp.MustCompile(`\d+(?:\.\d+)?`), "<num>"},
+}
+
+// KnownMetric reports whether m is "" or one of Metrics.
+func KnownMetric(m string) bool {
+	return m == "" || contains(Metrics, m)
+}
+
+// KnownStep reports whether s is one of Steps.
+func KnownStep(s string) bool {
+	return contains(Steps, s)
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize lower-cases text and applies the listed steps, e.g. with
+// numbers and hostnames "CPU 91% on web-01" becomes "cpu <num>% on <host>".
+func Normalize(text string, steps []string) string {
+	text = strings.ToLower(text)
+	for _, s := range Steps {
+		if contains(steps, s) {
+			t := templates[s]
+			text = t.re.ReplaceAllString(text, t.placeholder)
+		}
+	}
+	return text
+}
+
+// Scores compares source with each candidate using metric. Texts are
+// compared as given, so normalize them first. Two empty texts score 0.
+func Scores(metric, source string, candidates []string) []float64 {
+	scores := make([]float64, len(candidates))
+	switch metric {
+	case TFIDF:
+		idf := inverseFrequencies(append([]string{source}, candidates...))
+		src := tfidf(source, idf)
+		for i, c := range candidates {
+			scores[i] = cosine(src, tfidf(c, idf))
+		}
+	case NGram:
+		src := shingles(source)
+		for i, c := range candidates {
+			scores[i] = jaccard(src, shingles(c))
+		}
+	case Levenshtein:
+		for i, c := range candidates {
+			scores[i] = levenshteinSimilarity(source, c)
+		}
+	default:
+		src := wordSet(source)
+		for i, c := range candidates {
+			scores[i] = jaccard(src, wordSet(c))
+		}
+	}
+	return scores
+}
+
+func wordSet(text string) map[string]bool {
+	out := map[string]bool{}
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		out[w] = true
+	}
+	return out
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	inter := 0
+	for k := range a {
+		if b[k] {
+			inter++
+		}
+	}
+	union := len(a) + len(b) - inter
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
+
+// shingles returns the character n-grams of text with runs of whitespace
+// collapsed; a text shorter than one n-gram is its own shingle.
+func shingles(text string) map[string]bool {
+	runes := []rune(strings.Join(strings.Fields(strings.ToLower(text)), " "))
+	out := map[string]bool{}
+	if len(runes) == 0 {
+		return out
+	}
+	if len(runes) < ngramSize {
+		out[string(runes)] = true
+		return out
+	}
+	for i := 0; i+ngramSize <= len(runes); i++ {
+		out[string(runes[i:i+ngramSize])] = true
+	}
+	return out
+}
+
+// inverseFrequencies returns the smoothed IDF of every word over docs.
+func inverseFrequencies(docs []string) map[string]float64 {
+	df := map[string]int{}
+	for _, d := range docs {
+		for w := range wordSet(d) {
+			df[w]++
+		}
+	}
+	idf := make(map[string]float64, len(df))
+	n := float64(len(docs))
+	for w, f := range df {
+		idf[w] = math.Log((1+n)/(1+float64(f))) + 1
+	}
+	return idf
+}
+
+func tfidf(text string, idf map[string]float64) map[string]float64 {
+	out := map[string]float64{}
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		out[w] += idf[w]
+	}
+	return out
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for k, x := range a {
+		dot += x * b[k]
+		na += x * x
+	}
+	for _, y := range b {
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// levenshteinSimilarity is 1 minus the edit distance in runes over the
+// length of the longer text.
+func levenshteinSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longer := len(ra)
+	if len(rb) > longer {
+		longer = len(rb)
+	}
+	if longer == 0 {
+		return 0
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longer)
+}
+
+// Tokens returns the token set a near-duplicate index should hold for text
+// compared with metric: character n-grams for ngram and levenshtein, words
+// otherwise.
+func Tokens(metric, text string) []string {
+	set := wordSet(text)
+	if metric == NGram || metric == Levenshtein {
+		set = shingles(text)
+	}
+	out := make([]string, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package similarity
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		steps []string
+		want  string
+	}{
+		{"lower-cases only", "Disk FULL on /var", nil, "disk full on /var"},
+		{"iso timestamp", "failed at 2024-05-02T09:14:03.123Z, retrying", []string{Timestamps}, "failed at <ts>, retrying"},
+		{"timestamp with offset", "at 2024-05-02 09:14+02:00 ok", []string{Timestamps}, "at <ts> ok"},
+		{"date only", "backup of 2024-05-02 failed", []string{Timestamps}, "backup of <ts> failed"},
+		{"clock time", "down since 9:14:03 and 23:59", []string{Timestamps}, "down since <ts> and <ts>"},
+		{"uuid", "job 3F2504E0-4F89-11D3-9A0C-0305E82C3301 failed", []string{UUIDs}, "job <uuid> failed"},
+		{"ipv4", "no route to 10.0.3.7 from 192.168.1.201", []string{IPs}, "no route to <ip> from <ip>"},
+		{"ipv6", "peer fe80::1ff:fe23:4567:890a down", []string{IPs}, "peer <ip> down"},
+		{"hostname with digits", "CPU high on web-01 and db2", []string{Hostnames}, "cpu high on <host> and <host>"},
+		{"fqdn", "cert expired on api.example.com", []string{Hostnames}, "cert expired on <host>"},
+		{"plain words are not hosts", "disk full on server", []string{Hostnames}, "disk full on server"},
+		{"numbers", "cpu 91.5% for 300 seconds", []string{Numbers}, "cpu <num>% for <num> seconds"},
+		{"ips before numbers", "10.0.3.7 lost 12 packets", []string{Numbers, IPs}, "<ip> lost <num> packets"},
+		{"timestamps before numbers", "2024-05-02 disk at 91%", []string{Numbers, Timestamps}, "<ts> disk at <num>%"},
+		{"hosts before numbers", "web-01 cpu 91%", []string{Numbers, Hostnames}, "<host> cpu <num>%"},
+		{"uuid before hosts and numbers", "vm 3f2504e0-4f89-11d3-9a0c-0305e82c3301 stopped", []string{Numbers, Hostnames, UUIDs}, "vm <uuid> stopped"},
+		{"all steps", "2024-05-02T09:14:03Z web-01 (10.0.3.7) cpu 91%", Steps, "<ts> <host> (<ip>) cpu <num>%"},
+		{"unknown steps are ignored", "cpu 91%", []string{"emojis"}, "cpu 91%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.text, tt.steps); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScores(t *testing.T) {
+	tests := []struct {
+		metric    string
+		source    string
+		candidate string
+		want      float64
+	}{
+		{"", "disk full on var", "disk full on var", 1},
+		{Jaccard, "disk full on var", "disk full on tmp", 3.0 / 5},
+		{Jaccard, "Disk full", "disk FULL", 1},
+		{Jaccard, "", "", 0},
+		{NGram, "abcd", "abce", 1.0 / 3},
+		{NGram, "ab", "ab", 1},
+		{NGram, "disk  full", "disk full", 1},
+		{Levenshtein, "kitten", "sitting", 1 - 3.0/7},
+		{Levenshtein, "same", "same", 1},
+		{Levenshtein, "", "", 0},
+		{TFIDF, "disk full", "disk full", 1},
+		{TFIDF, "disk full", "cpu high", 0},
+	}
+	for _, tt := range tests {
+		got := Scores(tt.metric, tt.source, []string{tt.candidate})[0]
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Scores(%q, %q, %q) = %v, want %v", tt.metric, tt.source, tt.candidate, got, tt.want)
+		}
+	}
+}
+
+func TestTFIDFWeighsRareWords(t *testing.T) {
+	// "disk" appears everywhere, so sharing "var" counts for more
+	scores := Scores(TFIDF, "disk var", []string{"disk tmp", "cpu var", "disk home"})
+	if scores[1] <= scores[0] {
+		t.Errorf("sharing a rare word scored %v, a common one %v", scores[1], scores[0])
+	}
+}
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		metric string
+		text   string
+		want   []string
+	}{
+		{Jaccard, "Disk full disk", []string{"disk", "full"}},
+		{TFIDF, "a b", []string{"a", "b"}},
+		{NGram, "abcd", []string{"abc", "bcd"}},
+		{Levenshtein, "ab", []string{"ab"}},
+	}
+	for _, tt := range tests {
+		got := Tokens(tt.metric, tt.text)
+		sort.Strings(got)
+		if len(got) != len(tt.want) {
+			t.Errorf("Tokens(%q, %q) = %v, want %v", tt.metric, tt.text, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Tokens(%q, %q) = %v, want %v", tt.metric, tt.text, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestKnown(t *testing.T) {
+	for _, m := range append([]string{""}, Metrics...) {
+		if !KnownMetric(m) {
+			t.Errorf("KnownMetric(%q) = false", m)
+		}
+	}
+	if KnownMetric("cosine") {
+		t.Error(`KnownMetric("cosine") = true`)
+	}
+	for _, s := range Steps {
+		if !KnownStep(s) {
+			t.Errorf("KnownStep(%q) = false", s)
+		}
+	}
+	if KnownStep("") {
+		t.Error(`KnownStep("") = true`)
+	}
+}
@@ -0,0 +1,216 @@
+package snmptrap
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// BER tags used by SNMPv2c messages (RFC 3416 / RFC 2578)
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagNull        = 0x05
+	tagOID         = 0x06
+	tagSequence    = 0x30
+	tagIPAddress   = 0x40
+	tagCounter32   = 0x41
+	tagGauge32     = 0x42
+	tagTimeTicks   = 0x43
+	tagOpaque      = 0x44
+	tagCounter64   = 0x46
+	tagNoSuchObj   = 0x80
+	tagNoSuchInst  = 0x81
+	tagEndOfMIB    = 0x82
+
+	pduResponse = 0xa2
+	pduInform   = 0xa6
+	pduTrapV2   = 0xa7
+)
+
+var errTruncated = errors.New("snmptrap: truncated BER data")
+
+// readTLV splits the first tag-length-value element off b.
+func readTLV(b []byte) (tag byte, value []byte, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errTruncated
+	}
+	tag = b[0]
+	if tag&0x1f == 0x1f {
+		return 0, nil, nil, errors.New("snmptrap: multi-byte BER tags are not supported")
+	}
+
+	length, i := int(b[1]), 2
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 {
+			return 0, nil, nil, errors.New("snmptrap: unsupported BER length")
+		}
+		if len(b) < 2+n {
+			return 0, nil, nil, errTruncated
+		}
+		length = 0
+		for _, c := range b[2 : 2+n] {
+			length = length<<8 | int(c)
+		}
+		i += n
+	}
+	if length < 0 || len(b)-i < length {
+		return 0, nil, nil, errTruncated
+	}
+	return tag, b[i : i+length], b[i+length:], nil
+}
+
+// expect reads an element and checks its tag.
+func expect(b []byte, want byte) (value []byte, rest []byte, err error) {
+	tag, value, rest, err := readTLV(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tag != want {
+		return nil, nil, fmt.Errorf("snmptrap: expected BER tag 0x%02x, got 0x%02x", want, tag)
+	}
+	return value, rest, nil
+}
+
+func parseInt(v []byte) (int64, error) {
+	if len(v) == 0 || len(v) > 8 {
+		return 0, errors.New("snmptrap: invalid INTEGER length")
+	}
+	n := int64(int8(v[0])) // sign extend
+	for _, c := range v[1:] {
+		n = n<<8 | int64(c)
+	}
+	return n, nil
+}
+
+func parseUint(v []byte) (uint64, error) {
+	// A leading zero byte keeps the high bit clear, so Counter64 may take nine
+	if len(v) == 9 && v[0] == 0 {
+		v = v[1:]
+	}
+	if len(v) == 0 || len(v) > 8 {
+		return 0, errors.New("snmptrap: invalid unsigned length")
+	}
+	var n uint64
+	for _, c := range v {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func parseOID(v []byte) (string, error) {
+	if len(v) == 0 {
+		return "", errors.New("snmptrap: empty OID")
+	}
+	var parts []string
+	var sub uint64
+	for i, c := range v {
+		if sub > math.MaxUint64>>7 {
+			return "", errors.New("snmptrap: OID subidentifier too large")
+		}
+		sub = sub<<7 | uint64(c&0x7f)
+		if c&0x80 != 0 {
+			if i == len(v)-1 {
+				return "", errTruncated
+			}
+			continue
+		}
+		if parts == nil {
+			// The first subidentifier packs the first two arcs as 40*x + y
+			first := sub / 40
+			if first > 2 {
+				first = 2
+			}
+			parts = append(parts, strconv.FormatUint(first, 10), strconv.FormatUint(sub-first*40, 10))
+		} else {
+			parts = append(parts, strconv.FormatUint(sub, 10))
+		}
+		sub = 0
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// parseValue converts a varbind value to a plain Go value and a type name.
+func parseValue(tag byte, v []byte) (string, interface{}, error) {
+	switch tag {
+	case tagInteger:
+		n, err := parseInt(v)
+		return "Integer", n, err
+	case tagOctetString:
+		return "OctetString", octetString(v), nil
+	case tagNull:
+		return "Null", nil, nil
+	case tagOID:
+		oid, err := parseOID(v)
+		return "ObjectIdentifier", oid, err
+	case tagIPAddress:
+		if len(v) != 4 {
+			return "", nil, errors.New("snmptrap: invalid IpAddress")
+		}
+		return "IpAddress", fmt.Sprintf("%d.%d.%d.%d", v[0], v[1], v[2], v[3]), nil
+	case tagCounter32, tagGauge32, tagTimeTicks, tagCounter64:
+		n, err := parseUint(v)
+		return map[byte]string{tagCounter32: "Counter32", tagGauge32: "Gauge32", tagTimeTicks: "TimeTicks", tagCounter64: "Counter64"}[tag], n, err
+	case tagOpaque:
+		return "Opaque", hex.EncodeToString(v), nil
+	case tagNoSuchObj:
+		return "NoSuchObject", nil, nil
+	case tagNoSuchInst:
+		return "NoSuchInstance", nil, nil
+	case tagEndOfMIB:
+		return "EndOfMibView", nil, nil
+	}
+	return "", nil, fmt.Errorf("snmptrap: unsupported varbind type 0x%02x", tag)
+}
+
+// octetString returns printable strings as text and anything else (MAC
+// addresses, DateAndTime, ...) as colon separated hex.
+func octetString(v []byte) string {
+	if utf8.Valid(v) {
+		printable := true
+		for _, r := range string(v) {
+			if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+				printable = false
+				break
+			}
+		}
+		if printable {
+			return string(v)
+		}
+	}
+	parts := make([]string, len(v))
+	for i, c := range v {
+		parts[i] = fmt.Sprintf("%02x", c)
+	}
+	return strings.Join(parts, ":")
+}
+
+// encodeTLV is the inverse of readTLV, used to acknowledge informs.
+func encodeTLV(tag byte, value []byte) []byte {
+	out := []byte{tag}
+	switch n := len(value); {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n <= 0xff:
+		out = append(out, 0x81, byte(n))
+	case n <= 0xffff:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	default:
+		out = append(out, 0x84, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(out, value...)
+}
+
+func encodeInt(n int64) []byte {
+	b := []byte{byte(n)}
+	for (n > 127 || n < -128) && len(b) < 8 {
+		n >>= 8
+		b = append([]byte{byte(n)}, b...)
+	}
+	return encodeTLV(tagInteger, b)
+}
@@ -0,0 +1,225 @@
+package snmptrap
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// encodeOID is the inverse of parseOID, for building test packets.
+func encodeOID(t *testing.T, oid string) []byte {
+	t.Helper()
+	var arcs []uint64
+	for _, part := range strings.Split(oid, ".") {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			t.Fatalf("bad OID %q", oid)
+		}
+		arcs = append(arcs, n)
+	}
+	subs := append([]uint64{arcs[0]*40 + arcs[1]}, arcs[2:]...)
+	var out []byte
+	for _, sub := range subs {
+		chunk := []byte{byte(sub & 0x7f)}
+		for sub >>= 7; sub > 0; sub >>= 7 {
+			chunk = append([]byte{byte(sub&0x7f) | 0x80}, chunk...)
+		}
+		out = append(out, chunk...)
+	}
+	return out
+}
+
+func TestReadTLV(t *testing.T) {
+	long := bytes.Repeat([]byte{0xaa}, 300)
+	tests := []struct {
+		name      string
+		in        []byte
+		wantTag   byte
+		wantValue []byte
+		wantRest  []byte
+	}{
+		{"short form", []byte{0x04, 0x02, 'h', 'i', 0x05, 0x00}, 0x04, []byte("hi"), []byte{0x05, 0x00}},
+		{"empty value", []byte{0x05, 0x00}, 0x05, []byte{}, []byte{}},
+		{"one length byte", append([]byte{0x04, 0x81, 0x80}, bytes.Repeat([]byte{1}, 0x80)...), 0x04, bytes.Repeat([]byte{1}, 0x80), []byte{}},
+		{"two length bytes", append([]byte{0x04, 0x82, 0x01, 0x2c}, long...), 0x04, long, []byte{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, value, rest, err := readTLV(tt.in)
+			if err != nil {
+				t.Fatalf("readTLV error: %v", err)
+			}
+			if tag != tt.wantTag || !bytes.Equal(value, tt.wantValue) || !bytes.Equal(rest, tt.wantRest) {
+				t.Errorf("readTLV = 0x%02x %x %x, want 0x%02x %x %x", tag, value, rest, tt.wantTag, tt.wantValue, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestReadTLVRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"empty", nil},
+		{"tag only", []byte{0x04}},
+		{"multi-byte tag", []byte{0x1f, 0x01, 0x00}},
+		{"indefinite length", []byte{0x30, 0x80, 0x00, 0x00}},
+		{"five length bytes", []byte{0x04, 0x85, 0, 0, 0, 0, 1, 'x'}},
+		{"missing length bytes", []byte{0x04, 0x82, 0x01}},
+		{"value shorter than length", []byte{0x04, 0x05, 'a', 'b'}},
+		{"long form longer than data", []byte{0x04, 0x84, 0x7f, 0xff, 0xff, 0xff, 'a'}},
+		{"long form with high bit set", []byte{0x04, 0x84, 0xff, 0xff, 0xff, 0xff, 'a'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tag, value, _, err := readTLV(tt.in); err == nil {
+				t.Errorf("readTLV(%x) = 0x%02x %x, want an error", tt.in, tag, value)
+			}
+		})
+	}
+}
+
+func TestExpectChecksTag(t *testing.T) {
+	if _, _, err := expect([]byte{0x04, 0x00}, tagInteger); err == nil {
+		t.Error("expect accepted an OCTET STRING as INTEGER")
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want int64
+	}{
+		{[]byte{0x00}, 0},
+		{[]byte{0x7f}, 127},
+		{[]byte{0x80}, -128},
+		{[]byte{0xff}, -1},
+		{[]byte{0x00, 0x80}, 128},
+		{[]byte{0x01, 0x00}, 256},
+		{[]byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 1<<63 - 1},
+	}
+	for _, tt := range tests {
+		if got, err := parseInt(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseInt(%x) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range [][]byte{nil, make([]byte, 9)} {
+		if _, err := parseInt(in); err == nil {
+			t.Errorf("parseInt(%x) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestParseUint(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want uint64
+	}{
+		{[]byte{0x00}, 0},
+		{[]byte{0xff}, 255},
+		{[]byte{0x00, 0xff, 0xff, 0xff, 0xff}, 1<<32 - 1},
+		{[]byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 1<<64 - 1},
+	}
+	for _, tt := range tests {
+		if got, err := parseUint(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseUint(%x) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range [][]byte{nil, {0x01, 0, 0, 0, 0, 0, 0, 0, 0}, make([]byte, 10)} {
+		if _, err := parseUint(in); err == nil {
+			t.Errorf("parseUint(%x) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestParseOID(t *testing.T) {
+	for _, oid := range []string{"1.3.6.1.6.3.1.1.4.1.0", "0.0", "1.2.840.113549", "2.999.3", "1.3.6.1.4.1.4294967295"} {
+		got, err := parseOID(encodeOID(t, oid))
+		if err != nil || got != oid {
+			t.Errorf("parseOID(%s) = %q, %v", oid, got, err)
+		}
+	}
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"empty", nil},
+		{"continuation at the end", []byte{0x2b, 0x86}},
+		{"subidentifier overflows", append([]byte{0x2b}, append(bytes.Repeat([]byte{0xff}, 10), 0x7f)...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := parseOID(tt.in); err == nil {
+				t.Errorf("parseOID(%x) = %q, want an error", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		tag      byte
+		in       []byte
+		wantType string
+		want     interface{}
+	}{
+		{tagInteger, []byte{0x2a}, "Integer", int64(42)},
+		{tagOctetString, []byte("eth0"), "OctetString", "eth0"},
+		{tagOctetString, []byte{0x00, 0x1a, 0x2b}, "OctetString", "00:1a:2b"},
+		{tagOctetString, []byte{0xff, 0xfe}, "OctetString", "ff:fe"},
+		{tagNull, nil, "Null", nil},
+		{tagIPAddress, []byte{10, 0, 0, 1}, "IpAddress", "10.0.0.1"},
+		{tagCounter32, []byte{0x01, 0x00}, "Counter32", uint64(256)},
+		{tagGauge32, []byte{0x05}, "Gauge32", uint64(5)},
+		{tagTimeTicks, []byte{0x00, 0xff}, "TimeTicks", uint64(255)},
+		{tagCounter64, []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, "Counter64", uint64(1<<64 - 1)},
+		{tagOpaque, []byte{0xde, 0xad}, "Opaque", "dead"},
+		{tagNoSuchObj, nil, "NoSuchObject", nil},
+		{tagNoSuchInst, nil, "NoSuchInstance", nil},
+		{tagEndOfMIB, nil, "EndOfMibView", nil},
+	}
+	for _, tt := range tests {
+		typ, got, err := parseValue(tt.tag, tt.in)
+		if err != nil || typ != tt.wantType || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseValue(0x%02x, %x) = %s %#v %v, want %s %#v", tt.tag, tt.in, typ, got, err, tt.wantType, tt.want)
+		}
+	}
+
+	rejects := []struct {
+		tag byte
+		in  []byte
+	}{
+		{tagIPAddress, []byte{10, 0, 0}},
+		{tagInteger, nil},
+		{tagCounter32, nil},
+		{tagOID, nil},
+		{0x47, []byte{0x01}},
+	}
+	for _, tt := range rejects {
+		if typ, got, err := parseValue(tt.tag, tt.in); err == nil {
+			t.Errorf("parseValue(0x%02x, %x) = %s %#v, want an error", tt.tag, tt.in, typ, got)
+		}
+	}
+}
+
+func TestEncodeTLVRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, 0x7f, 0x80, 0xff, 0x100, 0xffff, 0x10000} {
+		value := bytes.Repeat([]byte{0x5a}, n)
+		tag, got, rest, err := readTLV(encodeTLV(tagOctetString, value))
+		if err != nil || tag != tagOctetString || !bytes.Equal(got, value) || len(rest) != 0 {
+			t.Errorf("round trip of %d bytes failed: tag 0x%02x, %d bytes, rest %d, %v", n, tag, len(got), len(rest), err)
+		}
+	}
+	for _, n := range []int64{0, 1, -1, 127, 128, -128, -129, 1 << 40, -(1 << 40)} {
+		v, _, err := expect(encodeInt(n), tagInteger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := parseInt(v); err != nil || got != n {
+			t.Errorf("encodeInt(%d) decodes to %d, %v", n, got, err)
+		}
+	}
+}
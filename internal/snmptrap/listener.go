@@ -0,0 +1,256 @@
+package snmptrap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// maxPacketSize is the largest UDP datagram accepted
+const maxPacketSize = 64 * 1024
+
+// workers bounds how many traps are processed concurrently
+const workers = 32
+
+// Pipeline connects the receiver to alert storage; the functions match
+// handlers.IngestAlert, handlers.ResolveAlert and handlers.LoadSNMPTrapConfig.
+type Pipeline struct {
+	Ingest  func(ctx context.Context, alert models.DbAlert) (models.DbAlert, bool, error)
+	Resolve func(ctx context.Context, fingerprint string, clearTime time.Time, comment string) (bool, error)
+	Load    func(ctx context.Context) (models.DbSNMPTrapConfig, error)
+}
+
+// Config selects the listen address and the accepted communities.
+type Config struct {
+	Addr        string   // disabled when empty
+	Communities []string // any community when empty
+}
+
+// ConfigFromEnv reads SNMP_TRAP_ADDR and the comma separated SNMP_TRAP_COMMUNITY.
+func ConfigFromEnv() Config {
+	cfg := Config{Addr: os.Getenv("SNMP_TRAP_ADDR")}
+	for _, c := range strings.Split(os.Getenv("SNMP_TRAP_COMMUNITY"), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cfg.Communities = append(cfg.Communities, c)
+		}
+	}
+	return cfg
+}
+
+type receiver struct {
+	cfg      Config
+	pipeline Pipeline
+}
+
+// Start opens the UDP trap listener and serves it in the background.
+func Start(cfg Config, pipeline Pipeline) error {
+	if cfg.Addr == "" {
+		return nil
+	}
+	conn, err := net.ListenPacket("udp", cfg.Addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("SNMP trap receiver listening on udp %s", cfg.Addr)
+	go (&receiver{cfg: cfg, pipeline: pipeline}).serve(conn)
+	return nil
+}
+
+func (r *receiver) serve(conn net.PacketConn) {
+	buf := make([]byte, maxPacketSize)
+	sem := make(chan struct{}, workers)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			log.Printf("SNMP trap read failed: %v", err)
+			return
+		}
+		datagram := append([]byte(nil), buf[:n]...)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem }()
+			r.handle(conn, datagram, addr)
+		}()
+	}
+}
+
+func (r *receiver) handle(conn net.PacketConn, datagram []byte, from net.Addr) {
+	pkt, err := Decode(datagram)
+	if err != nil {
+		log.Printf("SNMP trap from %s dropped: %v", from, err)
+		return
+	}
+	if !r.communityAllowed(pkt.Community) {
+		log.Printf("SNMP trap from %s dropped: unknown community", from)
+		return
+	}
+	if pkt.Inform {
+		if _, err := conn.WriteTo(pkt.response(), from); err != nil {
+			log.Printf("SNMP inform from %s not acknowledged: %v", from, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var cfg models.DbSNMPTrapConfig
+	if r.pipeline.Load != nil {
+		if cfg, err = r.pipeline.Load(ctx); err != nil {
+			log.Printf("Failed to load SNMP trap configuration, using built-in OIDs: %v", err)
+		}
+	}
+	resolver := NewResolver(cfg)
+
+	trapOID, err := pkt.TrapOID()
+	if err != nil {
+		log.Printf("SNMP trap from %s dropped: %v", from, err)
+		return
+	}
+
+	// A clear trap closes the open alert raised by its problem trap
+	if rule, ok := resolver.clearingRule(trapOID); ok {
+		name, _ := resolver.Name(trapOID)
+		fp := trapFingerprint(agentAddress(pkt, from), rule.trapOID, matchValues(pkt, rule.match))
+		if _, err := r.pipeline.Resolve(ctx, fp, time.Now(), fmt.Sprintf("Cleared by SNMP %s trap", name)); err != nil {
+			log.Printf("SNMP %s trap from %s did not clear: %v", name, from, err)
+		}
+		return
+	}
+
+	if _, _, err := r.pipeline.Ingest(ctx, ToAlert(pkt, trapOID, agentAddress(pkt, from), resolver)); err != nil {
+		log.Printf("SNMP trap from %s not stored: %v", from, err)
+	}
+}
+
+func (r *receiver) communityAllowed(community string) bool {
+	if len(r.cfg.Communities) == 0 {
+		return true
+	}
+	for _, c := range r.cfg.Communities {
+		if c == community {
+			return true
+		}
+	}
+	return false
+}
+
+// detailNumber stores an unsigned counter as int64, which BSON can encode,
+// and as a decimal string when it is too large for one (Counter64).
+func detailNumber(n uint64) interface{} {
+	if n > math.MaxInt64 {
+		return strconv.FormatUint(n, 10)
+	}
+	return int64(n)
+}
+
+// ToAlert maps a trap onto an alert. The varbinds are stored in
+// AdditionalDetails under their resolved names, minus the instance suffix.
+func ToAlert(pkt Packet, trapOID, agent string, resolver *Resolver) models.DbAlert {
+	name, _ := resolver.Name(trapOID)
+
+	entity := agent
+	if vb, ok := pkt.Varbind(oidSysName); ok {
+		if s, ok := vb.Value.(string); ok && s != "" {
+			entity = s
+		}
+	}
+
+	details := map[string]interface{}{
+		"trap_oid":  trapOID,
+		"trap_name": name,
+		"uptime":    detailNumber(pkt.Uptime()),
+		"agent":     agent,
+	}
+	if pkt.Inform {
+		details["inform"] = true
+	}
+	for _, vb := range pkt.Varbinds {
+		if vb.OID == oidSysUpTime || vb.OID == oidSnmpTrapOID {
+			continue
+		}
+		key, instance := resolver.Name(vb.OID)
+		if key == vb.OID {
+			key = "oid_" + strings.ReplaceAll(vb.OID, ".", "_")
+		}
+		if _, taken := details[key]; taken && instance != "" {
+			key += "_" + strings.ReplaceAll(instance, ".", "_")
+		}
+		value := vb.Value
+		if oid, ok := value.(string); ok && vb.Type == "ObjectIdentifier" {
+			value, _ = resolver.Name(oid)
+		}
+		if n, ok := value.(uint64); ok {
+			value = detailNumber(n)
+		}
+		details[key] = value
+	}
+
+	var match []string
+	if rule, ok := resolver.problemRule(trapOID); ok {
+		match = matchValues(pkt, rule.match)
+	}
+
+	return models.DbAlert{
+		Entity:            entity,
+		IpAddress:         agent,
+		AlertSource:       "snmptrap",
+		AlertSummary:      fmt.Sprintf("%s trap from %s", name, entity),
+		AlertType:         name,
+		Severity:          resolver.Severity(trapOID),
+		AdditionalDetails: details,
+		Fingerprint:       trapFingerprint(agent, trapOID, match),
+	}
+}
+
+// agentAddress prefers snmpTrapAddress.0, set when a proxy forwards the trap.
+func agentAddress(pkt Packet, from net.Addr) string {
+	if vb, ok := pkt.Varbind(oidSnmpTrapAddress); ok {
+		if s, ok := vb.Value.(string); ok && s != "" {
+			return s
+		}
+	}
+	if from == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(from.String())
+	if err != nil {
+		return from.String()
+	}
+	return host
+}
+
+// matchValues returns the values of the varbinds under each match OID,
+// e.g. the ifIndex of a linkDown. Missing varbinds yield "".
+func matchValues(pkt Packet, match []string) []string {
+	values := make([]string, len(match))
+	for i, oid := range match {
+		for _, vb := range pkt.Varbinds {
+			if vb.OID == oid || strings.HasPrefix(vb.OID, oid+".") {
+				values[i] = fmt.Sprint(vb.Value)
+				break
+			}
+		}
+	}
+	return values
+}
+
+// trapFingerprint identifies the alert raised by a problem trap; a clear trap
+// computes the same value from the problem trap OID to find it again.
+func trapFingerprint(agent, trapOID string, match []string) string {
+	h := sha256.New()
+	for _, part := range append([]string{agent, trapOID}, match...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
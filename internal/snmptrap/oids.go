@@ -0,0 +1,210 @@
+package snmptrap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// builtinOIDs covers the generic SNMPv2-MIB traps and the IF-MIB columns
+// that usually travel with them. The stored configuration can override any entry.
+var builtinOIDs = []models.SNMPOIDName{
+	{OID: "1.3.6.1.2.1.1.1", Name: "sysDescr"},
+	{OID: "1.3.6.1.2.1.1.3", Name: "sysUpTime"},
+	{OID: "1.3.6.1.2.1.1.5", Name: "sysName"},
+	{OID: "1.3.6.1.2.1.1.6", Name: "sysLocation"},
+	{OID: "1.3.6.1.6.3.1.1.4.1", Name: "snmpTrapOID"},
+	{OID: "1.3.6.1.6.3.1.1.4.3", Name: "snmpTrapEnterprise"},
+	{OID: "1.3.6.1.6.3.18.1.3", Name: "snmpTrapAddress"},
+	{OID: "1.3.6.1.6.3.18.1.4", Name: "snmpTrapCommunity"},
+	{OID: "1.3.6.1.6.3.1.1.5.1", Name: "coldStart", Severity: "WARN"},
+	{OID: "1.3.6.1.6.3.1.1.5.2", Name: "warmStart", Severity: "WARN"},
+	{OID: "1.3.6.1.6.3.1.1.5.3", Name: "linkDown", Severity: "ERROR"},
+	{OID: "1.3.6.1.6.3.1.1.5.4", Name: "linkUp", Severity: "INFO"},
+	{OID: "1.3.6.1.6.3.1.1.5.5", Name: "authenticationFailure", Severity: "WARN"},
+	{OID: "1.3.6.1.2.1.2.2.1.1", Name: "ifIndex"},
+	{OID: "1.3.6.1.2.1.2.2.1.2", Name: "ifDescr"},
+	{OID: "1.3.6.1.2.1.2.2.1.7", Name: "ifAdminStatus"},
+	{OID: "1.3.6.1.2.1.2.2.1.8", Name: "ifOperStatus"},
+	{OID: "1.3.6.1.2.1.31.1.1.1.1", Name: "ifName"},
+	{OID: "1.3.6.1.2.1.31.1.1.1.18", Name: "ifAlias"},
+}
+
+var builtinClearRules = []models.SNMPClearRule{
+	{Name: "link", TrapOID: "linkDown", ClearOID: "linkUp", MatchVarbinds: []string{"ifIndex"}},
+}
+
+// defaultSeverity applies to traps without a configured severity
+const defaultSeverity = "WARN"
+
+// clearRule is a SNMPClearRule with names resolved to OIDs.
+type clearRule struct {
+	name     string
+	trapOID  string
+	clearOID string
+	match    []string
+}
+
+// Resolver names OIDs and finds clear pairings for a configuration.
+type Resolver struct {
+	names      map[string]string // OID -> name
+	oids       map[string]string // name -> OID
+	severities map[string]string // trap OID -> severity
+	clears     []clearRule
+}
+
+// NewResolver merges cfg over the built-in tables. Clear rules whose trap or
+// clear OID cannot be resolved are skipped; Validate reports them on save.
+func NewResolver(cfg models.DbSNMPTrapConfig) *Resolver {
+	r := &Resolver{names: map[string]string{}, oids: map[string]string{}, severities: map[string]string{}}
+	for _, list := range [][]models.SNMPOIDName{builtinOIDs, cfg.OIDNames} {
+		for _, entry := range list {
+			oid := strings.TrimPrefix(strings.TrimSpace(entry.OID), ".")
+			r.names[oid] = entry.Name
+			r.oids[entry.Name] = oid
+			if entry.Severity != "" {
+				r.severities[oid] = strings.ToUpper(entry.Severity)
+			}
+		}
+	}
+
+	overridden := map[string]bool{}
+	for _, rule := range cfg.ClearRules {
+		if c, err := r.resolveClearRule(rule); err == nil {
+			r.clears = append(r.clears, c)
+			overridden[c.trapOID] = true
+		}
+	}
+	for _, rule := range builtinClearRules {
+		if c, err := r.resolveClearRule(rule); err == nil && !overridden[c.trapOID] {
+			r.clears = append(r.clears, c)
+		}
+	}
+	return r
+}
+
+func (r *Resolver) resolveClearRule(rule models.SNMPClearRule) (clearRule, error) {
+	trapOID, ok := r.OID(rule.TrapOID)
+	if !ok {
+		return clearRule{}, fmt.Errorf("unknown trap OID %q", rule.TrapOID)
+	}
+	clearOID, ok := r.OID(rule.ClearOID)
+	if !ok {
+		return clearRule{}, fmt.Errorf("unknown clear OID %q", rule.ClearOID)
+	}
+	c := clearRule{name: rule.Name, trapOID: trapOID, clearOID: clearOID}
+	for _, m := range rule.MatchVarbinds {
+		oid, ok := r.OID(m)
+		if !ok {
+			return clearRule{}, fmt.Errorf("unknown varbind %q", m)
+		}
+		c.match = append(c.match, oid)
+	}
+	return c, nil
+}
+
+// OID resolves a name or a dotted OID to a dotted OID.
+func (r *Resolver) OID(nameOrOID string) (string, bool) {
+	s := strings.TrimPrefix(strings.TrimSpace(nameOrOID), ".")
+	if isNumericOID(s) {
+		return s, true
+	}
+	oid, ok := r.oids[s]
+	return oid, ok
+}
+
+// Name returns the name of the longest known prefix of oid and the remaining
+// instance suffix, e.g. "1.3.6.1.2.1.2.2.1.1.3" -> ("ifIndex", "3").
+// Unknown OIDs are returned unchanged with an empty instance.
+func (r *Resolver) Name(oid string) (string, string) {
+	for prefix := oid; prefix != ""; {
+		if name, ok := r.names[prefix]; ok {
+			return name, strings.TrimPrefix(oid[len(prefix):], ".")
+		}
+		dot := strings.LastIndexByte(prefix, '.')
+		if dot < 0 {
+			break
+		}
+		prefix = prefix[:dot]
+	}
+	return oid, ""
+}
+
+// Severity returns the alert severity for a trap OID.
+func (r *Resolver) Severity(trapOID string) string {
+	if s, ok := r.severities[trapOID]; ok {
+		return s
+	}
+	return defaultSeverity
+}
+
+// problemRule returns the clear rule whose problem trap is trapOID.
+func (r *Resolver) problemRule(trapOID string) (clearRule, bool) {
+	for _, c := range r.clears {
+		if c.trapOID == trapOID {
+			return c, true
+		}
+	}
+	return clearRule{}, false
+}
+
+// clearingRule returns the clear rule closed by trapOID.
+func (r *Resolver) clearingRule(trapOID string) (clearRule, bool) {
+	for _, c := range r.clears {
+		if c.clearOID == trapOID {
+			return c, true
+		}
+	}
+	return clearRule{}, false
+}
+
+// Validate checks a configuration before it is saved.
+func Validate(cfg models.DbSNMPTrapConfig) error {
+	for _, entry := range cfg.OIDNames {
+		if !isNumericOID(strings.TrimPrefix(strings.TrimSpace(entry.OID), ".")) {
+			return fmt.Errorf("invalid OID %q", entry.OID)
+		}
+		if entry.Name == "" {
+			return fmt.Errorf("OID %s has no name", entry.OID)
+		}
+		switch strings.ToUpper(entry.Severity) {
+		case "", "CRITICAL", "ERROR", "WARN", "INFO":
+		default:
+			return fmt.Errorf("OID %s has unknown severity %q", entry.OID, entry.Severity)
+		}
+	}
+
+	r := NewResolver(models.DbSNMPTrapConfig{OIDNames: cfg.OIDNames})
+	for _, rule := range cfg.ClearRules {
+		if rule.TrapOID == "" || rule.ClearOID == "" {
+			return errors.New("clear rules need a trap_oid and a clear_oid")
+		}
+		c, err := r.resolveClearRule(rule)
+		if err != nil {
+			return fmt.Errorf("clear rule %q: %v", rule.Name, err)
+		}
+		if c.trapOID == c.clearOID {
+			return fmt.Errorf("clear rule %q: trap and clear OID are the same", rule.Name)
+		}
+	}
+	return nil
+}
+
+func isNumericOID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, part := range strings.Split(s, ".") {
+		if part == "" {
+			return false
+		}
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+// Package snmptrap receives SNMPv2c traps and informs and turns them into
+// alerts, naming OIDs from a configurable table and closing alerts when the
+// paired clear trap (e.g. linkUp after linkDown) arrives.
+package snmptrap
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Well-known varbinds carried by every SNMPv2 trap (RFC 3416 4.2.6)
+const (
+	oidSysUpTime       = "1.3.6.1.2.1.1.3.0"
+	oidSnmpTrapOID     = "1.3.6.1.6.3.1.1.4.1.0"
+	oidSnmpTrapAddress = "1.3.6.1.6.3.18.1.3.0"
+	oidSysName         = "1.3.6.1.2.1.1.5.0"
+)
+
+// Varbind is one decoded variable binding.
+type Varbind struct {
+	OID   string
+	Type  string
+	Value interface{}
+}
+
+// Packet is a decoded SNMPv2c trap or inform.
+type Packet struct {
+	Community string
+	Inform    bool
+	RequestID int64
+	Varbinds  []Varbind
+
+	rawVarbinds []byte // re-sent verbatim when acknowledging an inform
+}
+
+// Decode parses an SNMPv2c message carrying an SNMPv2-Trap or InformRequest PDU.
+func Decode(b []byte) (Packet, error) {
+	var pkt Packet
+
+	msg, _, err := expect(b, tagSequence)
+	if err != nil {
+		return pkt, err
+	}
+	v, msg, err := expect(msg, tagInteger)
+	if err != nil {
+		return pkt, err
+	}
+	version, err := parseInt(v)
+	if err != nil {
+		return pkt, err
+	}
+	if version != 1 {
+		return pkt, fmt.Errorf("snmptrap: unsupported SNMP version %d, only v2c is accepted", version+1)
+	}
+	community, msg, err := expect(msg, tagOctetString)
+	if err != nil {
+		return pkt, err
+	}
+	pkt.Community = string(community)
+
+	pduType, pdu, _, err := readTLV(msg)
+	if err != nil {
+		return pkt, err
+	}
+	switch pduType {
+	case pduTrapV2:
+	case pduInform:
+		pkt.Inform = true
+	default:
+		return pkt, fmt.Errorf("snmptrap: unexpected PDU type 0x%02x", pduType)
+	}
+
+	// request-id, error-status, error-index
+	for i := 0; i < 3; i++ {
+		if v, pdu, err = expect(pdu, tagInteger); err != nil {
+			return pkt, err
+		}
+		if i == 0 {
+			if pkt.RequestID, err = parseInt(v); err != nil {
+				return pkt, err
+			}
+		}
+	}
+
+	list, _, err := expect(pdu, tagSequence)
+	if err != nil {
+		return pkt, err
+	}
+	pkt.rawVarbinds = encodeTLV(tagSequence, list)
+	for len(list) > 0 {
+		var vb []byte
+		if vb, list, err = expect(list, tagSequence); err != nil {
+			return pkt, err
+		}
+		oidBytes, rest, err := expect(vb, tagOID)
+		if err != nil {
+			return pkt, err
+		}
+		oid, err := parseOID(oidBytes)
+		if err != nil {
+			return pkt, err
+		}
+		tag, value, _, err := readTLV(rest)
+		if err != nil {
+			return pkt, err
+		}
+		typ, val, err := parseValue(tag, value)
+		if err != nil {
+			return pkt, err
+		}
+		pkt.Varbinds = append(pkt.Varbinds, Varbind{OID: oid, Type: typ, Value: val})
+	}
+	return pkt, nil
+}
+
+// TrapOID returns the value of snmpTrapOID.0, which identifies the trap.
+func (p Packet) TrapOID() (string, error) {
+	// RFC 3416 requires it to be the second varbind, but be lenient about order
+	if vb, ok := p.Varbind(oidSnmpTrapOID); ok {
+		if oid, ok := vb.Value.(string); ok && vb.Type == "ObjectIdentifier" {
+			return oid, nil
+		}
+	}
+	return "", errors.New("snmptrap: trap carries no snmpTrapOID.0")
+}
+
+// Uptime returns sysUpTime.0 in hundredths of a second.
+func (p Packet) Uptime() uint64 {
+	if vb, ok := p.Varbind(oidSysUpTime); ok {
+		if n, ok := vb.Value.(uint64); ok {
+			return n
+		}
+	}
+	return 0
+}
+
+// Varbind looks up a varbind by its exact OID.
+func (p Packet) Varbind(oid string) (Varbind, bool) {
+	for _, vb := range p.Varbinds {
+		if vb.OID == oid {
+			return vb, true
+		}
+	}
+	return Varbind{}, false
+}
+
+// response builds the Response PDU acknowledging an inform.
+func (p Packet) response() []byte {
+	pdu := append(encodeInt(p.RequestID), encodeInt(0)...)
+	pdu = append(pdu, encodeInt(0)...)
+	pdu = append(pdu, p.rawVarbinds...)
+
+	msg := append(encodeInt(1), encodeTLV(tagOctetString, []byte(p.Community))...)
+	msg = append(msg, encodeTLV(pduResponse, pdu)...)
+	return encodeTLV(tagSequence, msg)
+}
@@ -0,0 +1,132 @@
+package snmptrap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func varbind(t *testing.T, oid string, tag byte, value []byte) []byte {
+	return encodeTLV(tagSequence, append(encodeTLV(tagOID, encodeOID(t, oid)), encodeTLV(tag, value)...))
+}
+
+// message builds an SNMP message with the given version, PDU type and
+// varbinds.
+func message(t *testing.T, version int64, pduType byte, varbinds ...[]byte) []byte {
+	var list []byte
+	for _, vb := range varbinds {
+		list = append(list, vb...)
+	}
+	pdu := append(encodeInt(1234), encodeInt(0)...)
+	pdu = append(pdu, encodeInt(0)...)
+	pdu = append(pdu, encodeTLV(tagSequence, list)...)
+
+	msg := append(encodeInt(version), encodeTLV(tagOctetString, []byte("public"))...)
+	msg = append(msg, encodeTLV(pduType, pdu)...)
+	return encodeTLV(tagSequence, msg)
+}
+
+func linkDown(t *testing.T, pduType byte) []byte {
+	return message(t, 1, pduType,
+		varbind(t, oidSysUpTime, tagTimeTicks, []byte{0x01, 0x00}),
+		varbind(t, oidSnmpTrapOID, tagOID, encodeOID(t, "1.3.6.1.6.3.1.1.5.3")),
+		varbind(t, "1.3.6.1.2.1.2.2.1.1.7", tagInteger, []byte{7}),
+		varbind(t, oidSysName, tagOctetString, []byte("sw-01")),
+	)
+}
+
+func TestDecode(t *testing.T) {
+	pkt, err := Decode(linkDown(t, pduTrapV2))
+	if err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	want := []Varbind{
+		{OID: oidSysUpTime, Type: "TimeTicks", Value: uint64(256)},
+		{OID: oidSnmpTrapOID, Type: "ObjectIdentifier", Value: "1.3.6.1.6.3.1.1.5.3"},
+		{OID: "1.3.6.1.2.1.2.2.1.1.7", Type: "Integer", Value: int64(7)},
+		{OID: oidSysName, Type: "OctetString", Value: "sw-01"},
+	}
+	if pkt.Community != "public" || pkt.Inform || pkt.RequestID != 1234 || !reflect.DeepEqual(pkt.Varbinds, want) {
+		t.Errorf("Decode = %+v", pkt)
+	}
+	if oid, err := pkt.TrapOID(); err != nil || oid != "1.3.6.1.6.3.1.1.5.3" {
+		t.Errorf("TrapOID = %q, %v", oid, err)
+	}
+	if up := pkt.Uptime(); up != 256 {
+		t.Errorf("Uptime = %d, want 256", up)
+	}
+}
+
+func TestDecodeInformResponse(t *testing.T) {
+	pkt, err := Decode(linkDown(t, pduInform))
+	if err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if !pkt.Inform {
+		t.Fatal("inform not flagged")
+	}
+
+	// The response echoes the request id and varbinds in a Response PDU
+	msg, rest, err := expect(pkt.response(), tagSequence)
+	if err != nil || len(rest) != 0 {
+		t.Fatalf("response is not one sequence: %v", err)
+	}
+	if _, msg, err = expect(msg, tagInteger); err != nil {
+		t.Fatal(err)
+	}
+	if _, msg, err = expect(msg, tagOctetString); err != nil {
+		t.Fatal(err)
+	}
+	pdu, _, err := expect(msg, pduResponse)
+	if err != nil {
+		t.Fatalf("response PDU: %v", err)
+	}
+	id, _, err := expect(pdu, tagInteger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := parseInt(id); n != 1234 {
+		t.Errorf("response request id = %d, want 1234", n)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	valid := linkDown(t, pduTrapV2)
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"empty", nil},
+		{"not a sequence", encodeTLV(tagOctetString, []byte("hello"))},
+		{"SNMPv1", message(t, 0, pduTrapV2)},
+		{"SNMPv3", message(t, 3, pduTrapV2)},
+		{"get request", message(t, 1, 0xa0)},
+		{"unsupported varbind type", message(t, 1, pduTrapV2, varbind(t, oidSysName, 0x47, []byte{1}))},
+		{"varbind without a value", message(t, 1, pduTrapV2, encodeTLV(tagSequence, encodeTLV(tagOID, encodeOID(t, oidSysName))))},
+		{"varbind that is not a sequence", message(t, 1, pduTrapV2, encodeTLV(tagInteger, []byte{1}))},
+		{"outer length past the data", append([]byte{tagSequence, 0x84, 0x7f, 0xff, 0xff, 0xff}, valid[2:]...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if pkt, err := Decode(tt.in); err == nil {
+				t.Errorf("Decode = %+v, want an error", pkt)
+			}
+		})
+	}
+
+	// Every truncation of a valid trap is an error, never a panic
+	for n := 0; n < len(valid); n++ {
+		if _, err := Decode(valid[:n]); err == nil {
+			t.Errorf("Decode of the first %d of %d bytes succeeded", n, len(valid))
+		}
+	}
+}
+
+func TestTrapOIDMissing(t *testing.T) {
+	pkt, err := Decode(message(t, 1, pduTrapV2, varbind(t, oidSysName, tagOctetString, []byte("sw-01"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pkt.TrapOID(); err == nil {
+		t.Error("TrapOID succeeded on a trap without snmpTrapOID.0")
+	}
+}
@@ -0,0 +1,122 @@
+package syslog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// selector is one syslog.conf style "facility.severity" pattern.
+// "*.err" selects err and anything more severe, "kern.=warning" only warning.
+type selector struct {
+	facility int // -1 for any
+	severity int // -1 for any
+	exact    bool
+}
+
+// Filter decides which messages become alerts. A message must match an
+// include selector (when any are configured) and no exclude selector.
+type Filter struct {
+	include []selector
+	exclude []selector
+}
+
+// NewFilter parses comma or semicolon separated selector lists,
+// e.g. include "*.err;local7.*" and exclude "auth.*".
+func NewFilter(include, exclude string) (*Filter, error) {
+	inc, err := parseSelectors(include)
+	if err != nil {
+		return nil, err
+	}
+	exc, err := parseSelectors(exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{include: inc, exclude: exc}, nil
+}
+
+// Allow reports whether msg passes the filter.
+func (f *Filter) Allow(msg Message) bool {
+	for _, s := range f.exclude {
+		if s.matches(msg) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, s := range f.include {
+		if s.matches(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s selector) matches(msg Message) bool {
+	if s.facility >= 0 && s.facility != msg.Facility {
+		return false
+	}
+	if s.severity < 0 {
+		return true
+	}
+	if s.exact {
+		return msg.Severity == s.severity
+	}
+	// Lower numbers are more severe
+	return msg.Severity <= s.severity
+}
+
+func parseSelectors(list string) ([]selector, error) {
+	selectors := []selector{}
+	for _, part := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ';' }) {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		dot := strings.LastIndexByte(part, '.')
+		if dot < 0 {
+			return nil, fmt.Errorf("syslog: selector %q must be facility.severity", part)
+		}
+		fac, sev := part[:dot], part[dot+1:]
+
+		s := selector{facility: -1, severity: -1}
+		if fac != "*" {
+			if s.facility = indexOf(facilityNames, fac); s.facility < 0 {
+				return nil, fmt.Errorf("syslog: unknown facility %q", fac)
+			}
+		}
+		if strings.HasPrefix(sev, "=") {
+			s.exact = true
+			sev = sev[1:]
+		}
+		if sev != "*" {
+			if s.severity = indexOf(severityNames, severityAlias(sev)); s.severity < 0 {
+				return nil, fmt.Errorf("syslog: unknown severity %q", sev)
+			}
+		}
+		selectors = append(selectors, s)
+	}
+	return selectors, nil
+}
+
+// severityAlias accepts the deprecated spellings still common in syslog.conf
+func severityAlias(s string) string {
+	switch s {
+	case "panic":
+		return "emerg"
+	case "error":
+		return "err"
+	case "warn":
+		return "warning"
+	}
+	return s
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
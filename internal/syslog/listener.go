@@ -0,0 +1,300 @@
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// maxMessageSize bounds a single syslog message on any transport
+const maxMessageSize = 64 * 1024
+
+// IngestFunc stores an alert; it matches handlers.IngestAlert.
+type IngestFunc func(ctx context.Context, alert models.DbAlert) (models.DbAlert, bool, error)
+
+// Config selects the transports to listen on. Empty addresses are disabled.
+type Config struct {
+	UDPAddr string
+	TCPAddr string
+	TLSAddr string
+	TLSCert string
+	TLSKey  string
+	Include string
+	Exclude string
+}
+
+// ConfigFromEnv reads the listener configuration from SYSLOG_* variables.
+// Only warning and more severe messages become alerts unless SYSLOG_INCLUDE says otherwise.
+func ConfigFromEnv() Config {
+	include := os.Getenv("SYSLOG_INCLUDE")
+	if include == "" {
+		include = "*.warning"
+	}
+	return Config{
+		UDPAddr: os.Getenv("SYSLOG_UDP_ADDR"),
+		TCPAddr: os.Getenv("SYSLOG_TCP_ADDR"),
+		TLSAddr: os.Getenv("SYSLOG_TLS_ADDR"),
+		TLSCert: os.Getenv("SYSLOG_TLS_CERT"),
+		TLSKey:  os.Getenv("SYSLOG_TLS_KEY"),
+		Include: include,
+		Exclude: os.Getenv("SYSLOG_EXCLUDE"),
+	}
+}
+
+// udpWorkers bounds how many datagrams are ingested concurrently
+const udpWorkers = 32
+
+type server struct {
+	filter *Filter
+	ingest IngestFunc
+}
+
+// Start opens the configured listeners and serves them in the background.
+func Start(cfg Config, ingest IngestFunc) error {
+	filter, err := NewFilter(cfg.Include, cfg.Exclude)
+	if err != nil {
+		return err
+	}
+	srv := &server{filter: filter, ingest: ingest}
+
+	if cfg.UDPAddr != "" {
+		conn, err := net.ListenPacket("udp", cfg.UDPAddr)
+		if err != nil {
+			return err
+		}
+		log.Printf("Syslog listening on udp %s", cfg.UDPAddr)
+		go srv.serveUDP(conn)
+	}
+	if cfg.TCPAddr != "" {
+		ln, err := net.Listen("tcp", cfg.TCPAddr)
+		if err != nil {
+			return err
+		}
+		log.Printf("Syslog listening on tcp %s", cfg.TCPAddr)
+		go srv.serveStream(ln)
+	}
+	if cfg.TLSAddr != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return err
+		}
+		ln, err := tls.Listen("tcp", cfg.TLSAddr, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+		if err != nil {
+			return err
+		}
+		log.Printf("Syslog listening on tls %s", cfg.TLSAddr)
+		go srv.serveStream(ln)
+	}
+	return nil
+}
+
+func (s *server) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, maxMessageSize)
+	sem := make(chan struct{}, udpWorkers)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			log.Printf("Syslog udp read failed: %v", err)
+			return
+		}
+		datagram := append([]byte(nil), buf[:n]...)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem }()
+			s.handle(datagram, addr)
+		}()
+	}
+}
+
+func (s *server) serveStream(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Printf("Syslog accept failed: %v", err)
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn reads RFC 6587 framed messages, accepting both octet counting
+// ("<len> <msg>") and newline-terminated framing on the same connection.
+func (s *server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReaderSize(conn, maxMessageSize)
+	for {
+		frame, err := readFrame(r)
+		if len(frame) > 0 {
+			s.handle(frame, conn.RemoteAddr())
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("Syslog connection from %s closed: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+	}
+}
+
+// readFrame reads one message. r must be sized maxMessageSize, which bounds
+// newline-terminated messages as well as octet counts.
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	if octetCounted(r) {
+		lenStr, err := r.ReadString(' ')
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(lenStr))
+		if err != nil || n > maxMessageSize {
+			return nil, errors.New("invalid octet count")
+		}
+		frame := make([]byte, n)
+		_, err = io.ReadFull(r, frame)
+		return frame, err
+	}
+	line, err := r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return nil, errors.New("message too long")
+	}
+	// ReadSlice's result is overwritten by the next read
+	return append([]byte(nil), line...), err
+}
+
+// octetCounted reports whether the next frame starts like "<len> <", peeking
+// one byte at a time so that a short newline-terminated message is not held
+// back waiting for more input.
+func octetCounted(r *bufio.Reader) bool {
+	maxDigits := len(strconv.Itoa(maxMessageSize))
+	for i := 1; i <= maxDigits+1; i++ {
+		b, err := r.Peek(i)
+		if err != nil {
+			return false
+		}
+		switch c := b[i-1]; {
+		case c >= '0' && c <= '9' && i <= maxDigits:
+			if i == 1 && c == '0' {
+				return false
+			}
+		case c == ' ' && i > 1:
+			next, err := r.Peek(i + 1)
+			return err == nil && next[i] == '<'
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func (s *server) handle(raw []byte, from net.Addr) {
+	msg, err := Parse(raw)
+	if err != nil {
+		log.Printf("Syslog message from %s dropped: %v", from, err)
+		return
+	}
+	if !s.filter.Allow(msg) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, _, err := s.ingest(ctx, ToAlert(msg, senderIP(from))); err != nil {
+		log.Printf("Syslog alert from %s not stored: %v", from, err)
+	}
+}
+
+// ToAlert maps a syslog message onto an alert. The hostname becomes the
+// entity (the sender address when the message carries none) and the syslog
+// severity is folded onto the alert severity scale.
+func ToAlert(msg Message, sender string) models.DbAlert {
+	entity := msg.Hostname
+	if entity == "" {
+		entity = sender
+	}
+
+	details := map[string]interface{}{
+		"facility":        msg.FacilityName(),
+		"syslog_severity": msg.SeverityName(),
+		"syslog_format":   msg.Format,
+		"sender":          sender,
+	}
+	if msg.AppName != "" {
+		details["appname"] = msg.AppName
+	}
+	if msg.ProcID != "" {
+		details["procid"] = msg.ProcID
+	}
+	if msg.MsgID != "" {
+		details["msgid"] = msg.MsgID
+	}
+	for id, params := range msg.StructuredData {
+		for k, v := range params {
+			details[models.DetailKey(id+"."+k)] = v
+		}
+	}
+
+	alertType := msg.AppName
+	if alertType == "" {
+		alertType = "syslog"
+	}
+
+	alert := models.DbAlert{
+		Entity:            entity,
+		IpAddress:         sender,
+		AlertSource:       "syslog",
+		AlertSummary:      msg.Message,
+		AlertType:         alertType,
+		Severity:          alertSeverity(msg.Severity),
+		AdditionalDetails: details,
+		Fingerprint:       messageFingerprint(entity, msg),
+	}
+	if !msg.Timestamp.IsZero() {
+		alert.AlertFirstTime = models.CustomTime{Time: msg.Timestamp}
+	}
+	return alert
+}
+
+// alertSeverity folds the eight syslog severities onto CRITICAL/ERROR/WARN/INFO
+func alertSeverity(sev int) string {
+	switch {
+	case sev <= 2:
+		return "CRITICAL"
+	case sev == 3:
+		return "ERROR"
+	case sev == 4:
+		return "WARN"
+	}
+	return "INFO"
+}
+
+// messageFingerprint collapses repeats of the same line from the same host
+func messageFingerprint(entity string, msg Message) string {
+	h := sha256.New()
+	for _, part := range []string{entity, msg.FacilityName(), msg.AppName, msg.Message} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+func senderIP(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
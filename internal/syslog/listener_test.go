@@ -0,0 +1,72 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func readFrames(input string) ([]string, error) {
+	r := bufio.NewReaderSize(strings.NewReader(input), maxMessageSize)
+	var frames []string
+	for {
+		frame, err := readFrame(r)
+		if len(frame) > 0 {
+			frames = append(frames, string(frame))
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return frames, nil
+			}
+			return frames, err
+		}
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"newline framing", "<13>one\n<13>two\n", []string{"<13>one\n", "<13>two\n"}},
+		{"last line without newline", "<13>one\n<13>two", []string{"<13>one\n", "<13>two"}},
+		{"octet counting", "7 <13>one7 <13>two", []string{"<13>one", "<13>two"}},
+		{"octet counted frame holds a newline", "7 <13>a\nb", []string{"<13>a\nb"}},
+		{"mixed framing", "7 <13>one<13>two\n", []string{"<13>one", "<13>two\n"}},
+		{"digits not followed by a PRI are a line", "404 not found\n", []string{"404 not found\n"}},
+		{"leading zero is a line", "07 <13>one\n", []string{"07 <13>one\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readFrames(tt.input)
+			if err != nil {
+				t.Fatalf("readFrame error: %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("frames = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFrameRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"octet count above the limit", strconv.Itoa(maxMessageSize+1) + " <13>x"},
+		{"truncated octet counted frame", "20 <13>short"},
+		{"line longer than the limit", strings.Repeat("x", maxMessageSize+1) + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readFrames(tt.input); err == nil {
+				t.Error("readFrame succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Package syslog receives RFC 5424 and RFC 3164 syslog messages over UDP, TCP
+// and TLS and turns the interesting ones into alerts.
+package syslog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a parsed syslog message in either format.
+type Message struct {
+	Facility       int
+	Severity       int
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string
+	Message        string
+	Format         string // rfc5424 | rfc3164
+}
+
+var facilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+var severityNames = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// FacilityName returns the conventional name of the message facility.
+func (m Message) FacilityName() string {
+	if m.Facility >= 0 && m.Facility < len(facilityNames) {
+		return facilityNames[m.Facility]
+	}
+	return strconv.Itoa(m.Facility)
+}
+
+// SeverityName returns the conventional name of the message severity.
+func (m Message) SeverityName() string {
+	if m.Severity >= 0 && m.Severity < len(severityNames) {
+		return severityNames[m.Severity]
+	}
+	return strconv.Itoa(m.Severity)
+}
+
+// Parse decodes a single syslog message, detecting RFC 5424 by its version
+// field and falling back to the looser BSD (RFC 3164) format.
+func Parse(raw []byte) (Message, error) {
+	s := strings.TrimRight(string(raw), "\r\n\x00")
+	if s == "" {
+		return Message{}, errors.New("syslog: empty message")
+	}
+
+	// Messages without a PRI part are treated as user.notice (RFC 3164 4.3.3)
+	msg := Message{Facility: 1, Severity: 5}
+	if s[0] == '<' {
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			return Message{}, errors.New("syslog: malformed PRI")
+		}
+		pri, ok := parsePRI(s[1:end])
+		if !ok {
+			return Message{}, errors.New("syslog: malformed PRI")
+		}
+		msg.Facility, msg.Severity = pri/8, pri%8
+		s = s[end+1:]
+	}
+
+	if strings.HasPrefix(s, "1 ") {
+		return parse5424(msg, s[2:])
+	}
+	return parse3164(msg, s), nil
+}
+
+// parsePRI reads the PRI value: one to three ASCII digits without a sign or
+// leading zeros (RFC 5424 6.2.1), at most 191.
+func parsePRI(s string) (int, bool) {
+	if len(s) < 1 || len(s) > 3 || (len(s) > 1 && s[0] == '0') {
+		return 0, false
+	}
+	pri := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+		pri = pri*10 + int(s[i]-'0')
+	}
+	if pri > 191 {
+		return 0, false
+	}
+	return pri, true
+}
+
+func parse5424(msg Message, s string) (Message, error) {
+	msg.Format = "rfc5424"
+	fields := make([]string, 5)
+	for i := range fields {
+		sp := strings.IndexByte(s, ' ')
+		if sp < 0 {
+			return Message{}, errors.New("syslog: truncated RFC 5424 header")
+		}
+		fields[i], s = s[:sp], s[sp+1:]
+	}
+	if fields[0] != "-" {
+		t, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return Message{}, errors.New("syslog: invalid RFC 5424 timestamp")
+		}
+		msg.Timestamp = t
+	}
+	msg.Hostname = nilValue(fields[1])
+	msg.AppName = nilValue(fields[2])
+	msg.ProcID = nilValue(fields[3])
+	msg.MsgID = nilValue(fields[4])
+
+	sd, rest, err := parseStructuredData(s)
+	if err != nil {
+		return Message{}, err
+	}
+	msg.StructuredData = sd
+	msg.Message = strings.TrimPrefix(strings.TrimPrefix(rest, " "), "\ufeff")
+	return msg, nil
+}
+
+// parseStructuredData reads "-" or a run of [id name="value" ...] elements
+func parseStructuredData(s string) (map[string]map[string]string, string, error) {
+	if strings.HasPrefix(s, "-") {
+		return nil, s[1:], nil
+	}
+	sd := map[string]map[string]string{}
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+		end := strings.IndexAny(s, " ]")
+		if end < 0 {
+			return nil, "", errors.New("syslog: unterminated structured data")
+		}
+		id := s[:end]
+		params := map[string]string{}
+		s = s[end:]
+		for strings.HasPrefix(s, " ") {
+			s = s[1:]
+			eq := strings.Index(s, "=\"")
+			if eq < 0 {
+				return nil, "", errors.New("syslog: malformed structured data parameter")
+			}
+			name := s[:eq]
+			s = s[eq+2:]
+			var value strings.Builder
+			closed := false
+			for i := 0; i < len(s); i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+					value.WriteByte(s[i+1])
+					i++
+					continue
+				}
+				if s[i] == '"' {
+					s = s[i+1:]
+					closed = true
+					break
+				}
+				value.WriteByte(s[i])
+			}
+			if !closed {
+				return nil, "", errors.New("syslog: unterminated structured data value")
+			}
+			params[name] = value.String()
+		}
+		if !strings.HasPrefix(s, "]") {
+			return nil, "", errors.New("syslog: unterminated structured data element")
+		}
+		s = s[1:]
+		sd[id] = params
+	}
+	return sd, s, nil
+}
+
+// parse3164 is deliberately lenient: devices disagree on nearly every part of
+// the BSD format, so any piece that cannot be recognised is left in the message.
+func parse3164(msg Message, s string) Message {
+	msg.Format = "rfc3164"
+
+	hasTimestamp := false
+	if len(s) >= 15 {
+		if t, err := time.Parse(time.Stamp, s[:15]); err == nil {
+			hasTimestamp = true
+			now := time.Now()
+			t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+			// A December timestamp received in January belongs to last year
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			msg.Timestamp = t
+			s = strings.TrimPrefix(s[15:], " ")
+		}
+	}
+
+	// HOSTNAME follows the timestamp, unless the next token is already the TAG
+	if sp := strings.IndexByte(s, ' '); hasTimestamp && sp > 0 {
+		token := s[:sp]
+		if !strings.HasSuffix(token, ":") && !strings.Contains(token, "[") {
+			msg.Hostname = token
+			s = s[sp+1:]
+		}
+	}
+
+	// TAG is alphanumeric, optionally followed by [pid], terminated by ':'
+	if colon := strings.Index(s, ": "); colon > 0 && !strings.ContainsAny(s[:colon], " ") {
+		tag := s[:colon]
+		if open := strings.IndexByte(tag, '['); open > 0 && strings.HasSuffix(tag, "]") {
+			msg.ProcID = tag[open+1 : len(tag)-1]
+			tag = tag[:open]
+		}
+		msg.AppName = tag
+		s = s[colon+2:]
+	}
+
+	msg.Message = s
+	return msg
+}
+
+func nilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+package syslog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	stamp := time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC)
+	tests := []struct {
+		name string
+		raw  string
+		want Message
+	}{
+		{
+			name: "rfc5424",
+			raw:  "<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - 'su root' failed for lonvick on /dev/pts/8",
+			want: Message{Facility: 4, Severity: 2, Timestamp: stamp, Hostname: "mymachine.example.com", AppName: "su", MsgID: "ID47",
+				Message: "'su root' failed for lonvick on /dev/pts/8", Format: "rfc5424"},
+		},
+		{
+			name: "rfc5424 nil values and BOM",
+			raw:  "<165>1 - - - - - - \ufeffhello\n",
+			want: Message{Facility: 20, Severity: 5, Message: "hello", Format: "rfc5424"},
+		},
+		{
+			name: "rfc5424 structured data",
+			raw:  `<165>1 2003-10-11T22:14:15.003Z host app 42 ID1 [exampleSDID@32473 iut="3" eventSource="Appl\"ication"][origin ip="10.0.0.1"] msg`,
+			want: Message{Facility: 20, Severity: 5, Timestamp: stamp, Hostname: "host", AppName: "app", ProcID: "42", MsgID: "ID1",
+				StructuredData: map[string]map[string]string{
+					"exampleSDID@32473": {"iut": "3", "eventSource": `Appl"ication`},
+					"origin":            {"ip": "10.0.0.1"},
+				},
+				Message: "msg", Format: "rfc5424"},
+		},
+		{
+			name: "rfc5424 empty structured data element",
+			raw:  "<0>1 - host app - - [meta] body",
+			want: Message{Hostname: "host", AppName: "app", StructuredData: map[string]map[string]string{"meta": {}}, Message: "body", Format: "rfc5424"},
+		},
+		{
+			name: "rfc3164 tag with pid",
+			raw:  "<13>Feb  5 17:32:18 web01 sshd[4721]: Accepted publickey",
+			want: Message{Facility: 1, Severity: 5, Hostname: "web01", AppName: "sshd", ProcID: "4721", Message: "Accepted publickey", Format: "rfc3164"},
+		},
+		{
+			name: "rfc3164 without hostname",
+			raw:  "<11>Feb  5 17:32:18 kernel: oops",
+			want: Message{Facility: 1, Severity: 3, AppName: "kernel", Message: "oops", Format: "rfc3164"},
+		},
+		{
+			name: "rfc3164 without timestamp",
+			raw:  "<191>just text",
+			want: Message{Facility: 23, Severity: 7, Message: "just text", Format: "rfc3164"},
+		},
+		{
+			name: "no PRI is user.notice",
+			raw:  "plain line",
+			want: Message{Facility: 1, Severity: 5, Message: "plain line", Format: "rfc3164"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.raw))
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.raw, err)
+			}
+			// RFC 3164 timestamps depend on the current year
+			if tt.want.Format == "rfc3164" {
+				got.Timestamp = time.Time{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) =\n%+v\nwant\n%+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", "\r\n"},
+		{"negative PRI", "<-1>msg"},
+		{"signed PRI", "<+13>msg"},
+		{"leading zero", "<013>msg"},
+		{"double zero", "<00>msg"},
+		{"four digits", "<1000>msg"},
+		{"PRI above 191", "<192>msg"},
+		{"empty PRI", "<>msg"},
+		{"non-digit PRI", "<1a>msg"},
+		{"unterminated PRI", "<13 msg"},
+		{"truncated rfc5424 header", "<13>1 2003-10-11T22:14:15Z host"},
+		{"bad rfc5424 timestamp", "<13>1 yesterday host app - - - msg"},
+		{"unterminated structured data", `<13>1 - host app - - [id a="1" msg`},
+		{"unterminated structured data value", `<13>1 - host app - - [id a="1]`},
+		{"structured data parameter without value", `<13>1 - host app - - [id a] msg`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if msg, err := Parse([]byte(tt.raw)); err == nil {
+				t.Errorf("Parse(%q) = %+v, want an error", tt.raw, msg)
+			}
+		})
+	}
+}
+
+func TestParsePRIBounds(t *testing.T) {
+	for raw, want := range map[string][2]int{"<0>x": {0, 0}, "<7>x": {0, 7}, "<191>x": {23, 7}} {
+		msg, err := Parse([]byte(raw))
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", raw, err)
+		}
+		if msg.Facility != want[0] || msg.Severity != want[1] {
+			t.Errorf("Parse(%q) = facility %d severity %d, want %d %d", raw, msg.Facility, msg.Severity, want[0], want[1])
+		}
+	}
+}
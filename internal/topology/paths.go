@@ -0,0 +1,149 @@
+package topology
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+)
+
+const pathTimeout = 5 * time.Second
+
+// Relations of a linked entity to the one the search started from
+const (
+	Upstream   = "upstream"   // the start depends on it, directly or through others
+	Downstream = "downstream" // it depends on the start
+	Related    = "related"    // connected, but neither depends on the other
+)
+
+// Link is the shortest path from one entity to another.
+type Link struct {
+	Hops     int
+	Path     string // e.g. "vm-12 -[RUNS_ON]-> host-3 -[CONNECTS_TO]-> sw-1"
+	Relation string
+}
+
+// PathOptions limit which paths Links follows. With UpstreamOut true,
+// relationships point from an entity to what it depends on.
+type PathOptions struct {
+	MaxHops           int
+	RelationshipTypes []string // empty means any
+	UpstreamOut       bool
+}
+
+// Available reports whether Neo4j is configured.
+func Available() bool {
+	return db.Neo4jDriver != nil
+}
+
+// Links returns the shortest path from entity to each of targets within
+// opts, keyed by the lower-cased target. Targets that are not reachable are
+// left out; a target naming entity itself is a link of zero hops.
+func Links(ctx context.Context, entity string, targets []string, opts PathOptions) (map[string]Link, error) {
+	out := map[string]Link{}
+	var others []string
+	for _, t := range targets {
+		t = strings.ToLower(t)
+		if t == strings.ToLower(entity) {
+			out[t] = Link{Path: entity, Relation: Related}
+			continue
+		}
+		others = append(others, t)
+	}
+	if len(others) == 0 || opts.MaxHops <= 0 {
+		return out, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pathTimeout)
+	defer cancel()
+
+	session := db.GetNeo4jDriver().NewSession(ctx, neo4j.SessionConfig{DatabaseName: "neo4j", AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	// Variable-length bounds cannot be parameters
+	cypher := fmt.Sprintf(`
+	MATCH (src)
+	WHERE toLower(src.name) = toLower($entity) OR toLower(src.id) = toLower($entity)
+	WITH src LIMIT 1
+	UNWIND $targets AS target
+	MATCH (dst)
+	WHERE (toLower(dst.name) = target OR toLower(dst.id) = target) AND dst <> src
+	WITH src, target, head(collect(dst)) AS dst
+	MATCH p = shortestPath((src)-[*1..%d]-(dst))
+	WHERE size($types) = 0 OR all(r IN relationships(p) WHERE type(r) IN $types)
+	RETURN
+		target,
+		[n IN nodes(p) | coalesce(n.name, n.id)] AS names,
+		[r IN relationships(p) | type(r)] AS types,
+		[i IN range(0, length(p) - 1) | startNode(relationships(p)[i]) = nodes(p)[i]] AS forward
+	`, opts.MaxHops)
+
+	types := opts.RelationshipTypes
+	if types == nil {
+		types = []string{}
+	}
+	result, err := session.Run(ctx, cypher, map[string]interface{}{
+		"entity":  entity,
+		"targets": others,
+		"types":   types,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for result.Next(ctx) {
+		rec := result.Record()
+		target, _ := rec.Values[0].(string)
+		names, _ := rec.Values[1].([]interface{})
+		rels, _ := rec.Values[2].([]interface{})
+		forward, _ := rec.Values[3].([]interface{})
+		if target == "" || len(rels) == 0 || len(names) != len(rels)+1 || len(forward) != len(rels) {
+			continue
+		}
+		out[target] = newLink(names, rels, forward, opts.UpstreamOut)
+	}
+	return out, result.Err()
+}
+
+// LinkTo returns the shortest path from entity to target within opts; ok is
+// false when target is not reachable.
+func LinkTo(ctx context.Context, entity, target string, opts PathOptions) (Link, bool, error) {
+	links, err := Links(ctx, entity, []string{target}, opts)
+	if err != nil {
+		return Link{}, false, err
+	}
+	link, ok := links[strings.ToLower(target)]
+	return link, ok, nil
+}
+
+// newLink describes a path. A path that only follows relationships toward
+// what entities depend on leads upstream; one that only goes against them
+// leads downstream.
+func newLink(names, rels, forward []interface{}, upstreamOut bool) Link {
+	var b strings.Builder
+	b.WriteString(propString(names[0]))
+	ahead, back := 0, 0
+	for i, r := range rels {
+		if f, _ := forward[i].(bool); f {
+			ahead++
+			fmt.Fprintf(&b, " -[%s]-> %s", propString(r), propString(names[i+1]))
+		} else {
+			back++
+			fmt.Fprintf(&b, " <-[%s]- %s", propString(r), propString(names[i+1]))
+		}
+	}
+	if !upstreamOut {
+		ahead, back = back, ahead
+	}
+
+	link := Link{Hops: len(rels), Path: b.String(), Relation: Related}
+	switch {
+	case back == 0:
+		link.Relation = Upstream
+	case ahead == 0:
+		link.Relation = Downstream
+	}
+	return link
+}
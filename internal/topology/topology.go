@@ -0,0 +1,236 @@
+// Package topology enriches alerts with what Neo4j knows about their entity:
+// support owner, node label, tier, datacenter and parent application.
+// Lookups are cached in Redis so repeated alerts do not hit Neo4j.
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/db"
+	"github.com/ruby4mag/alertmanager-go-backend-ui/internal/models"
+)
+
+// AdditionalDetails keys written by Stamp
+const (
+	KeyOwner       = "owner"
+	KeyLabel       = "node_label"
+	KeyTier        = "tier"
+	KeyDatacenter  = "datacenter"
+	KeyApplication = "application"
+)
+
+const (
+	cachePrefix   = "topology:entity:"
+	lookupTimeout = 2 * time.Second
+
+	// retryAfter is how long lookups are skipped after Neo4j failed, so
+	// ingestion does not wait lookupTimeout on every alert while it is down
+	retryAfter = 30 * time.Second
+
+	// maxHops bounds the search for the parent application and datacenter
+	maxHops = 3
+)
+
+var (
+	downMu    sync.Mutex
+	downUntil time.Time
+)
+
+// errUnavailable is returned by Lookup while Neo4j is considered down.
+var errUnavailable = errors.New("neo4j unavailable, retrying later")
+
+// Info is the topology of one entity. Found is false for entities Neo4j does
+// not know; those are cached too.
+type Info struct {
+	Found       bool   `json:"found"`
+	Owner       string `json:"owner,omitempty"`
+	Label       string `json:"label,omitempty"`
+	Tier        string `json:"tier,omitempty"`
+	Datacenter  string `json:"datacenter,omitempty"`
+	Application string `json:"application,omitempty"`
+}
+
+// Details returns the non-empty fields by their AdditionalDetails key.
+func (i Info) Details() map[string]string {
+	out := map[string]string{}
+	for key, value := range map[string]string{
+		KeyOwner:       i.Owner,
+		KeyLabel:       i.Label,
+		KeyTier:        i.Tier,
+		KeyDatacenter:  i.Datacenter,
+		KeyApplication: i.Application,
+	} {
+		if value != "" {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// Enabled reports whether alerts are enriched: Neo4j is configured and
+// TOPOLOGY_ENRICHMENT is not "false".
+func Enabled() bool {
+	return db.Neo4jDriver != nil && !strings.EqualFold(os.Getenv("TOPOLOGY_ENRICHMENT"), "false")
+}
+
+func cacheTTL() time.Duration {
+	if s := os.Getenv("TOPOLOGY_CACHE_TTL"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("Ignoring TOPOLOGY_CACHE_TTL %q", s)
+	}
+	return 5 * time.Minute
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Resolver looks entities up for one ingestion call, remembering the results
+// so a batch resolves each entity once.
+type Resolver struct {
+	seen map[string]Info
+}
+
+// NewResolver returns an empty Resolver.
+func NewResolver() *Resolver {
+	return &Resolver{seen: map[string]Info{}}
+}
+
+// Stamp writes the topology of alert.Entity into AdditionalDetails and
+// returns what it wrote. Values the alert already carries are kept, and a
+// failed lookup leaves the alert as it is.
+func (r *Resolver) Stamp(ctx context.Context, alert *models.DbAlert) map[string]string {
+	if alert.Entity == "" || !Enabled() {
+		return nil
+	}
+	key := strings.ToLower(alert.Entity)
+	info, ok := r.seen[key]
+	if !ok {
+		var err error
+		if info, err = Lookup(ctx, alert.Entity); err != nil {
+			if err != errUnavailable {
+				log.Printf("Topology lookup of %s failed: %v", alert.Entity, err)
+			}
+			return nil
+		}
+		r.seen[key] = info
+	}
+
+	written := map[string]string{}
+	for k, v := range info.Details() {
+		if existing, ok := alert.DetailValue(k); ok && existing != nil && existing != "" {
+			continue
+		}
+		alert.SetDetail(k, v)
+		written[k] = v
+	}
+	return written
+}
+
+// Lookup returns the topology of entity, from Redis when cached. After a
+// failed query, entities not cached fail fast for retryAfter.
+func Lookup(ctx context.Context, entity string) (Info, error) {
+	key := cachePrefix + strings.ToLower(entity)
+
+	rctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	cached, err := db.RedisClient.Get(rctx, key).Bytes()
+	cancel()
+	var info Info
+	if err == nil && json.Unmarshal(cached, &info) == nil {
+		return info, nil
+	}
+
+	downMu.Lock()
+	down := time.Now().Before(downUntil)
+	downMu.Unlock()
+	if down {
+		return Info{}, errUnavailable
+	}
+	if info, err = query(ctx, entity); err != nil {
+		downMu.Lock()
+		downUntil = time.Now().Add(retryAfter)
+		downMu.Unlock()
+		return Info{}, err
+	}
+	if raw, err := json.Marshal(info); err == nil {
+		rctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		db.RedisClient.Set(rctx, key, raw, cacheTTL())
+		cancel()
+	}
+	return info, nil
+}
+
+// query reads the entity node, matched by name or id like the entity graph,
+// and the nearest application and datacenter nodes around it. Owner and tier
+// fall back to the application's.
+func query(ctx context.Context, entity string) (Info, error) {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	session := db.GetNeo4jDriver().NewSession(ctx, neo4j.SessionConfig{DatabaseName: "neo4j", AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	cypher := fmt.Sprintf(`
+	MATCH (n)
+	WHERE toLower(n.name) = toLower($entity) OR toLower(n.id) = toLower($entity)
+	WITH n LIMIT 1
+	OPTIONAL MATCH pa = (n)-[*1..%[1]d]-(app)
+	WHERE $appLabel IN labels(app)
+	WITH n, app, length(pa) AS appHops ORDER BY appHops LIMIT 1
+	OPTIONAL MATCH pd = (n)-[*1..%[1]d]-(dc)
+	WHERE $dcLabel IN labels(dc)
+	WITH n, app, dc, length(pd) AS dcHops ORDER BY dcHops LIMIT 1
+	WITH n, CASE WHEN $appLabel IN labels(n) THEN n ELSE app END AS app, dc
+	RETURN
+		labels(n) AS labels,
+		coalesce(n.support_owner, app.support_owner) AS owner,
+		coalesce(n.tier, app.tier) AS tier,
+		coalesce(n.datacenter, dc.name, app.datacenter) AS datacenter,
+		app.name AS application
+	`, maxHops)
+
+	params := map[string]interface{}{
+		"entity":   entity,
+		"appLabel": envOr("TOPOLOGY_APPLICATION_LABEL", "Application"),
+		"dcLabel":  envOr("TOPOLOGY_DATACENTER_LABEL", "Datacenter"),
+	}
+	result, err := session.Run(ctx, cypher, params)
+	if err != nil {
+		return Info{}, err
+	}
+	if !result.Next(ctx) {
+		return Info{}, result.Err()
+	}
+	rec := result.Record()
+
+	info := Info{Found: true}
+	if labels, ok := rec.Values[0].([]interface{}); ok && len(labels) > 0 {
+		info.Label = fmt.Sprint(labels[0])
+	}
+	info.Owner = propString(rec.Values[1])
+	info.Tier = propString(rec.Values[2])
+	info.Datacenter = propString(rec.Values[3])
+	info.Application = propString(rec.Values[4])
+	return info, nil
+}
+
+func propString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}